@@ -0,0 +1,140 @@
+package pkgimporters
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Default circuit breaker values used when the fields of CircuitBreaker are zero.
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// CircuitBreaker halts requests to a Source after consecutive transient failures,
+// e.g., during a pkg.go.dev outage.
+// Once open, requests wait for Cooldown, then a single probe request is let through:
+// if it succeeds, the circuit closes and requests resume; otherwise it stays open for another Cooldown.
+// A CircuitBreaker must not be copied after first use.
+type CircuitBreaker struct {
+	// Threshold is the number of consecutive failures that opens the circuit.
+	// If zero, DefaultBreakerThreshold is used.
+	Threshold int
+
+	// Cooldown is how long the circuit stays open before a probe request.
+	// If zero, DefaultBreakerCooldown is used.
+	Cooldown time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	openUntil time.Time
+	probing   bool
+	changed   chan struct{} // closed and replaced on each state change
+}
+
+// allow blocks until a request may be made.
+// It reports whether the request is the probe of an open circuit.
+// A nil CircuitBreaker allows all requests.
+func (b *CircuitBreaker) allow(ctx context.Context) (probe bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	for {
+		b.mu.Lock()
+		if !b.open {
+			b.mu.Unlock()
+			return false, nil
+		}
+		wait := time.Until(b.openUntil)
+		if wait <= 0 && !b.probing {
+			b.probing = true
+			b.mu.Unlock()
+			return true, nil
+		}
+		if b.changed == nil {
+			b.changed = make(chan struct{})
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		// Wait for the cooldown to end, or for the probe to finish if it already has.
+		if err := b.sleep(ctx, wait, changed); err != nil {
+			return false, err
+		}
+	}
+}
+
+func (b *CircuitBreaker) sleep(ctx context.Context, d time.Duration, changed <-chan struct{}) error {
+	var timeout <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-timeout:
+		return nil
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// record updates the circuit with the outcome of a request
+// and reports whether the circuit opened or closed as a result.
+// Transient failures count toward Threshold; other outcomes mean the Source is healthy.
+func (b *CircuitBreaker) record(probe bool, err error) (opened, closed bool) {
+	if b == nil {
+		return false, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.broadcast()
+
+	if probe {
+		b.probing = false
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return false, false
+	case err == nil || !isRetryable(err):
+		b.failures = 0
+		if b.open {
+			b.open = false
+			return false, true
+		}
+		return false, false
+	}
+
+	b.failures++
+	if probe || (!b.open && b.failures >= cmp.Or(b.Threshold, DefaultBreakerThreshold)) {
+		b.open = true
+		b.openUntil = time.Now().Add(cmp.Or(b.Cooldown, DefaultBreakerCooldown))
+		return true, false
+	}
+	return false, false
+}
+
+// broadcast wakes up the requests waiting in allow. b.mu must be held.
+func (b *CircuitBreaker) broadcast() {
+	if b.changed != nil {
+		close(b.changed)
+		b.changed = nil
+	}
+}
+
+// recordBreaker records the outcome of a request in c.Breaker and logs state changes.
+func (c *Client) recordBreaker(ctx context.Context, probe bool, err error) {
+	opened, closed := c.Breaker.record(probe, err)
+	switch {
+	case opened:
+		c.logger().WarnContext(ctx, "circuit breaker open, pausing requests", "cooldown", cmp.Or(c.Breaker.Cooldown, DefaultBreakerCooldown), "err", err)
+	case closed:
+		c.logger().InfoContext(ctx, "circuit breaker closed, resuming requests")
+	}
+}
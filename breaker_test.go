@@ -0,0 +1,96 @@
+package pkgimporters
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	errServer := &StatusError{StatusCode: http.StatusInternalServerError, Status: "500 Internal Server Error"}
+	b := &CircuitBreaker{Threshold: 2, Cooldown: 50 * time.Millisecond}
+
+	if opened, _ := b.record(false, errServer); opened {
+		t.Fatal("circuit opened before reaching the threshold")
+	}
+	if _, closed := b.record(false, ErrNotFound); closed {
+		t.Fatal("circuit closed while not open")
+	}
+	b.record(false, errServer)
+	if opened, _ := b.record(false, errServer); !opened {
+		t.Fatal("expected the circuit to open after 2 consecutive failures")
+	}
+
+	// The first request after the cooldown is the probe; a failing probe reopens the circuit.
+	start := time.Now()
+	probe, err := b.allow(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !probe {
+		t.Fatal("expected a probe request")
+	}
+	if waited := time.Since(start); waited < 40*time.Millisecond {
+		t.Errorf("expected allow to wait for the cooldown, waited %v", waited)
+	}
+	if opened, _ := b.record(true, errServer); !opened {
+		t.Fatal("expected a failed probe to reopen the circuit")
+	}
+
+	// Requests wait while the probe is in flight and resume once it succeeds.
+	probe, err = b.allow(t.Context())
+	if err != nil || !probe {
+		t.Fatalf("expected a probe request, got probe=%v err=%v", probe, err)
+	}
+	allowed := make(chan bool)
+	go func() {
+		probe, _ := b.allow(context.Background())
+		allowed <- probe
+	}()
+	select {
+	case <-allowed:
+		t.Fatal("request allowed while the probe is in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if _, closed := b.record(true, nil); !closed {
+		t.Fatal("expected a successful probe to close the circuit")
+	}
+	if probe := <-allowed; probe {
+		t.Error("expected a regular request after the circuit closed")
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	b.record(false, errServer)
+	b.record(false, errServer)
+	if _, err := b.allow(ctx); err == nil {
+		t.Error("expected allow to fail when the context is canceled")
+	}
+}
+
+func TestClientBreaker(t *testing.T) {
+	calls := 0
+	client := &Client{
+		RequestsPerSecond: 100,
+		Retry:             RetryPolicy{MaxAttempts: 1},
+		Breaker:           &CircuitBreaker{Threshold: 1, Cooldown: time.Hour},
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			calls++
+			return 0, &StatusError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}
+		}),
+	}
+
+	if _, err := client.ImporterCount(t.Context(), "fmt"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 300*time.Millisecond)
+	defer cancel()
+	if _, err := client.ImporterCount(ctx, "io"); err == nil {
+		t.Fatal("expected an error while the circuit is open")
+	}
+	if calls != 1 {
+		t.Errorf("expected no requests while the circuit is open, got %d", calls)
+	}
+}
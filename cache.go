@@ -0,0 +1,141 @@
+package pkgimporters
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long Client caches importer counts when Client.CacheTTL is zero.
+const DefaultCacheTTL = time.Hour
+
+// ErrNotCached is returned by a Client in offline mode for packages missing from its Cache.
+var ErrNotCached = errors.New("package not cached")
+
+// CacheEntry is a cached importer count.
+type CacheEntry struct {
+	Count     int
+	FetchedAt time.Time
+
+	// Validators of the response the count was scraped from,
+	// used to revalidate an expired entry with a conditional request.
+	Validators Validators
+
+	// CanonicalPath is the path the package was redirected to, if any.
+	CanonicalPath string
+
+	// Modules is the number of unique importing modules, if the source reported it.
+	Modules int
+
+	// Examples are the paths of some of the importers, if the source reported them.
+	Examples []string
+
+	// License is the license of the package, if the source reported it.
+	License string
+
+	// Version is the latest version of the module of the package and Published its publish time,
+	// if the source reported them.
+	Version   string
+	Published time.Time
+
+	// Imports is the number of packages the package imports, if the source reported it.
+	Imports *int
+
+	// Redistributable reports whether the package may be redistributed, if the source reported it.
+	Redistributable *bool
+}
+
+// result returns the result for pkgPath holding the cached data of e.
+func (e CacheEntry) result(pkgPath string) Result {
+	return Result{
+		Path:            pkgPath,
+		Count:           e.Count,
+		CanonicalPath:   e.CanonicalPath,
+		Modules:         e.Modules,
+		Examples:        e.Examples,
+		License:         e.License,
+		Version:         e.Version,
+		Published:       e.Published,
+		Imports:         e.Imports,
+		Redistributable: e.Redistributable,
+	}
+}
+
+// Cache stores importer counts by package path.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the entry for the package path.
+	// It reports false if there is no entry or the entry has expired.
+	Get(ctx context.Context, pkgPath string) (CacheEntry, bool, error)
+
+	// Set stores the entry for the package path for the ttl duration.
+	Set(ctx context.Context, pkgPath string, entry CacheEntry, ttl time.Duration) error
+}
+
+// StaleCache is a Cache that can also return expired entries.
+// A Client in offline mode uses it to report stale counts instead of failing.
+type StaleCache interface {
+	Cache
+
+	// GetStale returns the entry for the package path even if it has expired.
+	// It reports false if there is no entry.
+	GetStale(ctx context.Context, pkgPath string) (CacheEntry, bool, error)
+}
+
+// MemoryCache is a Cache that keeps entries in memory.
+// Expired entries are kept for revalidation as long again as their TTL, then removed by later calls to Set.
+// The zero value is an empty cache ready to use.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	sets    int // calls to Set since entries were last swept
+}
+
+type memoryCacheEntry struct {
+	CacheEntry
+	expires time.Time
+	evicts  time.Time
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(_ context.Context, pkgPath string) (CacheEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[pkgPath]
+	if !ok || !time.Now().Before(e.expires) {
+		return CacheEntry{}, false, nil
+	}
+	return e.CacheEntry, true, nil
+}
+
+// GetStale implements StaleCache.
+func (c *MemoryCache) GetStale(_ context.Context, pkgPath string) (CacheEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[pkgPath]
+	return e.CacheEntry, ok, nil
+}
+
+// Set implements Cache.
+func (c *MemoryCache) Set(_ context.Context, pkgPath string, entry CacheEntry, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]memoryCacheEntry)
+	}
+	now := time.Now()
+	// Sweeping once per as many calls as there are entries keeps Set amortized constant time.
+	if c.sets++; c.sets >= len(c.entries) {
+		c.sets = 0
+		maps.DeleteFunc(c.entries, func(_ string, e memoryCacheEntry) bool {
+			return !now.Before(e.evicts)
+		})
+	}
+	c.entries[pkgPath] = memoryCacheEntry{CacheEntry: entry, expires: now.Add(ttl), evicts: now.Add(2 * max(ttl, 0))}
+	return nil
+}
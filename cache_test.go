@@ -0,0 +1,61 @@
+package pkgimporters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	var c MemoryCache
+	ctx := t.Context()
+
+	if _, ok, err := c.Get(ctx, "fmt"); err != nil || ok {
+		t.Fatalf("expected miss on empty cache, got ok=%t err=%v", ok, err)
+	}
+
+	entry := CacheEntry{Count: 42, FetchedAt: time.Now()}
+	if err := c.Set(ctx, "fmt", entry, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := c.Get(ctx, "fmt")
+	if err != nil || !ok {
+		t.Fatalf("expected hit, got ok=%t err=%v", ok, err)
+	}
+	if got.Count != 42 {
+		t.Errorf("expected count 42, got %d", got.Count)
+	}
+
+	if err := c.Set(ctx, "io", entry, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "io"); ok {
+		t.Error("expected miss for expired entry")
+	}
+}
+
+func TestMemoryCacheSweep(t *testing.T) {
+	var c MemoryCache
+	ctx := t.Context()
+
+	entry := CacheEntry{Count: 42, FetchedAt: time.Now()}
+	if err := c.Set(ctx, "io", entry, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(15 * time.Millisecond)
+	if _, ok, _ := c.GetStale(ctx, "io"); !ok {
+		t.Error("expected expired entry to be kept for revalidation")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	for _, path := range []string{"fmt", "os"} {
+		if err := c.Set(ctx, path, entry, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok, _ := c.GetStale(ctx, "io"); ok {
+		t.Error("expected entry expired for longer than its TTL to be removed")
+	}
+	if len(c.entries) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(c.entries))
+	}
+}
@@ -0,0 +1,122 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRecorded is returned by a Replay transport for requests without a recorded response.
+var ErrNotRecorded = errors.New("no recorded response")
+
+// cassette is a recorded HTTP exchange, stored as a JSON file by Record and read by Replay.
+type cassette struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Record returns a middleware that saves every response, with its status, headers, and body,
+// as a JSON file in dir, so a run can later be repeated without network access by Replay.
+// Responses are keyed by the method, URL, and body of the request; a later response replaces an earlier one.
+func Record(dir string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			name, err := cassetteFile(dir, req)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+
+			data, err := json.MarshalIndent(cassette{
+				Method: req.Method,
+				URL:    req.URL.String(),
+				Status: resp.StatusCode,
+				Header: resp.Header,
+				Body:   string(body),
+			}, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			if err := writeFileAtomic(name, data); err != nil {
+				return nil, fmt.Errorf("record response: %w", err)
+			}
+			return resp, nil
+		})
+	}
+}
+
+// Replay returns a transport that responds to requests with the responses recorded in dir by Record,
+// without network access, so tests and demos run deterministically.
+// It returns an error wrapping ErrNotRecorded for requests without a recorded response.
+func Replay(dir string) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		name, err := cassetteFile(dir, req)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w for %s %s in %s", ErrNotRecorded, req.Method, req.URL, dir)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var c cassette
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", name, err)
+		}
+		if c.Header == nil {
+			c.Header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", c.Status, http.StatusText(c.Status)),
+			StatusCode:    c.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        c.Header,
+			Body:          io.NopCloser(strings.NewReader(c.Body)),
+			ContentLength: int64(len(c.Body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+// cassetteFile returns the name of the file in dir holding the response to req,
+// named after a hash of its method, URL, and body.
+func cassetteFile(dir string, req *http.Request) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		if _, err := io.Copy(h, body); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, req.URL.Host, hex.EncodeToString(h.Sum(nil))[:32]+".json"), nil
+}
@@ -0,0 +1,37 @@
+package pkgimporters
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`<div class="ImportedBy"><strong>Known importers:</strong> 1,234</div>`))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	recorder := &Client{BaseURL: server.URL, Middleware: []Middleware{Record(dir)}}
+	if count, err := recorder.ImporterCount(t.Context(), "fmt"); err != nil || count != 1234 {
+		t.Fatalf("expected 1234 importers when recording, got %d, %v", count, err)
+	}
+	server.Close()
+
+	replayer := &Client{BaseURL: server.URL, HTTPClient: &http.Client{Transport: Replay(dir)}}
+	if count, err := replayer.ImporterCount(t.Context(), "fmt"); err != nil || count != 1234 {
+		t.Fatalf("expected 1234 importers when replaying, got %d, %v", count, err)
+	}
+	if requests != 1 {
+		t.Errorf("expected a single request to the server, got %d", requests)
+	}
+
+	if _, err := replayer.ImporterCount(t.Context(), "io"); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded for a package that was not recorded, got %v", err)
+	}
+}
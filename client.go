@@ -0,0 +1,682 @@
+// Package pkgimporters fetches the number of known importers for Go packages from pkg.go.dev.
+//
+// A zero Client is ready to use:
+//
+//	var c pkgimporters.Client
+//	count, err := c.ImporterCount(ctx, "fmt")
+package pkgimporters
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+)
+
+// DefaultWorkers is the number of concurrent requests made by Client.ImporterCounts
+// and Client.Stream when Client.Workers is zero.
+const DefaultWorkers = 5
+
+// DefaultRequestsPerSecond and DefaultBurst configure the rate limiter of a Client
+// when Client.RequestsPerSecond and Client.Burst are zero.
+const (
+	DefaultRequestsPerSecond = 1
+	DefaultBurst             = 3
+)
+
+// DefaultTimeout is the timeout of each request to the Source when Client.Timeout is zero.
+const DefaultTimeout = 15 * time.Second
+
+// DefaultBaseURL is the pkgsite instance used when Client.BaseURL is empty.
+const DefaultBaseURL = "https://pkg.go.dev"
+
+// ErrNotFound is returned when a Source does not know the requested package.
+var ErrNotFound = errors.New("package not found")
+
+// Source reports the number of known importers of a package.
+// Implementations return an error wrapping ErrNotFound for unknown packages.
+type Source interface {
+	Count(ctx context.Context, pkgPath string) (int, error)
+}
+
+// ConditionalSource is a Source that supports conditional requests,
+// so an expired cached count can be revalidated without downloading it again.
+type ConditionalSource interface {
+	Source
+
+	// CountIfModified is like Count, but sends the validators of a previous response
+	// and reports details of the response.
+	CountIfModified(ctx context.Context, pkgPath string, prev Validators) (Response, error)
+}
+
+// Response is the response of a ConditionalSource.
+type Response struct {
+	// Count is the number of known importers. It is meaningless if NotModified is set.
+	Count int
+
+	// Validators of the response for the next conditional request.
+	Validators Validators
+
+	// NotModified reports that the upstream confirmed the previous count is still current.
+	NotModified bool
+
+	// CanonicalPath is the path the package was redirected to, e.g., after its repository was renamed,
+	// or empty if the package was not redirected. Count is the count at the new path.
+	CanonicalPath string
+
+	// Modules is the number of unique modules among the importers, or 0 if the source does not report it.
+	Modules int
+
+	// Examples are the paths of some of the importers, if the source reports them.
+	Examples []string
+
+	// License is the license of the package, if the source reports it, see Result.License.
+	License string
+
+	// Version is the latest version of the module of the package and Published the time it was published,
+	// if the source reports them.
+	Version   string
+	Published time.Time
+
+	// Imports is the number of packages the package imports, or nil if the source does not report it.
+	Imports *int
+
+	// Redistributable reports whether the package may be redistributed under its licenses,
+	// or is nil if the source does not report it.
+	Redistributable *bool
+
+	// Parser names the strategy that extracted Count from the upstream page, if the source has several.
+	Parser string
+
+	// Fallback reports that Parser is not the source's primary strategy,
+	// a sign that the upstream markup changed.
+	Fallback bool
+}
+
+// Validators identify a version of an upstream response, as sent in the ETag and Last-Modified headers.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// IsZero reports whether v holds no validators.
+func (v Validators) IsZero() bool {
+	return v == Validators{}
+}
+
+// Client fetches importer counts from a Source, pkg.go.dev by default.
+// Requests are rate limited to 1 per second with a burst of 3 by default.
+// Expired cached counts are revalidated with conditional requests
+// if the Source is a ConditionalSource and the Cache is a StaleCache.
+// Transient failures are retried according to Retry.
+// Throttled requests (429 or 503 responses) are retried after any Retry-After delay at a halved rate,
+// which recovers gradually as requests succeed.
+// A Client is safe for concurrent use; its fields must not be modified after first use.
+type Client struct {
+	// HTTPClient is used to make requests to pkg.go.dev.
+	// If nil, a client with a dedicated transport from NewTransport is used.
+	HTTPClient *http.Client
+
+	// Middleware wraps the transport of HTTPClient, with the first middleware being the outermost.
+	// It applies to requests to pkg.go.dev, not to custom Sources.
+	Middleware []Middleware
+
+	// BaseURL is the URL of the pkgsite instance used by Search and the default Source,
+	// e.g., a private deployment. If empty, DefaultBaseURL is used.
+	BaseURL string
+
+	// Source provides the importer counts.
+	// If nil, a PkgGoDev source using HTTPClient and BaseURL is used.
+	Source Source
+
+	// Enrichers add metadata from other services to each fetched or cached result, in order.
+	// They are skipped in offline mode, and each call is limited by Timeout.
+	Enrichers []Enricher
+
+	// Cache stores fetched counts so repeated lookups skip the Source.
+	// If nil, an in-memory cache private to the Client is used.
+	Cache Cache
+
+	// CacheTTL is how long fetched counts are cached. If zero, DefaultCacheTTL is used.
+	CacheTTL time.Duration
+
+	// Offline, if true, answers exclusively from Cache without making any requests.
+	// Expired entries are returned with Result.Stale set if Cache is a StaleCache,
+	// and packages missing from Cache fail with an error wrapping ErrNotCached.
+	Offline bool
+
+	// OnRequest, if non-nil, is called before each request to the Source.
+	// Cached counts do not trigger it. It may be called concurrently from multiple goroutines.
+	OnRequest func(pkgPath string)
+
+	// OnResult, if non-nil, is called with the outcome of each ImporterCount call,
+	// including cached counts and errors. It may be called concurrently from multiple goroutines.
+	OnResult func(r Result, err error)
+
+	// OnRetry, if non-nil, is called before a failed request is retried,
+	// with the attempt number starting at 1 and the error that caused the retry.
+	// It may be called concurrently from multiple goroutines.
+	OnRetry func(pkgPath string, attempt int, err error)
+
+	// TracerProvider, if non-nil, is used to record OpenTelemetry spans
+	// for each package lookup and each request to the Source.
+	TracerProvider trace.TracerProvider
+
+	// Metrics, if non-nil, records metrics about requests and cache usage,
+	// e.g., in Prometheus with the prommetrics package.
+	Metrics Metrics
+
+	// Logger, if non-nil, receives debug logs about cache hits, rate limit waits, and requests.
+	Logger *slog.Logger
+
+	// RequestsPerSecond is the maximum sustained rate of requests.
+	// If zero, DefaultRequestsPerSecond is used.
+	RequestsPerSecond float64
+
+	// Burst is the maximum number of requests made at once before RequestsPerSecond applies.
+	// If zero, DefaultBurst is used.
+	Burst int
+
+	// Retry configures retries of transient failures:
+	// timeouts, connection resets, 5xx responses, and throttling.
+	Retry RetryPolicy
+
+	// Breaker, if non-nil, halts requests to the Source after consecutive transient failures
+	// until a probe request succeeds.
+	Breaker *CircuitBreaker
+
+	// Timeout limits each request to the Source, including reading the response.
+	// If zero, DefaultTimeout is used. A hedged request shares the timeout of the request it hedges.
+	Timeout time.Duration
+
+	// HedgeDelay, if positive, hedges slow requests: if a request to the Source has not completed
+	// after HedgeDelay, a second request for the same package is made as soon as the rate limiter allows,
+	// and the first successful response wins, canceling the other request.
+	HedgeDelay time.Duration
+
+	// Workers is the number of concurrent requests made by ImporterCounts and Stream.
+	// If zero, DefaultWorkers is used. If AutoWorkers, the number is derived from
+	// RequestsPerSecond and the measured request latency.
+	Workers int
+
+	initOnce    sync.Once
+	limiter     *rate.Limiter
+	limitMu     sync.Mutex // guards adaptive changes to the limiter rate and slowedAt
+	slowedAt    time.Time
+	http        *http.Client
+	memoryCache MemoryCache
+	flight      singleflight.Group
+	latency     atomic.Int64 // moving average of request latency in nanoseconds, for AutoWorkers
+}
+
+// Result is the number of known importers of a package.
+type Result struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+
+	// Status classifies the outcome of the fetch. Count is 0 unless it is StatusOK.
+	Status Status `json:"status"`
+
+	// CanonicalPath is the path pkg.go.dev redirected Path to, e.g., after a repository rename,
+	// or empty if Path was not redirected. Count is the count at CanonicalPath.
+	CanonicalPath string `json:"canonical_path,omitempty"`
+
+	// Modules is the number of unique modules among the importers, if the source reports it.
+	// It reflects adoption better than Count, which counts every importing package of a module.
+	Modules int `json:"modules,omitempty"`
+
+	// Examples are the paths of the first few importers, if the source reports them,
+	// giving context about who uses the package.
+	Examples []string `json:"examples,omitempty"`
+
+	// License is the SPDX identifier of the license of the package, e.g., "BSD-3-Clause",
+	// comma-separated if there are several, or "NONE" if no license was detected, if the source reports it.
+	License string `json:"license,omitempty"`
+
+	// Version is the latest version of the module providing the package, e.g., "v1.8.0",
+	// and Published the time it was published, if the source reports them,
+	// showing whether the package is still actively released.
+	Version   string    `json:"version,omitempty"`
+	Published time.Time `json:"published,omitzero"`
+
+	// Imports is the number of packages the package imports, if the source reports it,
+	// telling lightweight packages from heavy ones. It is nil if unknown, as 0 is a meaningful count.
+	Imports *int `json:"imports,omitempty"`
+
+	// Redistributable reports whether pkg.go.dev considers the package redistributable under its licenses,
+	// if the source reports it; pkg.go.dev hides the documentation of packages that are not,
+	// a signal for compliance-sensitive users. It is nil if unknown.
+	Redistributable *bool `json:"redistributable,omitempty"`
+
+	// Vulns are the IDs of the known vulnerabilities of the package, if an OSV enricher reports them.
+	Vulns []string `json:"vulns,omitempty"`
+
+	// GitHub is the popularity of the GitHub repository hosting the package, if a GitHubStars enricher reports it.
+	GitHub *GitHubRepo `json:"github,omitempty"`
+
+	// Scorecard is the aggregate OpenSSF Scorecard score of the repository hosting the package, from 0 to 10,
+	// if a Scorecard enricher reports it.
+	Scorecard *float64 `json:"scorecard,omitempty"`
+
+	// AgeDays is the number of days since the latest version of the module providing the package was published,
+	// if a ReleaseAge enricher reports it.
+	AgeDays *int `json:"age_days,omitempty"`
+
+	// Stale reports that the count is an expired cache entry served in offline mode.
+	Stale bool `json:"stale,omitempty"`
+
+	// Time is when the run that fetched the count started, if the caller records it,
+	// e.g., when appending the results of scheduled runs to a file to build a time series.
+	Time time.Time `json:"time,omitzero"`
+
+	// Error, if non-empty, describes why the count could not be fetched.
+	// Callers that keep going after failed packages set it, and renderers report it in place of the count.
+	Error string `json:"error,omitempty"`
+}
+
+// PackageError records an error and the package path that caused it.
+type PackageError struct {
+	Path string
+	Err  error
+}
+
+func (e *PackageError) Error() string {
+	return "fetch " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *PackageError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is returned by Sources when the server responds with an unexpected HTTP status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+
+	// RetryAfter is the delay requested by the Retry-After header, or zero if absent.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected status: " + e.Status
+}
+
+// newStatusError returns a StatusError for resp.
+func newStatusError(resp *http.Response) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// ImporterCount returns the number of known importers for the package with the given import path.
+// It returns 0 if the package has no known importers.
+// It returns an error wrapping ErrNotFound if pkg.go.dev does not know the package.
+// Counts are served from c.Cache when available,
+// and concurrent calls for the same package make a single request.
+func (c *Client) ImporterCount(ctx context.Context, pkgPath string) (int, error) {
+	r, err := c.lookup(ctx, pkgPath)
+	return r.Count, err
+}
+
+// lookup returns the result for pkgPath, reporting it to c.OnResult.
+func (c *Client) lookup(ctx context.Context, pkgPath string) (Result, error) {
+	ctx, span := c.tracer().Start(ctx, "pkgimporters.ImporterCount",
+		trace.WithAttributes(attribute.String("pkg.path", pkgPath)))
+	defer span.End()
+
+	// Concurrent lookups of the same package share a single request.
+	v, err, shared := c.flight.Do(pkgPath, func() (any, error) {
+		r, err := c.importerCount(ctx, pkgPath)
+		if err == nil && !c.Offline {
+			c.enrich(ctx, &r)
+		}
+		return r, err
+	})
+	r, _ := v.(Result)
+	r.Path = pkgPath
+	r.Status = StatusOf(err)
+	span.SetAttributes(attribute.Bool("singleflight.shared", shared))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.Int("pkg.importers", r.Count))
+	}
+	if c.OnResult != nil {
+		c.OnResult(r, err)
+	}
+	return r, err
+}
+
+func (c *Client) importerCount(ctx context.Context, pkgPath string) (Result, error) {
+	cache := c.cache()
+	entry, ok, err := cache.Get(ctx, pkgPath)
+	if err != nil {
+		return Result{}, &PackageError{Path: pkgPath, Err: fmt.Errorf("cache get: %w", err)}
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cache.hit", ok))
+	c.metrics().ObserveCache(ok)
+	if ok {
+		c.logger().DebugContext(ctx, "cache hit", "pkg", pkgPath, "count", entry.Count)
+		return entry.result(pkgPath), nil
+	}
+	if c.Offline {
+		return c.staleResult(ctx, cache, pkgPath)
+	}
+
+	// An expired entry holds the validators for a conditional request.
+	var prev *CacheEntry
+	if sc, ok := cache.(StaleCache); ok {
+		stale, ok, err := sc.GetStale(ctx, pkgPath)
+		if err != nil {
+			return Result{}, &PackageError{Path: pkgPath, Err: fmt.Errorf("cache get: %w", err)}
+		}
+		if ok && !stale.Validators.IsZero() {
+			prev = &stale
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return Result{}, err
+		}
+
+		probe, err := c.Breaker.allow(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		entry, err = c.fetch(ctx, pkgPath, prev)
+		c.recordBreaker(ctx, probe, err)
+		if err == nil {
+			c.speedUp(ctx)
+			break
+		}
+		if ctx.Err() != nil || !isRetryable(err) || attempt >= c.Retry.maxAttempts() {
+			return Result{}, &PackageError{Path: pkgPath, Err: err}
+		}
+		if isThrottled(err) {
+			c.slowDown(ctx)
+		}
+		if err := c.retry(ctx, pkgPath, attempt, err); err != nil {
+			return Result{}, err
+		}
+	}
+
+	entry.FetchedAt = time.Now()
+	if err := cache.Set(ctx, pkgPath, entry, cmp.Or(c.CacheTTL, DefaultCacheTTL)); err != nil {
+		return Result{}, &PackageError{Path: pkgPath, Err: fmt.Errorf("cache set: %w", err)}
+	}
+	return entry.result(pkgPath), nil
+}
+
+// staleResult returns the expired cache entry for pkgPath in offline mode,
+// or an error wrapping ErrNotCached if there is none.
+func (c *Client) staleResult(ctx context.Context, cache Cache, pkgPath string) (Result, error) {
+	if sc, ok := cache.(StaleCache); ok {
+		entry, ok, err := sc.GetStale(ctx, pkgPath)
+		if err != nil {
+			return Result{}, &PackageError{Path: pkgPath, Err: fmt.Errorf("cache get: %w", err)}
+		}
+		if ok {
+			c.logger().DebugContext(ctx, "stale cache hit", "pkg", pkgPath, "count", entry.Count, "fetched_at", entry.FetchedAt)
+			r := entry.result(pkgPath)
+			r.Stale = true
+			return r, nil
+		}
+	}
+	return Result{}, &PackageError{Path: pkgPath, Err: ErrNotCached}
+}
+
+// fetch makes a single request to the Source.
+// If the Source is a ConditionalSource, the returned entry holds the validators and canonical path of the response,
+// and if prev is non-nil the request is conditional, returning prev.Count if the upstream reports it as not modified.
+func (c *Client) fetch(ctx context.Context, pkgPath string, prev *CacheEntry) (CacheEntry, error) {
+	ctx, span := c.tracer().Start(ctx, "pkgimporters.Source.Count",
+		trace.WithAttributes(attribute.String("pkg.path", pkgPath)))
+	defer span.End()
+
+	if c.OnRequest != nil {
+		c.OnRequest(pkgPath)
+	}
+	c.logger().DebugContext(ctx, "fetch start", "pkg", pkgPath)
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(c.Timeout, DefaultTimeout))
+	defer cancel()
+	start := time.Now()
+	resp, err := c.hedge(ctx, pkgPath, func(ctx context.Context) (Response, error) {
+		if cs, ok := c.source().(ConditionalSource); ok {
+			var validators Validators
+			if prev != nil {
+				validators = prev.Validators
+			}
+			return cs.CountIfModified(ctx, pkgPath, validators)
+		}
+		count, err := c.source().Count(ctx, pkgPath)
+		return Response{Count: count}, err
+	})
+	entry := CacheEntry{Count: resp.Count, Validators: resp.Validators, CanonicalPath: resp.CanonicalPath, Modules: resp.Modules, Examples: resp.Examples, License: resp.License, Version: resp.Version, Published: resp.Published, Imports: resp.Imports, Redistributable: resp.Redistributable}
+	elapsed := time.Since(start)
+	c.metrics().ObserveRequest(elapsed, err)
+	c.observeLatency(elapsed)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.logger().DebugContext(ctx, "fetch failed", "pkg", pkgPath, "duration", elapsed, "err", err)
+		return CacheEntry{}, err
+	}
+	if resp.NotModified {
+		entry.Count, entry.Modules, entry.Examples, entry.License = prev.Count, prev.Modules, prev.Examples, prev.License
+		entry.Version, entry.Published, entry.Imports, entry.Redistributable = prev.Version, prev.Published, prev.Imports, prev.Redistributable
+		entry.CanonicalPath = cmp.Or(entry.CanonicalPath, prev.CanonicalPath)
+		span.SetAttributes(attribute.Bool("http.not_modified", true))
+		c.metrics().ObserveRevalidation()
+		c.logger().DebugContext(ctx, "fetch not modified", "pkg", pkgPath, "count", entry.Count, "duration", elapsed)
+		return entry, nil
+	}
+	if entry.CanonicalPath != "" {
+		span.SetAttributes(attribute.String("pkg.canonical_path", entry.CanonicalPath))
+		c.logger().DebugContext(ctx, "fetch redirected", "pkg", pkgPath, "canonical", entry.CanonicalPath)
+	}
+	if resp.Fallback {
+		c.logger().InfoContext(ctx, "fetch parsed with fallback", "pkg", pkgPath, "parser", resp.Parser)
+	}
+	c.logger().DebugContext(ctx, "fetch done", "pkg", pkgPath, "count", entry.Count, "parser", resp.Parser, "duration", elapsed)
+	return entry, nil
+}
+
+// ImporterCounts fetches the number of known importers for each package in pkgPaths
+// concurrently using c.Workers workers.
+// It returns the results in the order of pkgPaths.
+// It stops at the first error, which is a *PackageError for failed fetches.
+func (c *Client) ImporterCounts(ctx context.Context, pkgPaths []string) ([]Result, error) {
+	byPath := make(map[string]Result, len(pkgPaths))
+	for r, err := range c.Stream(ctx, pkgPaths) {
+		if err != nil {
+			return nil, err
+		}
+		byPath[r.Path] = r
+	}
+
+	// Convert results map to slice in input order
+	results := make([]Result, 0, len(pkgPaths))
+	for _, path := range pkgPaths {
+		if r, ok := byPath[path]; ok {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// Stream fetches the number of known importers for each package in pkgPaths
+// concurrently using c.Workers workers, yielding results as they arrive.
+// Results are yielded in completion order, not in the order of pkgPaths.
+// A failed fetch yields the package path with a non-nil error, and the iteration continues.
+// Stopping the iteration early cancels the outstanding requests.
+func (c *Client) Stream(ctx context.Context, pkgPaths []string) iter.Seq2[Result, error] {
+	return func(yield func(Result, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type item struct {
+			result Result
+			err    error
+		}
+		jobs := make(chan string)
+		items := make(chan item)
+
+		go func() {
+			defer close(jobs)
+			for _, path := range pkgPaths {
+				select {
+				case jobs <- path:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		var wg sync.WaitGroup
+		var started atomic.Int64
+		var worker func()
+		worker = func() {
+			for path := range jobs {
+				r, err := c.lookup(ctx, path)
+				if c.Workers == AutoWorkers {
+					// Grow the pool from a running worker, so wg.Go never races with wg.Wait.
+					for n := started.Load(); n < int64(c.autoWorkers()); n = started.Load() {
+						if started.CompareAndSwap(n, n+1) {
+							c.logger().DebugContext(ctx, "adding worker", "workers", n+1)
+							wg.Go(worker)
+						}
+					}
+				}
+				select {
+				case items <- item{result: r, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+		n := c.workers()
+		if c.Workers == AutoWorkers {
+			n = c.autoWorkers()
+		}
+		started.Store(int64(n))
+		for range n {
+			wg.Go(worker)
+		}
+		go func() {
+			wg.Wait()
+			close(items)
+		}()
+
+		for it := range items {
+			if !yield(it.result, it.err) {
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) workers() int {
+	if c.Workers > 0 {
+		return c.Workers
+	}
+	return DefaultWorkers
+}
+
+// wait blocks until the rate limiter allows a request to pkg.go.dev.
+func (c *Client) wait(ctx context.Context) error {
+	c.init()
+	start := time.Now()
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	if waited := time.Since(start); waited >= time.Millisecond {
+		c.logger().DebugContext(ctx, "rate limit wait", "duration", waited)
+	}
+
+	// Add random jitter (50-200ms) to make pattern less predictable
+	jitter := 50*time.Millisecond + rand.N(150*time.Millisecond)
+	select {
+	case <-time.After(jitter):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) init() {
+	c.initOnce.Do(func() {
+		c.limiter = rate.NewLimiter(c.maxLimit(), cmp.Or(c.Burst, DefaultBurst))
+
+		base := c.HTTPClient
+		if base == nil {
+			base = &http.Client{Transport: NewTransport(TransportOptions{})}
+		}
+		c.http = chain(base, c.Middleware)
+	})
+}
+
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return discardLogger
+}
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func (c *Client) metrics() Metrics {
+	if c.Metrics != nil {
+		return c.Metrics
+	}
+	return noMetrics{}
+}
+
+func (c *Client) tracer() trace.Tracer {
+	tp := c.TracerProvider
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+	return tp.Tracer("github.com/alexandear/pkgimporters")
+}
+
+func (c *Client) cache() Cache {
+	if c.Cache != nil {
+		return c.Cache
+	}
+	return &c.memoryCache
+}
+
+func (c *Client) source() Source {
+	if c.Source != nil {
+		return c.Source
+	}
+	return &PkgGoDev{HTTPClient: c.httpClient(), BaseURL: c.BaseURL}
+}
+
+func (c *Client) baseURL() string {
+	return strings.TrimSuffix(cmp.Or(c.BaseURL, DefaultBaseURL), "/")
+}
+
+func (c *Client) httpClient() *http.Client {
+	c.init()
+	return c.http
+}
@@ -0,0 +1,443 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/time/rate"
+)
+
+func TestClientImporterCounts(t *testing.T) {
+	ioHTML, err := os.ReadFile("testdata/io.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysisHTML, err := os.ReadFile("testdata/golang.org/x/tools/go/analysis.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &urlTransport{files: map[string][]byte{
+		"https://pkg.go.dev/io?tab=importedby":                             ioHTML,
+		"https://pkg.go.dev/golang.org/x/tools/go/analysis?tab=importedby": analysisHTML,
+	}}
+	client := &Client{
+		HTTPClient: &http.Client{Transport: transport},
+		Workers:    2,
+	}
+
+	t.Run("results in input order", func(t *testing.T) {
+		got, err := client.ImporterCounts(t.Context(), []string{"io", "golang.org/x/tools/go/analysis"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []Result{
+			{Path: "io", Count: fixtureCount(t, "io"), Status: StatusOK},
+			{Path: "golang.org/x/tools/go/analysis", Count: fixtureCount(t, "golang.org/x/tools/go/analysis"), Status: StatusOK},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("unknown package", func(t *testing.T) {
+		_, err := client.ImporterCounts(t.Context(), []string{"io", "example.com/unknown"})
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+		var pkgErr *PackageError
+		if !errors.As(err, &pkgErr) || pkgErr.Path != "example.com/unknown" {
+			t.Errorf("expected *PackageError for example.com/unknown, got %v", err)
+		}
+	})
+}
+
+func TestClientStream(t *testing.T) {
+	ioHTML, err := os.ReadFile("testdata/io.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &urlTransport{files: map[string][]byte{
+		"https://pkg.go.dev/io?tab=importedby": ioHTML,
+	}}
+	client := &Client{
+		HTTPClient: &http.Client{Transport: transport},
+		Workers:    1,
+	}
+
+	t.Run("yields errors and continues", func(t *testing.T) {
+		got := make(map[string]int)
+		var failed []string
+		for r, err := range client.Stream(t.Context(), []string{"example.com/unknown", "io"}) {
+			if err != nil {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("expected ErrNotFound for %s, got %v", r.Path, err)
+				}
+				failed = append(failed, r.Path)
+				continue
+			}
+			got[r.Path] = r.Count
+		}
+
+		if !slices.Equal(failed, []string{"example.com/unknown"}) {
+			t.Errorf("expected example.com/unknown to fail, got %v", failed)
+		}
+		if want := fixtureCount(t, "io"); got["io"] != want {
+			t.Errorf("expected io count %d, got %v", want, got)
+		}
+	})
+
+	t.Run("stops early", func(t *testing.T) {
+		n := 0
+		for _, err := range client.Stream(t.Context(), []string{"io", "io", "io", "io", "io"}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			n++
+			break
+		}
+		if n != 1 {
+			t.Errorf("expected 1 result, got %d", n)
+		}
+	})
+}
+
+func TestClientSource(t *testing.T) {
+	client := &Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			if pkgPath == "example.com/unknown" {
+				return 0, ErrNotFound
+			}
+			return len(pkgPath), nil
+		}),
+	}
+
+	count, err := client.ImporterCount(t.Context(), "net/http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 8 {
+		t.Errorf("expected count 8, got %d", count)
+	}
+
+	if _, err := client.ImporterCount(t.Context(), "example.com/unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	client := &Client{
+		Timeout: 10 * time.Millisecond,
+		Retry:   RetryPolicy{MaxAttempts: 1},
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}),
+	}
+
+	if _, err := client.ImporterCount(t.Context(), "fmt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	tests := []struct {
+		client    *Client
+		wantLimit rate.Limit
+		wantBurst int
+	}{
+		{client: &Client{}, wantLimit: DefaultRequestsPerSecond, wantBurst: DefaultBurst},
+		{client: &Client{RequestsPerSecond: 20, Burst: 50}, wantLimit: 20, wantBurst: 50},
+	}
+	for _, tt := range tests {
+		tt.client.init()
+		if got := tt.client.limiter.Limit(); got != tt.wantLimit {
+			t.Errorf("expected limit %v, got %v", tt.wantLimit, got)
+		}
+		if got := tt.client.limiter.Burst(); got != tt.wantBurst {
+			t.Errorf("expected burst %d, got %d", tt.wantBurst, got)
+		}
+	}
+}
+
+func TestClientSingleflight(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client := &Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			calls.Add(1)
+			<-release
+			return 42, nil
+		}),
+	}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			if count, err := client.ImporterCount(t.Context(), "fmt"); err != nil || count != 42 {
+				t.Errorf("expected count 42, got %d, %v", count, err)
+			}
+		})
+	}
+	time.Sleep(300 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 request for concurrent lookups, got %d", got)
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	calls := 0
+	client := &Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			calls++
+			return 7, nil
+		}),
+	}
+
+	for range 3 {
+		count, err := client.ImporterCount(t.Context(), "fmt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 7 {
+			t.Errorf("expected count 7, got %d", count)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 source call, got %d", calls)
+	}
+}
+
+func TestClientOffline(t *testing.T) {
+	ctx := t.Context()
+	var cache MemoryCache
+	if err := cache.Set(ctx, "fmt", CacheEntry{Count: 7}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Set(ctx, "io", CacheEntry{Count: 5}, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			t.Errorf("unexpected request for %s in offline mode", pkgPath)
+			return 0, nil
+		}),
+		Cache:   &cache,
+		Offline: true,
+	}
+
+	got, err := client.ImporterCounts(ctx, []string{"fmt", "io"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Result{{Path: "fmt", Count: 7, Status: StatusOK}, {Path: "io", Count: 5, Status: StatusOK, Stale: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if _, err := client.ImporterCount(ctx, "bufio"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("expected ErrNotCached, got %v", err)
+	}
+}
+
+func TestClientConditionalRequest(t *testing.T) {
+	var conditional []string
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{"Etag": []string{`"v1"`}}
+		if etag := req.Header.Get("If-None-Match"); etag != "" {
+			conditional = append(conditional, etag)
+			return &http.Response{StatusCode: http.StatusNotModified, Status: "304 Not Modified", Header: header, Body: http.NoBody}, nil
+		}
+		body := `<div class="ImportedBy"><strong>Known importers:</strong> 1,234</div>`
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: header, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+	metrics := &countingMetrics{}
+	cache := &DiskCache{Dir: t.TempDir()}
+	client := &Client{
+		HTTPClient:        &http.Client{Transport: transport},
+		Cache:             cache,
+		CacheTTL:          time.Nanosecond,
+		RequestsPerSecond: 100,
+		Metrics:           metrics,
+	}
+
+	for range 2 {
+		count, err := client.ImporterCount(t.Context(), "fmt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1234 {
+			t.Errorf("expected count 1234, got %d", count)
+		}
+	}
+
+	if want := []string{`"v1"`}; !slices.Equal(conditional, want) {
+		t.Errorf("expected conditional requests with %v, got %v", want, conditional)
+	}
+	if got := metrics.revalidated.Load(); got != 1 {
+		t.Errorf("expected 1 revalidation, got %v", got)
+	}
+	entry, ok, err := cache.GetStale(t.Context(), "fmt")
+	if err != nil || !ok || entry.Count != 1234 || entry.Validators.ETag != `"v1"` {
+		t.Errorf("expected revalidated entry with count 1234 and ETag \"v1\", got %+v ok=%t err=%v", entry, ok, err)
+	}
+}
+
+func TestClientHooks(t *testing.T) {
+	var requests []string
+	var results []Result
+	client := &Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			if pkgPath == "example.com/unknown" {
+				return 0, ErrNotFound
+			}
+			return 3, nil
+		}),
+		OnRequest: func(pkgPath string) {
+			requests = append(requests, pkgPath)
+		},
+		OnResult: func(r Result, err error) {
+			if err != nil {
+				r.Count = -1
+			}
+			results = append(results, r)
+		},
+	}
+
+	for _, path := range []string{"fmt", "fmt", "example.com/unknown"} {
+		_, _ = client.ImporterCount(t.Context(), path)
+	}
+
+	if want := []string{"fmt", "example.com/unknown"}; !slices.Equal(requests, want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+	want := []Result{
+		{Path: "fmt", Count: 3, Status: StatusOK},
+		{Path: "fmt", Count: 3, Status: StatusOK},
+		{Path: "example.com/unknown", Count: -1, Status: StatusNotFound},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("expected results %v, got %v", want, results)
+	}
+}
+
+func TestClientTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	client := &Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			return 3, nil
+		}),
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	}
+
+	for range 2 {
+		if _, err := client.ImporterCount(t.Context(), "fmt"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	want := []string{"pkgimporters.Source.Count", "pkgimporters.ImporterCount", "pkgimporters.ImporterCount"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected spans %v, got %v", want, names)
+	}
+}
+
+type sourceFunc func(ctx context.Context, pkgPath string) (int, error)
+
+func (f sourceFunc) Count(ctx context.Context, pkgPath string) (int, error) {
+	return f(ctx, pkgPath)
+}
+
+type htmlFileTransport struct {
+	content       []byte
+	requestedURLs []string
+}
+
+func (t *htmlFileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requestedURLs = append(t.requestedURLs, req.URL.String())
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+		Header: http.Header{
+			"Content-Type": []string{"text/html"},
+		},
+		Body:          io.NopCloser(bytes.NewReader(t.content)),
+		ContentLength: int64(len(t.content)),
+		Request:       req,
+	}, nil
+}
+
+// fixtureCount returns the importer count shown on the importedby tab of pkgPath in testdata,
+// as recorded in testdata/counts.json by "pkgimporters internal refresh-testdata".
+func fixtureCount(t *testing.T, pkgPath string) int {
+	t.Helper()
+	data, err := os.ReadFile("testdata/counts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counts map[string]int
+	if err := json.Unmarshal(data, &counts); err != nil {
+		t.Fatal(err)
+	}
+	count, ok := counts[pkgPath]
+	if !ok {
+		t.Fatalf("no count of %s in testdata/counts.json", pkgPath)
+	}
+	return count
+}
+
+// urlTransport serves files by request URL and responds with 404 Not Found to unknown URLs.
+type urlTransport struct {
+	mu            sync.Mutex
+	files         map[string][]byte
+	requestedURLs []string
+}
+
+func (t *urlTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requestedURLs = append(t.requestedURLs, req.URL.String())
+	t.mu.Unlock()
+
+	content, ok := t.files[req.URL.String()]
+	if !ok {
+		return &http.Response{
+			Status:     "404 Not Found",
+			StatusCode: http.StatusNotFound,
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Content-Type": []string{"text/html"},
+		},
+		Body:          io.NopCloser(bytes.NewReader(content)),
+		ContentLength: int64(len(content)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alexandear/pkgimporters"
+	"golang.org/x/mod/module"
+)
+
+// modEdge is a line of go mod graph output: module from requires module to.
+// Both are module paths followed by "@version", except the main module, which has no version.
+type modEdge struct {
+	from, to string
+}
+
+// parseModGraph parses the output of go mod graph.
+func parseModGraph(r io.Reader) ([]modEdge, error) {
+	var edges []modEdge
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		switch len(fields) {
+		case 0:
+			continue
+		case 2:
+			edges = append(edges, modEdge{from: fields[0], to: fields[1]})
+		default:
+			return nil, fmt.Errorf("line %d: expected \"module module@version\", got %q", line, scanner.Text())
+		}
+	}
+	return edges, scanner.Err()
+}
+
+// modGraphPaths returns the unique module paths of edges without versions, in order of appearance,
+// skipping nodes that are not modules, such as go@1.25 and toolchain@go1.25.
+func modGraphPaths(edges []modEdge) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, e := range edges {
+		for _, node := range []string{e.from, e.to} {
+			path, _, _ := strings.Cut(node, "@")
+			if seen[path] || module.CheckImportPath(path) != nil || !strings.Contains(path, ".") {
+				continue
+			}
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// runAnnotate runs the annotate subcommand, which reads go mod graph output
+// and prints it with the importer count of each module.
+func runAnnotate(args []string, stdout, stderr io.Writer) (err error) {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("annotate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	clientFlags := addClientFlags(fs)
+	rateFlags := addRateFlags(fs)
+	input := fs.String("i", "", "read go mod graph output from `file` instead of stdin")
+	format := fs.String("format", "text", "output `format`: 'text' (go mod graph lines followed by both importer counts) or 'dot' (Graphviz)")
+	output := fs.String("o", "", "write the annotated graph to `file` instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s annotate - annotate go mod graph output with importer counts\n\n"+
+			"SYNOPSIS\n"+
+			"    go mod graph | %[1]s annotate [-rps rate] [-burst N] [-workers N]\n"+
+			"        [-base-url URL] [-proxy URL] [-user-agent header] [-timeout duration] [-format text|dot] [-i file] [-o file]\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s annotate reads the output of go mod graph and fetches the importer count of each module's root package.\n"+
+			"    It prints every \"module module@version\" line followed by the counts of both modules, or - if unknown,\n"+
+			"    so the first two fields can still be read as go mod graph output.\n"+
+			"    With -format dot, it prints a Graphviz graph labeling each module with its count.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEXAMPLES\n"+
+			"    go mod graph | %[1]s annotate\n"+
+			"        Print the dependency graph of the current module with importer counts\n\n"+
+			"    go mod graph | %[1]s annotate -format dot | dot -Tsvg -o deps.svg\n"+
+			"        Draw the dependency graph with importer counts with Graphviz\n", progName)
+	}
+	args, err = parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return &cmdError{code: 2, msg: "annotate takes no arguments; pipe go mod graph output to it or use -i"}
+	}
+	write, ok := annotateWriters[*format]
+	if !ok {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -format value: %q (must be 'text' or 'dot')", *format)}
+	}
+	logger, err := newLogger(stderr, "text", false, false)
+	if err != nil {
+		return err
+	}
+	client, err := clientFlags.client(logger)
+	if err != nil {
+		return err
+	}
+	if err := rateFlags.apply(client); err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	edges, err := parseModGraph(in)
+	if err != nil {
+		return fmt.Errorf("parse go mod graph: %w", err)
+	}
+
+	paths := modGraphPaths(edges)
+	results, failures, err := fetchCounts(context.Background(), client, paths, fetchOptions{}, nil)
+	if err != nil {
+		return err
+	}
+	counts := make(map[string]int, len(results))
+	for _, r := range results {
+		if r.Error == "" {
+			counts[r.Path] = r.Count
+		}
+	}
+
+	out, closeOutput, err := createOutput(stdout, *output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = cmp.Or(err, closeOutput())
+	}()
+	if err := write(out, edges, counts); err != nil {
+		return err
+	}
+	return failuresError(failures, len(paths))
+}
+
+// annotateWriters write an annotated go mod graph in the formats of annotate -format.
+// counts maps module paths to their importer counts.
+var annotateWriters = map[string]func(w io.Writer, edges []modEdge, counts map[string]int) error{
+	"text": writeAnnotatedText,
+	"dot":  writeAnnotatedDOT,
+}
+
+// nodeCount returns the importer count of a go mod graph node.
+func nodeCount(counts map[string]int, node string) (int, bool) {
+	path, _, _ := strings.Cut(node, "@")
+	count, ok := counts[path]
+	return count, ok
+}
+
+// nodeCountText returns the importer count of a go mod graph node, or "-" if it is unknown.
+func nodeCountText(counts map[string]int, node string) string {
+	if count, ok := nodeCount(counts, node); ok {
+		return strconv.Itoa(count)
+	}
+	return "-"
+}
+
+func writeAnnotatedText(w io.Writer, edges []modEdge, counts map[string]int) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range edges {
+		fmt.Fprintln(bw, e.from, e.to, nodeCountText(counts, e.from), nodeCountText(counts, e.to))
+	}
+	return bw.Flush()
+}
+
+func writeAnnotatedDOT(w io.Writer, edges []modEdge, counts map[string]int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph modules {")
+	seen := make(map[string]bool)
+	for _, e := range edges {
+		for _, node := range []string{e.from, e.to} {
+			if seen[node] {
+				continue
+			}
+			seen[node] = true
+			label := node
+			if count, ok := nodeCount(counts, node); ok {
+				label += "\n" + pkgimporters.FormatCount(count) + " importers"
+			}
+			fmt.Fprintf(bw, "\t%s [label=%s];\n", strconv.Quote(node), strconv.Quote(label))
+		}
+	}
+	for _, e := range edges {
+		fmt.Fprintf(bw, "\t%s -> %s;\n", strconv.Quote(e.from), strconv.Quote(e.to))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
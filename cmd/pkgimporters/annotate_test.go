@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseModGraph(t *testing.T) {
+	input := "example.com/m github.com/spf13/cobra@v1.8.0\n" +
+		"example.com/m go@1.25\n" +
+		"\n" +
+		"github.com/spf13/cobra@v1.8.0 github.com/spf13/pflag@v1.0.5\n"
+	edges, err := parseModGraph(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []modEdge{
+		{from: "example.com/m", to: "github.com/spf13/cobra@v1.8.0"},
+		{from: "example.com/m", to: "go@1.25"},
+		{from: "github.com/spf13/cobra@v1.8.0", to: "github.com/spf13/pflag@v1.0.5"},
+	}
+	if !slices.Equal(edges, want) {
+		t.Errorf("expected %v, got %v", want, edges)
+	}
+
+	wantPaths := []string{"example.com/m", "github.com/spf13/cobra", "github.com/spf13/pflag"}
+	if paths := modGraphPaths(edges); !slices.Equal(paths, wantPaths) {
+		t.Errorf("expected paths %v, got %v", wantPaths, paths)
+	}
+
+	if _, err := parseModGraph(strings.NewReader("a b\nc\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error for line 2, got %v", err)
+	}
+}
+
+func TestRunAnnotate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counts := map[string]string{"/github.com/spf13/cobra": "1,234", "/github.com/spf13/pflag": "5,678"}
+		count, ok := counts[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<div class="ImportedBy"><strong>Known importers:</strong> %s</div>`, count)
+	}))
+	defer srv.Close()
+
+	input := filepath.Join(t.TempDir(), "graph.txt")
+	graph := "example.com/m github.com/spf13/cobra@v1.8.0\n" +
+		"github.com/spf13/cobra@v1.8.0 github.com/spf13/pflag@v1.0.5\n"
+	if err := os.WriteFile(input, []byte(graph), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	if err := runAnnotate([]string{"-base-url", srv.URL, "-rps", "100", "-i", input}, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	want := "example.com/m github.com/spf13/cobra@v1.8.0 - 1234\n" +
+		"github.com/spf13/cobra@v1.8.0 github.com/spf13/pflag@v1.0.5 1234 5678\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	stdout.Reset()
+	if err := runAnnotate([]string{"-base-url", srv.URL, "-rps", "100", "-i", input, "-format", "dot"}, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"example.com/m" [label="example.com/m"];`,
+		`"github.com/spf13/cobra@v1.8.0" [label="github.com/spf13/cobra@v1.8.0\n1,234 importers"];`,
+		`"example.com/m" -> "github.com/spf13/cobra@v1.8.0";`,
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected DOT output to contain %s, got:\n%s", want, stdout.String())
+		}
+	}
+}
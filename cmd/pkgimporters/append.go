@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// appendResults appends results with their Time set to runTime to file in format, csv or json,
+// creating the file if it does not exist, so that scheduled runs build a time series.
+// The CSV header is only written to a new or empty file, and must match the header of an existing one.
+// JSON is appended as JSON Lines, one object per result, as a JSON array cannot be appended to.
+func appendResults(file, format string, runTime time.Time, results []pkgimporters.Result) (err error) {
+	timed := make([]pkgimporters.Result, 0, len(results))
+	for _, r := range results {
+		r.Time = runTime
+		timed = append(timed, r)
+	}
+
+	var (
+		buf  bytes.Buffer
+		data []byte
+	)
+	switch format {
+	case "csv":
+		if err := (pkgimporters.CSVRenderer{}).Render(&buf, timed); err != nil {
+			return err
+		}
+		header, err := buf.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		existing, err := readFirstLine(file)
+		if err != nil {
+			return err
+		}
+		switch {
+		case existing == nil:
+			data = append(header, buf.Bytes()...)
+		case !bytes.Equal(existing, header):
+			return fmt.Errorf("append to %s: its columns differ from the output of this version; append to a new file", file)
+		default:
+			data = buf.Bytes()
+		}
+	case "json":
+		write, err := (pkgimporters.JSONRenderer{}).RenderStream(&buf)
+		if err != nil {
+			return err
+		}
+		for _, r := range timed {
+			if err := write(r); err != nil {
+				return err
+			}
+		}
+		data = buf.Bytes()
+	default:
+		return fmt.Errorf("append in format %q is not supported", format)
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	_, err = f.Write(data)
+	return err
+}
+
+// readFirstLine returns the first line of file including its newline,
+// or nil if the file does not exist or is empty.
+func readFirstLine(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+	return line, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestAppendResults(t *testing.T) {
+	results := []pkgimporters.Result{
+		{Path: "fmt", Count: 5485422, Status: pkgimporters.StatusOK},
+		{Path: "io", Count: 1533321, Status: pkgimporters.StatusOK},
+	}
+	first := time.Date(2025, 1, 6, 3, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	t.Run("csv", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "counts.csv")
+		for _, runTime := range []time.Time{first, second} {
+			if err := appendResults(file, "csv", runTime, results); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(lines) != 5 {
+			t.Fatalf("expected a header and 4 rows, got:\n%s", data)
+		}
+		if !strings.HasPrefix(lines[0], "schema_version,path,") || !strings.HasSuffix(lines[0], ",time") {
+			t.Errorf("unexpected header %q", lines[0])
+		}
+		if !strings.HasPrefix(lines[1], "1,fmt,5485422,") || !strings.HasSuffix(lines[1], ",2025-01-06T03:00:00Z") {
+			t.Errorf("unexpected first row %q", lines[1])
+		}
+		if !strings.HasPrefix(lines[4], "1,io,1533321,") || !strings.HasSuffix(lines[4], ",2025-01-07T03:00:00Z") {
+			t.Errorf("unexpected last row %q", lines[4])
+		}
+	})
+
+	t.Run("csv with other columns", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "counts.csv")
+		if err := os.WriteFile(file, []byte("path,count\nfmt,5485422\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := appendResults(file, "csv", first, results); err == nil || !strings.Contains(err.Error(), "columns differ") {
+			t.Errorf("expected an error for different columns, got %v", err)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "counts.jsonl")
+		for _, runTime := range []time.Time{first, second} {
+			if err := appendResults(file, "json", runTime, results[:1]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"schema_version":1,"path":"fmt","count":5485422,"status":"OK","time":"2025-01-06T03:00:00Z"}` + "\n" +
+			`{"schema_version":1,"path":"fmt","count":5485422,"status":"OK","time":"2025-01-07T03:00:00Z"}` + "\n"
+		if string(data) != want {
+			t.Errorf("expected:\n%s\ngot:\n%s", want, data)
+		}
+	})
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alexandear/pkgimporters"
+	"golang.org/x/tools/go/packages"
+)
+
+// auditSeverity is the severity of an audit finding, ordered from least to most severe.
+type auditSeverity int
+
+const (
+	severityNone auditSeverity = iota
+	severityWarn
+	severityError
+)
+
+var severityNames = []string{"none", "warn", "error"}
+
+func (s auditSeverity) String() string {
+	return severityNames[s]
+}
+
+func (s auditSeverity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func parseSeverity(s string) (auditSeverity, bool) {
+	i := slices.Index(severityNames, s)
+	return auditSeverity(i), i >= 0
+}
+
+// auditFinding is a third-party package with few importers.
+type auditFinding struct {
+	pkgimporters.Result
+	Severity auditSeverity `json:"severity"`
+}
+
+// versionedFinding is the JSON object of a finding in the JSON format.
+type versionedFinding struct {
+	SchemaVersion int `json:"schema_version"`
+	auditFinding
+}
+
+// auditThresholds are the importer counts below which a package is flagged.
+type auditThresholds struct {
+	warnBelow, errorBelow int
+}
+
+// severity returns the severity of r: error or warn if its count is below the thresholds,
+// and warn if the package is unknown, which is also a supply-chain risk.
+func (t auditThresholds) severity(r pkgimporters.Result) auditSeverity {
+	switch {
+	case r.Status == pkgimporters.StatusNotFound:
+		return severityWarn
+	case r.Error != "":
+		return severityNone
+	case r.Count < t.errorBelow:
+		return severityError
+	case r.Count < t.warnBelow:
+		return severityWarn
+	}
+	return severityNone
+}
+
+// audit returns the flagged results, most severe and least imported first.
+func (t auditThresholds) audit(results []pkgimporters.Result) []auditFinding {
+	var findings []auditFinding
+	for _, r := range results {
+		if severity := t.severity(r); severity != severityNone {
+			findings = append(findings, auditFinding{Result: r, Severity: severity})
+		}
+	}
+	slices.SortFunc(findings, func(a, b auditFinding) int {
+		return cmp.Or(cmp.Compare(b.Severity, a.Severity), cmp.Compare(a.Count, b.Count), cmp.Compare(a.Path, b.Path))
+	})
+	return findings
+}
+
+// loadThirdPartyImports returns the sorted paths of the packages imported by the packages matching patterns
+// that are neither in the standard library nor in the modules of the matched packages.
+func loadThirdPartyImports(patterns []string, tests bool) ([]string, error) {
+	cfg := &packages.Config{Mode: packages.NeedName | packages.NeedImports | packages.NeedModule, Tests: tests}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", strings.Join(patterns, " "), err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("load %s: packages contain errors", strings.Join(patterns, " "))
+	}
+
+	own := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.Module != nil {
+			own[pkg.Module.Path] = true
+		}
+	}
+	isOwn := func(path string) bool {
+		for mod := range own {
+			if path == mod || strings.HasPrefix(path, mod+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	var imports []string
+	for _, pkg := range pkgs {
+		for path := range pkg.Imports {
+			if !isStdPackage(path) && !isOwn(path) && path != "C" {
+				imports = append(imports, path)
+			}
+		}
+	}
+	slices.Sort(imports)
+	return slices.Compact(imports), nil
+}
+
+// isStdPackage reports whether path is a standard library package, whose first element has no dot.
+func isStdPackage(path string) bool {
+	elem, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(elem, ".")
+}
+
+// runAudit runs the audit subcommand, which flags the third-party packages imported by a project
+// that have few importers, a sign of abandonment or supply-chain risk.
+func runAudit(args []string, stdout, stderr io.Writer) (err error) {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	clientFlags := addClientFlags(fs)
+	rateFlags := addRateFlags(fs)
+	warnBelow := fs.Int("warn-below", 100, "flag packages with fewer than `N` importers as warn")
+	errorBelow := fs.Int("error-below", 10, "flag packages with fewer than `N` importers as error")
+	failOn := fs.String("fail-on", "error", "least `severity` that fails the audit: 'warn', 'error', or 'none'")
+	exitCode := fs.Int("exit-code", 3, "exit `status` of a failed audit")
+	exclude := fs.String("exclude", "", "comma-separated list of package patterns not to audit, e.g. 'corp.example.com/...'")
+	tests := fs.Bool("tests", false, "also audit the imports of test files")
+	format := fs.String("format", "text", "output `format`: 'text' or 'json'")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s audit - flag dependencies with few importers\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s audit [-warn-below N] [-error-below N] [-fail-on warn|error|none] [-exit-code status]\n"+
+			"        [-exclude pattern,...] [-tests] [-format text|json] [-rps rate] [-burst N] [-workers N]\n"+
+			"        [-base-url URL] [-proxy URL] [-user-agent header] [-timeout duration] [packages]\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s audit loads the packages matching the patterns (default ./...), fetches the importer counts\n"+
+			"    of the third-party packages they import, and prints those with fewer than -warn-below importers,\n"+
+			"    a sign of abandonment or supply-chain risk. Packages unknown to pkg.go.dev are flagged as warn.\n"+
+			"    The audit fails with -exit-code if a package is flagged with -fail-on or a more severe severity.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEXAMPLES\n"+
+			"    %[1]s audit ./...\n"+
+			"        Flag the dependencies of the current module with fewer than 100 importers\n\n"+
+			"    %[1]s audit -warn-below 1000 -error-below 50 -fail-on warn -exclude corp.example.com/... ./...\n"+
+			"        Use stricter thresholds in CI, skipping private packages\n", progName)
+	}
+	args, err = parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	switch {
+	case *errorBelow < 0 || *warnBelow < *errorBelow:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -error-below and -warn-below values: %d and %d (must satisfy 0 <= -error-below <= -warn-below)", *errorBelow, *warnBelow)}
+	case *format != "text" && *format != "json":
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -format value: %q (must be 'text' or 'json')", *format)}
+	}
+	failSeverity, ok := parseSeverity(*failOn)
+	if !ok {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -fail-on value: %q (must be 'warn', 'error', or 'none')", *failOn)}
+	}
+	logger, err := newLogger(stderr, "text", false, false)
+	if err != nil {
+		return err
+	}
+	client, err := clientFlags.client(logger)
+	if err != nil {
+		return err
+	}
+	if err := rateFlags.apply(client); err != nil {
+		return err
+	}
+
+	pkgPaths, err := loadThirdPartyImports(args, *tests)
+	if err != nil {
+		return err
+	}
+	if *exclude != "" {
+		pkgPaths = excludePackages(pkgPaths, strings.Split(*exclude, ","))
+	}
+	results, failures, err := fetchCounts(context.Background(), client, pkgPaths, fetchOptions{}, nil)
+	if err != nil {
+		return err
+	}
+	findings := auditThresholds{warnBelow: *warnBelow, errorBelow: *errorBelow}.audit(results)
+
+	if err := writeFindings(stdout, *format, findings); err != nil {
+		return err
+	}
+	if err := failuresError(failures, len(pkgPaths)); err != nil {
+		return err
+	}
+	summary := fmt.Sprintf("%d of %d third-party packages flagged", len(findings), len(pkgPaths))
+	if failSeverity != severityNone && slices.ContainsFunc(findings, func(f auditFinding) bool { return f.Severity >= failSeverity }) {
+		return &cmdError{code: *exitCode, msg: summary}
+	}
+	fmt.Fprintln(stderr, summary)
+	return nil
+}
+
+func writeFindings(w io.Writer, format string, findings []auditFinding) error {
+	if format == "json" {
+		versioned := make([]versionedFinding, 0, len(findings))
+		for _, f := range findings {
+			versioned = append(versioned, versionedFinding{SchemaVersion: pkgimporters.SchemaVersion, auditFinding: f})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(versioned)
+	}
+	width := 0
+	for _, f := range findings {
+		width = max(width, len(f.Path))
+	}
+	bw := bufio.NewWriter(w)
+	for _, f := range findings {
+		value := pkgimporters.FormatCount(f.Count)
+		if f.Error != "" {
+			value = string(f.Status) + " " + f.Error
+		}
+		fmt.Fprintf(bw, "%-5s %-*s %s\n", f.Severity, width, f.Path, value)
+	}
+	return bw.Flush()
+}
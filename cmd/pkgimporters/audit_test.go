@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestAuditThresholds(t *testing.T) {
+	thresholds := auditThresholds{warnBelow: 100, errorBelow: 10}
+	results := []pkgimporters.Result{
+		{Path: "example.com/popular", Count: 100},
+		{Path: "example.com/few", Count: 50},
+		{Path: "example.com/none", Count: 0},
+		{Path: "example.com/rare", Count: 5},
+		{Path: "example.com/unknown", Status: pkgimporters.StatusNotFound, Error: "not found"},
+		{Path: "example.com/blocked", Status: pkgimporters.StatusBlocked, Error: "blocked"},
+	}
+	want := []auditFinding{
+		{Result: results[2], Severity: severityError},
+		{Result: results[3], Severity: severityError},
+		{Result: results[4], Severity: severityWarn},
+		{Result: results[1], Severity: severityWarn},
+	}
+	if got := thresholds.audit(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestRunAudit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counts := map[string]string{"/example.com/dep": "42", "/example.com/dep/sub": "3"}
+		count, ok := counts[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<div class="ImportedBy"><strong>Known importers:</strong> %s</div>`, count)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":          "module example.com/app\n\ngo 1.25\n\nrequire example.com/dep v0.0.0\n\nreplace example.com/dep => ./dep\n",
+		"main.go":         "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/internal/x\"\n\t\"example.com/dep\"\n)\n\nfunc main() { fmt.Println(x.X, dep.Dep) }\n",
+		"main_test.go":    "package main\n\nimport (\n\t\"testing\"\n\n\t\"example.com/dep/sub\"\n)\n\nfunc TestSub(t *testing.T) { _ = sub.Sub }\n",
+		"internal/x/x.go": "package x\n\nconst X = 1\n",
+		"dep/go.mod":      "module example.com/dep\n\ngo 1.25\n",
+		"dep/dep.go":      "package dep\n\nconst Dep = 1\n",
+		"dep/sub/sub.go":  "package sub\n\nconst Sub = 1\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOWORK", "off")
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Chdir(dir)
+
+	paths, err := loadThirdPartyImports([]string{"./..."}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/dep", "example.com/dep/sub"}; !slices.Equal(paths, want) {
+		t.Errorf("expected imports %v, got %v", want, paths)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := runAudit([]string{"-base-url", srv.URL, "-rps", "100"}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if want := "warn  example.com/dep 42\n"; stdout.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+	if want := "1 of 1 third-party packages flagged\n"; stderr.String() != want {
+		t.Errorf("expected stderr %q, got %q", want, stderr.String())
+	}
+
+	stdout.Reset()
+	err = runAudit([]string{"-base-url", srv.URL, "-rps", "100", "-tests", "-fail-on", "warn", "-exit-code", "4", "./..."}, &stdout, io.Discard)
+	var cmdErr *cmdError
+	if !errors.As(err, &cmdErr) || cmdErr.code != 4 || cmdErr.msg != "2 of 2 third-party packages flagged" {
+		t.Errorf("expected exit code 4, got %v", err)
+	}
+	if want := "error example.com/dep/sub 3\nwarn  example.com/dep     42\n"; stdout.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+
+	stdout.Reset()
+	if err := runAudit([]string{"-base-url", srv.URL, "-rps", "100", "-tests", "-exclude", "example.com/dep/...", "-format", "json"}, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "[]" {
+		t.Errorf("expected no findings, got %s", got)
+	}
+}
+
+func TestWriteFindingsJSON(t *testing.T) {
+	findings := []auditFinding{{Result: pkgimporters.Result{Path: "example.com/rare", Count: 5, Status: pkgimporters.StatusOK}, Severity: severityError}}
+	var out bytes.Buffer
+	if err := writeFindings(&out, "json", findings); err != nil {
+		t.Fatal(err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, out.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"schema_version":1,"path":"example.com/rare","count":5,"status":"OK","severity":"error"}]`
+	if got := compact.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestRunAuditInvalidFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-warn-below", "5", "-error-below", "10"},
+		{"-fail-on", "fatal"},
+		{"-format", "csv"},
+	} {
+		var cmdErr *cmdError
+		if err := runAudit(args, io.Discard, io.Discard); !errors.As(err, &cmdErr) || cmdErr.code != 2 {
+			t.Errorf("runAudit(%q): expected a usage error, got %v", args, err)
+		}
+	}
+}
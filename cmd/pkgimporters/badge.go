@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// shieldsBadge is a badge in the shields.io endpoint format, see https://shields.io/badges/endpoint-badge.
+type shieldsBadge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	IsError       bool   `json:"isError,omitempty"`
+
+	// CacheSeconds is how long shields.io may cache the badge, the freshness of the count.
+	CacheSeconds int `json:"cacheSeconds,omitempty"`
+}
+
+// handleBadge responds with the badge of a package in the shields.io endpoint format,
+// or rendered as SVG if the path ends with ".svg". The label defaults to "importers" and is set by the label parameter.
+// Failed lookups are responded to with an error badge and 200 OK, as badge renderers do not show other responses.
+func (s *server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	pkgPath, svg := strings.CutSuffix(r.PathValue("pkg"), ".svg")
+	pkgPath = normalizePackagePath(pkgPath)
+	badge := shieldsBadge{SchemaVersion: 1, Label: "importers"}
+	if label := r.URL.Query().Get("label"); label != "" {
+		badge.Label = label
+	}
+	if err := validatePackages([]string{pkgPath}); err != nil {
+		badge.Message, badge.Color, badge.IsError = "invalid package", "lightgrey", true
+		w.Header().Set("Cache-Control", "no-cache")
+	} else if result, err := s.lookup(r.Context(), pkgPath); err != nil {
+		badge.Message, badge.Color, badge.IsError = "unavailable", "lightgrey", true
+		if errors.Is(err, pkgimporters.ErrNotFound) {
+			badge.Message = "not found"
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		badge.Message, badge.Color = formatBadgeCount(result.Count), badgeColor(result.Count)
+		badge.CacheSeconds = s.setCacheHeaders(w, result)
+	}
+	if !svg {
+		writeJSON(w, http.StatusOK, badge)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	if err := writeBadgeSVG(w, badge); err != nil {
+		s.logger.Error("writing badge failed", "pkg", pkgPath, "err", err)
+	}
+}
+
+// formatBadgeCount formats n compactly for a badge, e.g., 999, 1.5k, 184k, 5.4M, rounding down.
+func formatBadgeCount(n int) string {
+	compact := func(unit float64, suffix string) string {
+		v := float64(n) / unit
+		if v < 10 {
+			return strconv.FormatFloat(math.Floor(v*10)/10, 'f', -1, 64) + suffix
+		}
+		return strconv.Itoa(int(v)) + suffix
+	}
+	switch {
+	case n < 1000:
+		return strconv.Itoa(n)
+	case n < 1_000_000:
+		return compact(1000, "k")
+	}
+	return compact(1_000_000, "M")
+}
+
+// badgeColor returns the shields.io color of a badge for count, greener for more importers.
+func badgeColor(count int) string {
+	switch {
+	case count >= 1000:
+		return "brightgreen"
+	case count >= 100:
+		return "green"
+	case count >= 10:
+		return "yellowgreen"
+	case count > 0:
+		return "yellow"
+	}
+	return "lightgrey"
+}
+
+// badgeColors maps the shields.io color names used by badgeColor to their hex values.
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"lightgrey":   "#9f9f9f",
+}
+
+// badgeTextWidth estimates the width in pixels of s in 11px Verdana, the font of shields.io badges.
+func badgeTextWidth(s string) int {
+	width := 0.0
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("ijl.,:;'|!", r):
+			width += 3.5
+		case strings.ContainsRune(" frtI()[]-", r):
+			width += 4.5
+		case strings.ContainsRune("mwMW", r):
+			width += 10.5
+		case 'A' <= r && r <= 'Z':
+			width += 7.5
+		default:
+			width += 7
+		}
+	}
+	return int(math.Ceil(width))
+}
+
+// badgeSVG is a badge in the flat style of shields.io.
+var badgeSVG = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{html .Label}}: {{html .Message}}">
+<title>{{html .Label}}: {{html .Message}}</title>
+<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="{{.LabelWidth}}" height="20" fill="#555"/><rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/><rect width="{{.Width}}" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="{{.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{html .Label}}</text><text x="{{.LabelX}}" y="14">{{html .Label}}</text>
+<text x="{{.MessageX}}" y="15" fill="#010101" fill-opacity=".3">{{html .Message}}</text><text x="{{.MessageX}}" y="14">{{html .Message}}</text>
+</g>
+</svg>
+`))
+
+// writeBadgeSVG writes badge rendered as SVG in the flat style of shields.io.
+func writeBadgeSVG(w io.Writer, badge shieldsBadge) error {
+	const padding = 10
+	labelWidth := badgeTextWidth(badge.Label) + padding
+	messageWidth := badgeTextWidth(badge.Message) + padding
+	return badgeSVG.Execute(w, struct {
+		Label, Message, Color           string
+		Width, LabelWidth, MessageWidth int
+		LabelX, MessageX                float64
+	}{
+		Label:        badge.Label,
+		Message:      badge.Message,
+		Color:        badgeColors[badge.Color],
+		Width:        labelWidth + messageWidth,
+		LabelWidth:   labelWidth,
+		MessageWidth: messageWidth,
+		LabelX:       float64(labelWidth) / 2,
+		MessageX:     float64(labelWidth) + float64(messageWidth)/2,
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestFormatBadgeCount(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1k"},
+		{1599, "1.5k"},
+		{184231, "184k"},
+		{999999, "999k"},
+		{5400000, "5.4M"},
+		{12345678, "12M"},
+	}
+	for _, tt := range tests {
+		if got := formatBadgeCount(tt.n); got != tt.want {
+			t.Errorf("formatBadgeCount(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestServerBadge(t *testing.T) {
+	source := sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+		if pkgPath == "github.com/spf13/cobra" {
+			return 184231, nil
+		}
+		return 0, pkgimporters.ErrNotFound
+	})
+	client := &pkgimporters.Client{Source: source, RequestsPerSecond: 100}
+	srv := newServer(client, &pkgimporters.MemoryCache{}, time.Hour, slog.New(slog.DiscardHandler))
+	ts := httptest.NewServer(srv.handler())
+	defer ts.Close()
+
+	get := func(path string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 OK for %s, got %s", path, resp.Status)
+		}
+		return resp, string(body)
+	}
+
+	tests := []struct {
+		path string
+		want shieldsBadge
+	}{
+		{
+			path: "/badge/github.com/spf13/cobra",
+			want: shieldsBadge{SchemaVersion: 1, Label: "importers", Message: "184k", Color: "brightgreen", CacheSeconds: 3600},
+		},
+		{
+			path: "/badge/github.com/spf13/cobra?label=used%20by",
+			want: shieldsBadge{SchemaVersion: 1, Label: "used by", Message: "184k", Color: "brightgreen", CacheSeconds: 3600},
+		},
+		{
+			path: "/badge/example.com/unknown",
+			want: shieldsBadge{SchemaVersion: 1, Label: "importers", Message: "not found", Color: "lightgrey", IsError: true},
+		},
+		{
+			path: "/badge/example.com/a:b",
+			want: shieldsBadge{SchemaVersion: 1, Label: "importers", Message: "invalid package", Color: "lightgrey", IsError: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, body := get(tt.path)
+			var got shieldsBadge
+			if err := json.Unmarshal([]byte(body), &got); err != nil {
+				t.Fatalf("decode %s: %v", body, err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	resp, body := get("/badge/github.com/spf13/cobra.svg?label=<used>")
+	if ct := resp.Header.Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("expected Content-Type image/svg+xml, got %q", ct)
+	}
+	if err := xml.Unmarshal([]byte(body), new(struct{})); err != nil {
+		t.Errorf("expected well-formed SVG, got %v:\n%s", err, body)
+	}
+	for _, want := range []string{`<title>&lt;used&gt;: 184k</title>`, `fill="#4c1"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected SVG to contain %q, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// checkpoint records the results of completed packages in a JSON file as they finish,
+// so that an interrupted run can be resumed without fetching them again.
+type checkpoint struct {
+	file string
+
+	mu      sync.Mutex
+	results map[string]pkgimporters.Result
+}
+
+// loadCheckpoint returns the checkpoint stored in file.
+// A missing file is an empty checkpoint.
+func loadCheckpoint(file string) (*checkpoint, error) {
+	cp := &checkpoint{file: file, results: make(map[string]pkgimporters.Result)}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint: %w", err)
+	}
+
+	var results []pkgimporters.Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("load checkpoint %s: %w", file, err)
+	}
+	for _, r := range results {
+		// Only fetched packages are recorded.
+		r.Status = pkgimporters.StatusOK
+		cp.results[r.Path] = r
+	}
+	return cp, nil
+}
+
+// result returns the recorded result of the package path.
+func (cp *checkpoint) result(path string) (pkgimporters.Result, bool) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	r, ok := cp.results[path]
+	return r, ok
+}
+
+// record adds r to the checkpoint and rewrites the file.
+// The file is replaced atomically, so an interruption never leaves it truncated.
+func (cp *checkpoint) record(r pkgimporters.Result) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.results[r.Path] = r
+	results := make([]pkgimporters.Result, 0, len(cp.results))
+	for _, r := range cp.results {
+		results = append(results, r)
+	}
+	slices.SortFunc(results, func(a, b pkgimporters.Result) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(cp.file), filepath.Base(cp.file)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	if err := os.Rename(f.Name(), cp.file); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// checkpointCounts fetches the importer counts of the packages in pkgPaths not yet recorded in cp,
+// recording each successful result as it arrives.
+// It returns the recorded and fetched results in the order of pkgPaths,
+// handling errors like fetchCounts.
+func checkpointCounts(ctx context.Context, client *pkgimporters.Client, cp *checkpoint, pkgPaths []string, opts fetchOptions) (results, failures []pkgimporters.Result, err error) {
+	var pending []string
+	for _, path := range pkgPaths {
+		r, ok := cp.result(path)
+		if !ok {
+			pending = append(pending, path)
+			continue
+		}
+		if opts.emit != nil {
+			if err := opts.emit(r); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	fetched, failures, err := fetchCounts(ctx, client, pending, opts, cp.record)
+	if err != nil {
+		return nil, nil, err
+	}
+	failed := make(map[string]pkgimporters.Result)
+	for _, r := range fetched {
+		if r.Error != "" {
+			failed[r.Path] = r
+		}
+	}
+
+	results = make([]pkgimporters.Result, 0, len(pkgPaths))
+	for _, path := range pkgPaths {
+		if r, ok := cp.result(path); ok {
+			results = append(results, r)
+		} else if r, ok := failed[path]; ok {
+			results = append(results, r)
+		}
+	}
+	return results, failures, nil
+}
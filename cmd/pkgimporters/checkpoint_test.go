@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestCheckpointCounts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "run.json")
+	pkgPaths := []string{"io", "example.com/unknown", "fmt"}
+
+	cp, err := loadCheckpoint(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &pkgimporters.Client{Source: mapSource{"fmt": 7, "io": 5}, RequestsPerSecond: 100, Workers: 1}
+	if _, _, err := checkpointCounts(t.Context(), client, cp, pkgPaths, fetchOptions{failFast: true, strict: true}); !errors.Is(err, pkgimporters.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	// The resumed run must only fetch the packages missing from the checkpoint.
+	cp, err = loadCheckpoint(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cp.result("io"); !ok {
+		t.Fatal("expected io to be recorded before the failure")
+	}
+	var (
+		mu      sync.Mutex
+		fetched []string
+	)
+	client = &pkgimporters.Client{
+		Source:            mapSource{"fmt": 7, "io": 5, "example.com/unknown": 1},
+		RequestsPerSecond: 100,
+		OnRequest: func(pkgPath string) {
+			mu.Lock()
+			defer mu.Unlock()
+			fetched = append(fetched, pkgPath)
+		},
+	}
+	got, _, err := checkpointCounts(t.Context(), client, cp, pkgPaths, fetchOptions{failFast: true, strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []pkgimporters.Result{
+		{Path: "io", Count: 5, Status: pkgimporters.StatusOK},
+		{Path: "example.com/unknown", Count: 1, Status: pkgimporters.StatusOK},
+		{Path: "fmt", Count: 7, Status: pkgimporters.StatusOK},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if slices.Contains(fetched, "io") {
+		t.Errorf("expected io to be skipped, fetched %v", fetched)
+	}
+}
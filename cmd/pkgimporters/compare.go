@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// disagreementRatio is how many times larger the highest count of a package
+// may be than the lowest before the sources are considered to disagree.
+const disagreementRatio = 2
+
+// comparison holds the importer counts of a package from several sources.
+// Sources that do not know the package have no entry in Counts.
+type comparison struct {
+	Path     string         `json:"path"`
+	Counts   map[string]int `json:"counts"`
+	Disagree bool           `json:"disagree"`
+}
+
+// versionedComparison is the JSON object of a comparison in the JSON format.
+type versionedComparison struct {
+	SchemaVersion int `json:"schema_version"`
+	comparison
+}
+
+// compareSources fetches the importer counts of pkgPaths from each client concurrently.
+// names[i] is the name of the source used by clients[i].
+// Packages unknown to a source, or missing from its cache in offline mode, are left out of its column;
+// any other error stops the comparison.
+// The comparisons are returned in the order of pkgPaths.
+func compareSources(ctx context.Context, names []string, clients []*pkgimporters.Client, pkgPaths []string) ([]comparison, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	counts := make(map[string]map[string]int, len(pkgPaths))
+	for _, path := range pkgPaths {
+		counts[path] = make(map[string]int, len(names))
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	for i, client := range clients {
+		wg.Go(func() {
+			for r, err := range client.Stream(ctx, pkgPaths) {
+				mu.Lock()
+				switch {
+				case err == nil:
+					counts[r.Path][names[i]] = r.Count
+				case !errors.Is(err, pkgimporters.ErrNotFound) && !errors.Is(err, pkgimporters.ErrNotCached):
+					errs = append(errs, fmt.Errorf("%s: %w", names[i], err))
+					cancel()
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	comparisons := make([]comparison, 0, len(pkgPaths))
+	for _, path := range pkgPaths {
+		comparisons = append(comparisons, comparison{
+			Path:     path,
+			Counts:   counts[path],
+			Disagree: disagree(counts[path], len(names)),
+		})
+	}
+	return comparisons, nil
+}
+
+// disagree reports whether the counts from n sources disagree:
+// some source does not know the package, or the counts differ by more than disagreementRatio.
+func disagree(counts map[string]int, n int) bool {
+	if len(counts) < n {
+		return true
+	}
+	lowest, highest := -1, 0
+	for _, count := range counts {
+		if lowest < 0 || count < lowest {
+			lowest = count
+		}
+		highest = max(highest, count)
+	}
+	return highest > disagreementRatio*lowest
+}
+
+// renderComparisons writes comparisons to w with one column per source, in the given format.
+func renderComparisons(w io.Writer, format string, names []string, comparisons []comparison) error {
+	switch format {
+	case "text":
+		return renderComparisonsText(w, names, comparisons)
+	case "json":
+		versioned := make([]versionedComparison, 0, len(comparisons))
+		for _, c := range comparisons {
+			versioned = append(versioned, versionedComparison{SchemaVersion: pkgimporters.SchemaVersion, comparison: c})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(versioned)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(append(append([]string{"schema_version", "path"}, names...), "disagree")); err != nil {
+			return err
+		}
+		for _, c := range comparisons {
+			record := []string{strconv.Itoa(pkgimporters.SchemaVersion), c.Path}
+			for _, name := range names {
+				count, ok := c.Counts[name]
+				if !ok {
+					record = append(record, "")
+					continue
+				}
+				record = append(record, strconv.Itoa(count))
+			}
+			record = append(record, strconv.FormatBool(c.Disagree))
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("invalid -format value for -sources: %q (must be 'text', 'json', or 'csv')", format)
+	}
+}
+
+// renderComparisonsText writes an aligned table of comparisons to w.
+// Unknown counts are shown as "-" and rows where the sources disagree are marked with "!".
+func renderComparisonsText(w io.Writer, names []string, comparisons []comparison) error {
+	rows := make([][]string, 0, len(comparisons)+1)
+	rows = append(rows, append([]string{"PATH"}, names...))
+	for _, c := range comparisons {
+		row := []string{c.Path}
+		for _, name := range names {
+			count, ok := c.Counts[name]
+			if !ok {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, pkgimporters.FormatCount(count))
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(names)+1)
+	widths[0] = 20
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	for i, row := range rows {
+		var line strings.Builder
+		for j, cell := range row {
+			if j > 0 {
+				line.WriteByte(' ')
+			}
+			fmt.Fprintf(&line, "%-*s", widths[j], cell)
+		}
+		if i > 0 && comparisons[i-1].Disagree {
+			line.WriteString(" !")
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line.String(), " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
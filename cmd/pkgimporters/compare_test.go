@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// mapSource is a pkgimporters.Source serving counts from a map.
+type mapSource map[string]int
+
+func (s mapSource) Count(_ context.Context, pkgPath string) (int, error) {
+	count, ok := s[pkgPath]
+	if !ok {
+		return 0, pkgimporters.ErrNotFound
+	}
+	return count, nil
+}
+
+func TestCompareSources(t *testing.T) {
+	names := []string{"a", "b"}
+	clients := []*pkgimporters.Client{
+		{Source: mapSource{"fmt": 100, "github.com/spf13/cobra": 50, "example.com/x": 10}},
+		{Source: mapSource{"github.com/spf13/cobra": 60, "example.com/x": 30}},
+	}
+	pkgPaths := []string{"fmt", "github.com/spf13/cobra", "example.com/x"}
+
+	comparisons, err := compareSources(t.Context(), names, clients, pkgPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := renderComparisons(&out, "text", names, comparisons); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"PATH                   a   b\n" +
+		"fmt                    100 -  !\n" +
+		"github.com/spf13/cobra 50  60\n" +
+		"example.com/x          10  30 !\n"
+	if got := out.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	out.Reset()
+	if err := renderComparisons(&out, "csv", names, comparisons); err != nil {
+		t.Fatal(err)
+	}
+	want = "schema_version,path,a,b,disagree\n1,fmt,100,,true\n1,github.com/spf13/cobra,50,60,false\n1,example.com/x,10,30,true\n"
+	if got := out.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	out.Reset()
+	if err := renderComparisons(&out, "json", names, comparisons[:1]); err != nil {
+		t.Fatal(err)
+	}
+	want = `[{"schema_version":1,"path":"fmt","counts":{"a":100},"disagree":true}]`
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(out.String())); err != nil {
+		t.Fatal(err)
+	}
+	if got := compact.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestCompareSourcesError(t *testing.T) {
+	errBoom := errors.New("boom")
+	clients := []*pkgimporters.Client{
+		{Source: mapSource{"fmt": 100}},
+		{Source: errSource{errBoom}},
+	}
+
+	_, err := compareSources(t.Context(), []string{"a", "b"}, clients, []string{"fmt"})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected boom error, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "b: ") {
+		t.Errorf("expected error to name the source, got %q", err)
+	}
+}
+
+// errSource is a pkgimporters.Source that always fails.
+type errSource struct{ err error }
+
+func (s errSource) Count(context.Context, string) (int, error) {
+	return 0, fmt.Errorf("count: %w", s.err)
+}
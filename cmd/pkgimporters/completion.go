@@ -0,0 +1,205 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// completionShells are the shells supported by the completion subcommand.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completion describes what a completion script completes.
+type completion struct {
+	prog     string
+	flags    []completionFlag
+	commands []string // subcommands, completed as the first argument
+	packages []string // package names, completed as arguments and -pkgs values
+}
+
+// completionFlag is a flag of the main command.
+type completionFlag struct {
+	name   string
+	usage  string
+	isBool bool
+	values []string // values completed for the flag, if known
+}
+
+// runCompletion runs the completion subcommand, which prints a completion script for a shell
+// completing the flags of main.
+func runCompletion(main *flag.FlagSet, args []string, stdout, stderr io.Writer) error {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("completion", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s completion - generate a shell completion script\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s completion bash|zsh|fish\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s completion prints a script completing the flags of %[1]s, the values of -format,\n"+
+			"    -sort, -source, and -log-format, and the standard library packages, listed with the go command.\n\n"+
+			"EXAMPLES\n"+
+			"    source <(%[1]s completion bash)\n"+
+			"        Enable completion in the current bash session\n\n"+
+			"    %[1]s completion zsh > \"${fpath[1]}/_%[1]s\"\n"+
+			"        Install completion for zsh\n\n"+
+			"    %[1]s completion fish > ~/.config/fish/completions/%[1]s.fish\n"+
+			"        Install completion for fish\n", progName)
+	}
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 || !slices.Contains(completionShells, args[0]) {
+		return &cmdError{code: 2, msg: fmt.Sprintf("completion requires one shell: %s; use completion -h for help", strings.Join(completionShells, ", "))}
+	}
+
+	values := map[string][]string{
+		"format":     pkgimporters.RendererNames(),
+		"sort":       {"name", "count"},
+		"source":     sourceNames,
+		"log-format": {"text", "json"},
+	}
+	// The packages are only completed if the go command can list them.
+	packages, _ := loadPackagePaths("std", loadOptions{})
+	// mainSubcommands are listed by name, as they include runCompletion itself.
+	commands := slices.DeleteFunc(append(slices.Collect(maps.Keys(subcommands)), "completion", "doctor", "man"), func(name string) bool {
+		return name == "internal"
+	})
+	slices.Sort(commands)
+	c := completion{prog: progName, commands: commands, packages: append([]string{"std", "cmd"}, packages...)}
+	main.VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		bf, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		cf := completionFlag{name: f.Name, usage: usage, isBool: isBool && bf.IsBoolFlag(), values: values[f.Name]}
+		if f.Name == "pkgs" {
+			cf.values = c.packages
+		}
+		c.flags = append(c.flags, cf)
+	})
+
+	switch args[0] {
+	case "bash":
+		return c.writeBash(stdout)
+	case "zsh":
+		return c.writeZsh(stdout)
+	default:
+		return c.writeFish(stdout)
+	}
+}
+
+// funcName returns the name of the shell function completing c.prog.
+func (c completion) funcName() string {
+	return "_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, c.prog)
+}
+
+func (c completion) writeBash(w io.Writer) error {
+	var b strings.Builder
+	fn := c.funcName()
+	fmt.Fprintf(&b, "# bash completion for %s\n\n", c.prog)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("\tlocal cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}\n")
+	fmt.Fprintf(&b, "\tlocal packages=%s\n", shellQuote(strings.Join(c.packages, " ")))
+	b.WriteString("\tcase $prev in\n")
+	var valueFlags []string
+	for _, f := range c.flags {
+		switch {
+		case f.name == "pkgs":
+			fmt.Fprintf(&b, "\t-%s | --%[1]s)\n\t\tCOMPREPLY=($(compgen -W \"$packages\" -- \"$cur\"))\n\t\treturn\n\t\t;;\n", f.name)
+		case f.values != nil:
+			fmt.Fprintf(&b, "\t-%s | --%[1]s)\n\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n\t\treturn\n\t\t;;\n", f.name, shellQuote(strings.Join(f.values, " ")))
+		case !f.isBool:
+			valueFlags = append(valueFlags, "-"+f.name, "--"+f.name)
+		}
+	}
+	if len(valueFlags) > 0 {
+		fmt.Fprintf(&b, "\t%s)\n\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n\t\treturn\n\t\t;;\n", strings.Join(valueFlags, " | "))
+	}
+	b.WriteString("\tesac\n")
+	names := make([]string, 0, len(c.flags))
+	for _, f := range c.flags {
+		names = append(names, "-"+f.name)
+	}
+	fmt.Fprintf(&b, "\tif [[ $cur == -* ]]; then\n\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(names, " ")))
+	fmt.Fprintf(&b, "\telif ((COMP_CWORD == 1)); then\n\t\tCOMPREPLY=($(compgen -W %s\" $packages\" -- \"$cur\"))\n", shellQuote(strings.Join(c.commands, " ")))
+	b.WriteString("\telse\n\t\tCOMPREPLY=($(compgen -W \"$packages\" -- \"$cur\"))\n\tfi\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, c.prog)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (c completion) writeZsh(w io.Writer) error {
+	var b strings.Builder
+	fn := c.funcName()
+	fmt.Fprintf(&b, "#compdef %s\n\n", c.prog)
+	fmt.Fprintf(&b, "%s_packages() {\n", fn)
+	fmt.Fprintf(&b, "\tlocal -a packages=(%s)\n", strings.Join(c.packages, " "))
+	fmt.Fprintf(&b, "\t((CURRENT == 2)) && packages+=(%s)\n", strings.Join(c.commands, " "))
+	b.WriteString("\tcompadd \"$@\" -a packages\n}\n\n")
+	fmt.Fprintf(&b, "%s() {\n\t_arguments -S \\\n", fn)
+	for _, f := range c.flags {
+		spec := "-" + f.name + "[" + zshEscape(f.usage) + "]"
+		switch {
+		case f.name == "pkgs":
+			spec += ":packages:" + fn + "_packages"
+		case f.values != nil:
+			spec += ":" + f.name + ":(" + strings.Join(f.values, " ") + ")"
+		case !f.isBool:
+			spec += ":" + f.name + ":_files"
+		}
+		fmt.Fprintf(&b, "\t\t%s \\\n", shellQuote(spec))
+	}
+	fmt.Fprintf(&b, "\t\t%s\n}\n\n", shellQuote("*:package:"+fn+"_packages"))
+	fmt.Fprintf(&b, "%s \"$@\"\n", fn)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (c completion) writeFish(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n\n", c.prog)
+	fmt.Fprintf(&b, "complete -c %s -f\n", c.prog)
+	for _, f := range c.flags {
+		fmt.Fprintf(&b, "complete -c %s -o %s -d %s", c.prog, f.name, fishQuote(f.usage))
+		switch {
+		case f.values != nil:
+			fmt.Fprintf(&b, " -x -a %s", fishQuote(strings.Join(f.values, " ")))
+		case !f.isBool:
+			b.WriteString(" -r -F")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "complete -c %s -n __fish_use_subcommand -a %s\n", c.prog, fishQuote(strings.Join(c.commands, " ")))
+	fmt.Fprintf(&b, "complete -c %s -a %s\n", c.prog, fishQuote(strings.Join(c.packages, " ")))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// shellQuote quotes s for bash and zsh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish, where backslashes and single quotes are escaped in single quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// zshEscape escapes the characters of s that are special in the description of an _arguments spec.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func testCompletion() completion {
+	return completion{
+		prog: "pkgimporters",
+		flags: []completionFlag{
+			{name: "cache-dir", usage: "directory of the cache"},
+			{name: "format", usage: "output format: 'text' or 'json'", values: []string{"csv", "json", "text"}},
+			{name: "pkgs", usage: "packages [pkg1,pkg2]: or std", values: []string{"std", "cmd", "fmt", "io", "io/fs"}},
+			{name: "v", usage: "log requests", isBool: true},
+		},
+		commands: []string{"audit", "completion", "list"},
+		packages: []string{"std", "cmd", "fmt", "io", "io/fs"},
+	}
+}
+
+func TestCompletionBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	var b strings.Builder
+	if err := testCompletion().writeBash(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"pkgimporters", "-f"}, []string{"-format"}},
+		{[]string{"pkgimporters", "-format", "j"}, []string{"json"}},
+		{[]string{"pkgimporters", "-pkgs", "io"}, []string{"io", "io/fs"}},
+		{[]string{"pkgimporters", "a"}, []string{"audit"}},
+		{[]string{"pkgimporters", "-v", "f"}, []string{"fmt"}},
+		{[]string{"pkgimporters", "fmt", "c"}, []string{"cmd"}},
+	} {
+		script := b.String() + "COMP_WORDS=(" + strings.Join(tt.words, " ") + ")\n" +
+			"COMP_CWORD=" + strconv.Itoa(len(tt.words)-1) + "\n" +
+			"_pkgimporters\nprintf '%s\\n' \"${COMPREPLY[@]}\"\n"
+		out, err := exec.Command(bash, "-c", script).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: %v\n%s", tt.words, err, out)
+		}
+		if got := strings.Fields(string(out)); !slices.Equal(got, tt.want) {
+			t.Errorf("%v: expected completions %v, got %v", tt.words, tt.want, got)
+		}
+	}
+}
+
+func TestCompletionZsh(t *testing.T) {
+	var b strings.Builder
+	if err := testCompletion().writeZsh(&b); err != nil {
+		t.Fatal(err)
+	}
+	script := b.String()
+	for _, want := range []string{
+		"#compdef pkgimporters\n",
+		"local -a packages=(std cmd fmt io io/fs)\n",
+		"((CURRENT == 2)) && packages+=(audit completion list)\n",
+		`'-cache-dir[directory of the cache]:cache-dir:_files' \`,
+		`'-format[output format\: '\''text'\'' or '\''json'\'']:format:(csv json text)' \`,
+		`'-pkgs[packages \[pkg1,pkg2\]\: or std]:packages:_pkgimporters_packages' \`,
+		`'-v[log requests]' \`,
+		"'*:package:_pkgimporters_packages'\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected the script to contain %q, got:\n%s", want, script)
+		}
+	}
+}
+
+func TestCompletionFish(t *testing.T) {
+	var b strings.Builder
+	if err := testCompletion().writeFish(&b); err != nil {
+		t.Fatal(err)
+	}
+	script := b.String()
+	for _, want := range []string{
+		"complete -c pkgimporters -f\n",
+		"complete -c pkgimporters -o cache-dir -d 'directory of the cache' -r -F\n",
+		`complete -c pkgimporters -o format -d 'output format: \'text\' or \'json\'' -x -a 'csv json text'` + "\n",
+		"complete -c pkgimporters -o v -d 'log requests'\n",
+		"complete -c pkgimporters -n __fish_use_subcommand -a 'audit completion list'\n",
+		"complete -c pkgimporters -a 'std cmd fmt io io/fs'\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected the script to contain %q, got:\n%s", want, script)
+		}
+	}
+}
+
+func TestRunCompletion(t *testing.T) {
+	main := flag.NewFlagSet("pkgimporters", flag.ContinueOnError)
+	for _, args := range [][]string{nil, {"powershell"}, {"bash", "zsh"}} {
+		var cmdErr *cmdError
+		if err := runCompletion(main, args, io.Discard, io.Discard); !errors.As(err, &cmdErr) || cmdErr.code != 2 {
+			t.Errorf("%v: expected a usage error, got %v", args, err)
+		}
+	}
+}
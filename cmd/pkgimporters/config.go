@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// defaultConfigFile returns the config file read unless -config is set,
+// e.g., ~/.config/pkgimporters/config.yaml on Linux, or "" if there is no user config directory.
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pkgimporters", "config.yaml")
+}
+
+// envPrefix is the prefix of the environment variables setting flags, e.g., PKGIMPORTERS_WORKERS for -workers.
+const envPrefix = "PKGIMPORTERS_"
+
+// envName returns the environment variable setting the flag name, e.g., PKGIMPORTERS_CACHE_DIR for -cache-dir.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnv sets the flags that were not set on the command line from PKGIMPORTERS_* environment variables,
+// looked up with getenv, e.g., os.Getenv. Flags set by applyEnv count as set for applyConfig,
+// so environment variables take precedence over the config file.
+func applyEnv(flags *flag.FlagSet, getenv func(string) string) error {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		value := getenv(envName(f.Name))
+		if err != nil || set[f.Name] || value == "" {
+			return
+		}
+		if setErr := flags.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid %s value %q: %w", envName(f.Name), value, setErr)
+		}
+	})
+	return err
+}
+
+// setsKey is the key of the config file defining named package sets rather than a flag.
+const setsKey = "sets"
+
+// applyConfig sets the flags that were not set on the command line
+// to the defaults in the YAML config file name, whose keys are flag names without the dash, e.g.:
+//
+//	workers: 8
+//	rps: 2
+//	format: json
+//	exclude: [crypto/..., testing/...]
+//	sets:
+//	  myorg: [github.com/myorg/a, github.com/myorg/b]
+//
+// Lists are joined with commas, as comma-separated flags expect.
+// It returns the named package sets defined under the sets key, referenced as "@name" by -pkgs.
+// A missing file is ignored unless required, e.g., because it was named by -config.
+func applyConfig(flags *flag.FlagSet, name string, required bool) (sets map[string][]string, err error) {
+	if name == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var config map[string]any
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", name, err)
+	}
+	var setsConfig struct {
+		Sets map[string][]string `yaml:"sets"`
+	}
+	if err := yaml.Unmarshal(data, &setsConfig); err != nil {
+		return nil, fmt.Errorf("config file %s: sets must map names to lists of packages: %w", name, err)
+	}
+	delete(config, setsKey)
+
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for key, value := range config {
+		f := flags.Lookup(key)
+		if f == nil || key == "config" {
+			return nil, fmt.Errorf("config file %s: unknown flag %q", name, key)
+		}
+		if set[key] {
+			continue
+		}
+		s, err := configValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %s: %w", name, key, err)
+		}
+		if err := f.Value.Set(s); err != nil {
+			return nil, fmt.Errorf("config file %s: invalid %s value %q: %w", name, key, s, err)
+		}
+	}
+	return setsConfig.Sets, nil
+}
+
+// configValue returns the flag value of a value decoded from the config file.
+func configValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case []any:
+		elems := make([]string, len(v))
+		for i, e := range v {
+			s, err := configValue(e)
+			if err != nil {
+				return "", err
+			}
+			elems[i] = s
+		}
+		return strings.Join(elems, ","), nil
+	case time.Time:
+		// YAML decodes unquoted dates and times, e.g., for index-since.
+		return v.Format(time.RFC3339), nil
+	case map[string]any:
+		return "", errors.New("must be a value or a list")
+	}
+	return fmt.Sprint(v), nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestApplyConfig(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.yaml")
+	config := "workers: 8\n" +
+		"format: json\n" +
+		"offline: true\n" +
+		"exclude: [crypto/..., testing/...]\n" +
+		"cache-ttl: 24h\n" +
+		"index-since: 2024-01-02\n"
+	if err := os.WriteFile(name, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("pkgimporters", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	workers := fs.Int("workers", 4, "")
+	format := fs.String("format", "text", "")
+	offline := fs.Bool("offline", false, "")
+	exclude := fs.String("exclude", "", "")
+	cacheTTL := fs.Duration("cache-ttl", time.Hour, "")
+	indexSince := fs.String("index-since", "", "")
+	if err := fs.Parse([]string{"-format", "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := applyConfig(fs, name, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if *workers != 8 || !*offline || *cacheTTL != 24*time.Hour {
+		t.Errorf("expected the config values, got workers %d, offline %v, cache-ttl %v", *workers, *offline, *cacheTTL)
+	}
+	if *format != "csv" {
+		t.Errorf("expected the command line to take precedence, got format %q", *format)
+	}
+	if *exclude != "crypto/...,testing/..." {
+		t.Errorf("expected a comma-separated list, got %q", *exclude)
+	}
+	if *indexSince != "2024-01-02T00:00:00Z" {
+		t.Errorf("expected an RFC 3339 time, got %q", *indexSince)
+	}
+
+	if _, err := applyConfig(fs, filepath.Join(t.TempDir(), "missing.yaml"), false); err != nil {
+		t.Errorf("expected a missing default config file to be ignored, got %v", err)
+	}
+	if _, err := applyConfig(fs, filepath.Join(t.TempDir(), "missing.yaml"), true); err == nil {
+		t.Error("expected an error for a missing -config file")
+	}
+	for _, config := range []string{"unknown: 1\n", "workers: many\n", "workers: {a: 1}\n", "workers: [\n", "sets: [a, b]\n"} {
+		if err := os.WriteFile(name, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		fs := flag.NewFlagSet("pkgimporters", flag.ContinueOnError)
+		fs.Int("workers", 4, "")
+		if _, err := applyConfig(fs, name, true); err == nil {
+			t.Errorf("expected an error for config %q", config)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"PKGIMPORTERS_WORKERS":   "8",
+		"PKGIMPORTERS_FORMAT":    "json",
+		"PKGIMPORTERS_CACHE_DIR": "/cache",
+	}
+	fs := flag.NewFlagSet("pkgimporters", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	workers := fs.Int("workers", 4, "")
+	format := fs.String("format", "text", "")
+	cacheDir := fs.String("cache-dir", "", "")
+	rps := fs.Float64("rps", 1, "")
+	if err := fs.Parse([]string{"-format", "csv"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyEnv(fs, func(key string) string { return env[key] }); err != nil {
+		t.Fatal(err)
+	}
+	if *workers != 8 || *cacheDir != "/cache" || *rps != 1 {
+		t.Errorf("expected the environment values, got workers %d, cache-dir %q, rps %v", *workers, *cacheDir, *rps)
+	}
+	if *format != "csv" {
+		t.Errorf("expected the command line to take precedence, got format %q", *format)
+	}
+
+	name := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(name, []byte("workers: 2\nrps: 5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := applyConfig(fs, name, true); err != nil {
+		t.Fatal(err)
+	}
+	if *workers != 8 || *rps != 5 {
+		t.Errorf("expected the environment to take precedence over the config file, got workers %d, rps %v", *workers, *rps)
+	}
+
+	env["PKGIMPORTERS_RPS"] = "fast"
+	fs = flag.NewFlagSet("pkgimporters", flag.ContinueOnError)
+	fs.Float64("rps", 1, "")
+	if err := applyEnv(fs, func(key string) string { return env[key] }); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
+
+func TestApplyConfigSets(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.yaml")
+	config := "workers: 8\n" +
+		"sets:\n" +
+		"  myorg: [github.com/myorg/a, github.com/myorg/b]\n" +
+		"  stdlib: [std]\n"
+	if err := os.WriteFile(name, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("pkgimporters", flag.ContinueOnError)
+	fs.Int("workers", 4, "")
+	sets, err := applyConfig(fs, name, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"myorg": {"github.com/myorg/a", "github.com/myorg/b"}, "stdlib": {"std"}}
+	if !maps.EqualFunc(sets, want, slices.Equal) {
+		t.Errorf("expected sets %v, got %v", want, sets)
+	}
+}
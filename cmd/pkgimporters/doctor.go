@@ -0,0 +1,228 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// doctorPackage is the package whose importers doctor counts with every source,
+// a popular third-party package known to all of them.
+const doctorPackage = "github.com/google/uuid"
+
+// runDoctor runs the doctor subcommand, which checks the setup of the main command:
+// its config file, the connectivity to pkg.go.dev and the configured sources, the parser, and the cache directory.
+// It accepts the flags of main, which are also read from the environment and the config file as in a run.
+func runDoctor(main *flag.FlagSet, args []string, stdout, stderr io.Writer) error {
+	progName := filepath.Base(os.Args[0])
+	main.SetOutput(stderr)
+	main.Usage = func() {
+		fmt.Fprintf(main.Output(), "NAME\n"+
+			"    %[1]s doctor - diagnose connectivity, sources, parser, and cache\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s doctor [flags]\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s doctor checks the config file, the connectivity to -base-url, the sources set with -source,\n"+
+			"    -source-fallback, and -sources by counting the importers of %[2]s, which also tests\n"+
+			"    the pkg.go.dev parser, and that -cache-dir is writable. Failed checks are printed with a hint.\n"+
+			"    It accepts the flags of %[1]s, which are also read from the environment and the config file,\n"+
+			"    so it diagnoses the setup of a run with the same flags. Run '%[1]s -h' for the flags.\n\n"+
+			"EXAMPLES\n"+
+			"    %[1]s doctor\n"+
+			"        Check the default setup\n\n"+
+			"    %[1]s doctor -sources pkggodev,depsdev -cache-dir ~/.cache/pkgimporters\n"+
+			"        Check two sources and a cache directory\n", progName, doctorPackage)
+	}
+	if err := main.Parse(args); err != nil {
+		return err
+	}
+	if main.NArg() > 0 {
+		return &cmdError{code: 2, msg: "doctor takes no arguments; use doctor -h for help"}
+	}
+	if err := applyEnv(main, os.Getenv); err != nil {
+		return &cmdError{code: 2, msg: err.Error()}
+	}
+	flagValue := func(name string) any {
+		return main.Lookup(name).Value.(flag.Getter).Get()
+	}
+	configFile := flagValue("config").(string)
+	d := doctor{configFile: cmp.Or(configFile, defaultConfigFile())}
+	_, d.configErr = applyConfig(main, d.configFile, configFile != "")
+
+	d.baseURL = flagValue("base-url").(string)
+	d.cacheDir = flagValue("cache-dir").(string)
+	d.timeout = flagValue("timeout").(time.Duration)
+	for _, name := range []string{"source", "source-fallback", "sources"} {
+		for _, s := range splitSourceNames(flagValue(name).(string)) {
+			if !slices.Contains(d.sources, s) {
+				d.sources = append(d.sources, s)
+			}
+		}
+	}
+	transport, err := newTransport(transportOptions{
+		proxy:              flagValue("proxy").(string),
+		caCert:             flagValue("cacert").(string),
+		insecureSkipVerify: flagValue("insecure-skip-verify").(bool),
+	})
+	if err != nil {
+		return &cmdError{code: 2, msg: err.Error()}
+	}
+	d.srcOpts = sourceOptions{
+		httpClient:       &http.Client{Transport: pkgimporters.WithHeader("User-Agent", flagValue("user-agent").(string))(transport)},
+		baseURL:          d.baseURL,
+		sourcegraphURL:   flagValue("sourcegraph-url").(string),
+		sourcegraphToken: flagValue("sourcegraph-token").(string),
+		librariesIOKey:   flagValue("librariesio-key").(string),
+	}
+
+	if failed, total := d.run(context.Background(), stdout); failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, total)
+	}
+	return nil
+}
+
+// doctor checks the setup of the main command.
+type doctor struct {
+	configFile string
+	configErr  error // error reading configFile
+	baseURL    string
+	sources    []string
+	srcOpts    sourceOptions
+	cacheDir   string
+	timeout    time.Duration
+}
+
+// doctorCheck is a check of doctor, returning a detail of its success.
+type doctorCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+	hint string // how to fix a failure, if not derived from its error by doctorHint
+}
+
+// run runs the checks, writing "ok" or "FAIL" with a detail for each to w, followed by a hint for failures,
+// and returns the number of failed and total checks.
+func (d doctor) run(ctx context.Context, w io.Writer) (failed, total int) {
+	checks := []doctorCheck{
+		{name: "config", run: d.checkConfig, hint: "fix the config file, or name another one with -config"},
+		{name: "connectivity", run: d.checkConnectivity},
+	}
+	for _, name := range d.sources {
+		checks = append(checks, doctorCheck{name: "source " + name, run: func(ctx context.Context) (string, error) {
+			return d.checkSource(ctx, name)
+		}})
+	}
+	checks = append(checks, doctorCheck{name: "cache", run: d.checkCache, hint: "set -cache-dir to a writable directory"})
+
+	for _, c := range checks {
+		ctx, cancel := context.WithTimeout(ctx, d.timeout)
+		detail, err := c.run(ctx)
+		cancel()
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "FAIL %s: %v\n", c.name, err)
+			if hint := cmp.Or(c.hint, doctorHint(err)); hint != "" {
+				fmt.Fprintf(w, "     hint: %s\n", hint)
+			}
+			continue
+		}
+		fmt.Fprintf(w, "ok   %s: %s\n", c.name, detail)
+	}
+	return failed, len(checks)
+}
+
+func (d doctor) checkConfig(context.Context) (string, error) {
+	if d.configErr != nil {
+		return "", d.configErr
+	}
+	if _, err := os.Stat(d.configFile); err != nil {
+		return "no config file at " + d.configFile, nil
+	}
+	return "read " + d.configFile, nil
+}
+
+// checkConnectivity requests the base URL, which is reachable if it responds at all.
+func (d doctor) checkConnectivity(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.baseURL, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	start := time.Now()
+	resp, err := d.srcOpts.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return fmt.Sprintf("%s responded %s in %v", d.baseURL, resp.Status, time.Since(start).Round(time.Millisecond)), nil
+}
+
+// checkSource counts the importers of doctorPackage with the source name,
+// which fails for an unreachable source, invalid credentials, or a broken parser.
+func (d doctor) checkSource(ctx context.Context, name string) (string, error) {
+	source, err := newSource(name, d.srcOpts)
+	if err != nil {
+		return "", err
+	}
+	count, err := source.Count(ctx, doctorPackage)
+	if err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return "", fmt.Errorf("%s has no known importers: %w", doctorPackage, pkgimporters.ErrParse)
+	}
+	return fmt.Sprintf("%s has %s importers", doctorPackage, pkgimporters.FormatCount(count)), nil
+}
+
+// checkCache checks that a file can be created in the cache directory, if any.
+func (d doctor) checkCache(context.Context) (string, error) {
+	if d.cacheDir == "" {
+		return "no -cache-dir; counts are kept in memory only", nil
+	}
+	if err := os.MkdirAll(d.cacheDir, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(d.cacheDir, ".doctor-*")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return "", err
+	}
+	return d.cacheDir + " is writable", nil
+}
+
+// doctorHint returns how to fix the failure of a check with err, or "" if unknown.
+func doctorHint(err error) string {
+	var statusErr *pkgimporters.StatusError
+	var certErr *tls.CertificateVerificationError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, pkgimporters.ErrParse):
+		return "the page layout may have changed; update pkgimporters with 'go install github.com/alexandear/pkgimporters/cmd/pkgimporters@latest'"
+	case errors.Is(err, pkgimporters.ErrBlocked):
+		return "the requests are blocked; lower -rps, set -user-agent with contact information, or try again later"
+	case errors.Is(err, pkgimporters.ErrNotFound):
+		return "the source does not know the package; it may not index it yet"
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode == http.StatusServiceUnavailable):
+		return "the requests are rate limited; lower -rps and -workers"
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden):
+		return "check the credentials of the source, e.g., -sourcegraph-token or -librariesio-key"
+	case errors.As(err, &certErr):
+		return "set -cacert to the CA certificate of a TLS-intercepting proxy"
+	case errors.As(err, &netErr):
+		return "check the network connection and -proxy, $HTTPS_PROXY, or $NO_PROXY"
+	}
+	return ""
+}
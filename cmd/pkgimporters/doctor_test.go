@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters/pkgimporterstest"
+)
+
+func TestDoctor(t *testing.T) {
+	srv := pkgimporterstest.NewServer()
+	defer srv.Close()
+	srv.SetPackage(doctorPackage, pkgimporterstest.Package{Importers: 12345})
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	newDoctor := func() doctor {
+		return doctor{
+			configFile: filepath.Join(dir, "config.yaml"),
+			baseURL:    srv.URL,
+			sources:    []string{"pkggodev"},
+			srcOpts:    sourceOptions{httpClient: srv.Client(), baseURL: srv.URL},
+			cacheDir:   filepath.Join(dir, "cache"),
+			timeout:    time.Minute,
+		}
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		var b strings.Builder
+		failed, total := newDoctor().run(context.Background(), &b)
+		if failed != 0 || total != 4 {
+			t.Errorf("expected 0 of 4 checks to fail, got %d of %d:\n%s", failed, total, b.String())
+		}
+		for _, want := range []string{
+			"ok   config: no config file at " + filepath.Join(dir, "config.yaml") + "\n",
+			"ok   connectivity: " + srv.URL + " responded ",
+			"ok   source pkggodev: " + doctorPackage + " has 12,345 importers\n",
+			"ok   cache: " + filepath.Join(dir, "cache") + " is writable\n",
+		} {
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, b.String())
+			}
+		}
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		srv.SetBlocked(true)
+		defer srv.SetBlocked(false)
+		d := newDoctor()
+		d.configErr = errors.New("config file config.yaml: unknown flag \"wrokers\"")
+		d.cacheDir = file
+		d.sources = append(d.sources, "librariesio")
+
+		var b strings.Builder
+		failed, total := d.run(context.Background(), &b)
+		if failed != 4 || total != 5 {
+			t.Errorf("expected 4 of 5 checks to fail, got %d of %d:\n%s", failed, total, b.String())
+		}
+		for _, want := range []string{
+			"FAIL config: config file config.yaml: unknown flag \"wrokers\"\n     hint: fix the config file, or name another one with -config\n",
+			"FAIL source pkggodev: ",
+			"     hint: the requests are blocked; lower -rps, set -user-agent with contact information, or try again later\n",
+			"FAIL source librariesio: -source librariesio requires an API key",
+			"FAIL cache: ",
+			"     hint: set -cache-dir to a writable directory\n",
+		} {
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, b.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// fetchOptions controls how fetchCounts handles packages that cannot be fetched
+// and how it reports results as they arrive.
+type fetchOptions struct {
+	// failFast stops at the first failed package.
+	failFast bool
+
+	// strict treats unknown packages and pages without a count as failures,
+	// instead of only reporting them.
+	strict bool
+
+	// emit, if non-nil, is called with every result as it arrives, including failed packages,
+	// e.g., to print it with -stream.
+	emit func(pkgimporters.Result) error
+}
+
+// isFailure reports whether err fails the run.
+// Unknown packages and pages without a count only do in strict mode.
+func (o fetchOptions) isFailure(err error) bool {
+	return o.strict || !errors.Is(err, pkgimporters.ErrNotFound) && !errors.Is(err, pkgimporters.ErrParse)
+}
+
+// fetchCounts fetches the importer counts of pkgPaths, calling record, if non-nil,
+// for each successful result as it arrives, and opts.emit, if non-nil, for every result.
+// Packages that cannot be fetched are returned as results with Error set.
+// Those that fail the run are also returned as failures;
+// with opts.failFast, fetchCounts stops at the first of them and returns its error instead.
+// It stops if ctx is done.
+// The results are returned in the order of pkgPaths.
+func fetchCounts(ctx context.Context, client *pkgimporters.Client, pkgPaths []string, opts fetchOptions, record func(pkgimporters.Result) error) (results, failures []pkgimporters.Result, err error) {
+	byPath := make(map[string]pkgimporters.Result, len(pkgPaths))
+	failed := make(map[string]bool)
+	for r, err := range client.Stream(ctx, pkgPaths) {
+		if err != nil {
+			if ctx.Err() != nil || opts.failFast && opts.isFailure(err) {
+				return nil, nil, err
+			}
+			r.Count = 0
+			r.Error = packageErrorMessage(ctx, client, err)
+			failed[r.Path] = opts.isFailure(err)
+		} else if record != nil {
+			if err := record(r); err != nil {
+				return nil, nil, err
+			}
+		}
+		byPath[r.Path] = r
+		if opts.emit != nil {
+			if err := opts.emit(r); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	results = make([]pkgimporters.Result, 0, len(pkgPaths))
+	for _, path := range pkgPaths {
+		r, ok := byPath[path]
+		if !ok {
+			continue
+		}
+		results = append(results, r)
+		if failed[path] {
+			failures = append(failures, r)
+		}
+	}
+	return results, failures, nil
+}
+
+// packageErrorMessage returns the message of a failed package fetch without the package path,
+// suggesting a similar package if the package is unknown.
+func packageErrorMessage(ctx context.Context, client *pkgimporters.Client, err error) string {
+	var pkgErr *pkgimporters.PackageError
+	if !errors.As(err, &pkgErr) {
+		return err.Error()
+	}
+	msg := pkgErr.Err.Error()
+	if errors.Is(err, pkgimporters.ErrNotFound) {
+		if suggestion := suggestPackage(ctx, client, pkgErr.Path); suggestion != "" {
+			msg = fmt.Sprintf("%s; did you mean %s?", msg, suggestion)
+		}
+	}
+	return msg
+}
+
+// failuresError returns an error listing the failed packages out of total, or nil if there are none.
+func failuresError(failures []pkgimporters.Result, total int) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	for _, r := range failures {
+		fmt.Fprintf(&b, "\n    %s: %s", r.Path, r.Error)
+	}
+	return fmt.Errorf("%d of %d packages could not be fetched:%s", len(failures), total, b.String())
+}
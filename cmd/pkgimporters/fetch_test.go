@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestFetchCounts(t *testing.T) {
+	client := &pkgimporters.Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			switch pkgPath {
+			case "example.com/broken":
+				return 0, errors.New("connection refused")
+			case "example.com/markup":
+				return 0, pkgimporters.ErrParse
+			}
+			return mapSource{"fmt": 7, "io": 5}.Count(ctx, pkgPath)
+		}),
+		RequestsPerSecond: 100,
+		// Suggestions for the unknown package must not search pkg.go.dev.
+		HTTPClient: &http.Client{Transport: pkgimporters.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("no network")
+		})},
+	}
+	pkgPaths := []string{"io", "example.com/unknown", "example.com/markup", "example.com/broken", "fmt"}
+	want := []pkgimporters.Result{
+		{Path: "io", Count: 5, Status: pkgimporters.StatusOK},
+		{Path: "example.com/unknown", Status: pkgimporters.StatusNotFound, Error: "package not found"},
+		{Path: "example.com/markup", Status: pkgimporters.StatusParseError, Error: "importer count not found on page"},
+		{Path: "example.com/broken", Status: pkgimporters.StatusFailed, Error: "connection refused"},
+		{Path: "fmt", Count: 7, Status: pkgimporters.StatusOK},
+	}
+
+	t.Run("keep going", func(t *testing.T) {
+		var recorded []string
+		got, failures, err := fetchCounts(t.Context(), client, pkgPaths, fetchOptions{}, func(r pkgimporters.Result) error {
+			recorded = append(recorded, r.Path)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		slices.Sort(recorded)
+		if want := []string{"fmt", "io"}; !slices.Equal(recorded, want) {
+			t.Errorf("expected recorded %v, got %v", want, recorded)
+		}
+
+		wantErr := "1 of 5 packages could not be fetched:\n    example.com/broken: connection refused"
+		if err := failuresError(failures, len(got)); err == nil || err.Error() != wantErr {
+			t.Errorf("expected error %q, got %v", wantErr, err)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		got, failures, err := fetchCounts(t.Context(), client, pkgPaths, fetchOptions{strict: true}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		if !reflect.DeepEqual(failures, want[1:4]) {
+			t.Errorf("expected failures %v, got %v", want[1:4], failures)
+		}
+	})
+
+	t.Run("emit", func(t *testing.T) {
+		var emitted []pkgimporters.Result
+		opts := fetchOptions{emit: func(r pkgimporters.Result) error {
+			emitted = append(emitted, r)
+			return nil
+		}}
+		if _, _, err := fetchCounts(t.Context(), client, pkgPaths, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+		slices.SortFunc(emitted, func(a, b pkgimporters.Result) int {
+			return slices.Index(pkgPaths, a.Path) - slices.Index(pkgPaths, b.Path)
+		})
+		if !reflect.DeepEqual(emitted, want) {
+			t.Errorf("expected emitted %v, got %v", want, emitted)
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		if _, _, err := fetchCounts(t.Context(), client, pkgPaths, fetchOptions{failFast: true}, nil); err == nil || errors.Is(err, pkgimporters.ErrNotFound) {
+			t.Errorf("expected the connection error, got %v", err)
+		}
+		if _, _, err := fetchCounts(t.Context(), client, pkgPaths, fetchOptions{failFast: true, strict: true}, nil); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+type sourceFunc func(ctx context.Context, pkgPath string) (int, error)
+
+func (f sourceFunc) Count(ctx context.Context, pkgPath string) (int, error) {
+	return f(ctx, pkgPath)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"golang.org/x/mod/modfile"
+)
+
+const githubAPIURL = "https://api.github.com"
+
+// errNotFound is returned when the requested GitHub resource does not exist.
+var errNotFound = errors.New("not found")
+
+type githubRepo struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Language      string `json:"language"`
+	DefaultBranch string `json:"default_branch"`
+	Fork          bool   `json:"fork"`
+	Archived      bool   `json:"archived"`
+}
+
+// loadGitHubOrgModulePaths returns the module paths of the Go repositories owned by the GitHub organization.
+// The module path is read from the go.mod file at the root of the default branch;
+// repositories without go.mod are reported as "github.com/<org>/<repo>".
+// Forks and archived repositories are skipped.
+// If token is non-empty, it is used to authenticate requests to the GitHub API.
+func loadGitHubOrgModulePaths(ctx context.Context, client *http.Client, org, token string) ([]string, error) {
+	repos, err := listGitHubOrgRepos(ctx, client, org, token)
+	if err != nil {
+		return nil, fmt.Errorf("list %s repositories: %w", org, err)
+	}
+
+	var paths []string
+	for _, repo := range repos {
+		if repo.Language != "Go" || repo.Fork || repo.Archived {
+			continue
+		}
+
+		modPath, err := fetchGitHubModulePath(ctx, client, repo, token)
+		if errors.Is(err, errNotFound) {
+			modPath = "github.com/" + repo.FullName
+		} else if err != nil {
+			return nil, fmt.Errorf("resolve module path of %s: %w", repo.FullName, err)
+		}
+		paths = append(paths, modPath)
+	}
+	return paths, nil
+}
+
+// listGitHubOrgRepos returns all repositories of the organization, following pagination.
+func listGitHubOrgRepos(ctx context.Context, client *http.Client, org, token string) ([]githubRepo, error) {
+	const perPage = 100
+
+	var repos []githubRepo
+	for page := 1; ; page++ {
+		q := url.Values{}
+		q.Set("type", "public")
+		q.Set("per_page", strconv.Itoa(perPage))
+		q.Set("page", strconv.Itoa(page))
+		body, err := fetchGitHub(ctx, client, "/orgs/"+url.PathEscape(org)+"/repos?"+q.Encode(), "application/vnd.github+json", token)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageRepos []githubRepo
+		if err := json.Unmarshal(body, &pageRepos); err != nil {
+			return nil, fmt.Errorf("decode repositories: %w", err)
+		}
+		repos = append(repos, pageRepos...)
+		if len(pageRepos) < perPage {
+			return repos, nil
+		}
+	}
+}
+
+// fetchGitHubModulePath returns the module path declared in the go.mod file of the repository.
+func fetchGitHubModulePath(ctx context.Context, client *http.Client, repo githubRepo, token string) (string, error) {
+	apiPath := "/repos/" + repo.FullName + "/contents/go.mod?ref=" + url.QueryEscape(repo.DefaultBranch)
+	body, err := fetchGitHub(ctx, client, apiPath, "application/vnd.github.raw+json", token)
+	if err != nil {
+		return "", err
+	}
+
+	modPath := modfile.ModulePath(body)
+	if modPath == "" {
+		return "", fmt.Errorf("no module directive in go.mod")
+	}
+	return modPath, nil
+}
+
+// fetchGitHub performs a GET request to the GitHub API and returns the response body.
+func fetchGitHub(ctx context.Context, client *http.Client, apiPath, accept, token string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, githubAPIURL+apiPath, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errNotFound
+	default:
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	return body, nil
+}
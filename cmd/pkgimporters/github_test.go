@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestLoadGitHubOrgModulePaths(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://api.github.com/orgs/myorg/repos?page=1&per_page=100&type=public": []byte(`[
+			{"name": "tool", "full_name": "myorg/tool", "language": "Go", "default_branch": "main"},
+			{"name": "legacy", "full_name": "myorg/legacy", "language": "Go", "default_branch": "master"},
+			{"name": "site", "full_name": "myorg/site", "language": "TypeScript", "default_branch": "main"},
+			{"name": "fork", "full_name": "myorg/fork", "language": "Go", "default_branch": "main", "fork": true},
+			{"name": "old", "full_name": "myorg/old", "language": "Go", "default_branch": "main", "archived": true}
+		]`),
+		"https://api.github.com/repos/myorg/tool/contents/go.mod?ref=main": []byte("module example.com/tool/v2\n\ngo 1.25\n"),
+	}}
+	client := &http.Client{Transport: transport}
+
+	got, err := loadGitHubOrgModulePaths(t.Context(), client, "myorg", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"example.com/tool/v2", "github.com/myorg/legacy"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// runGraph runs the graph subcommand, which prints the reverse-dependency graph of a package:
+// its importers, their importers, and so on, up to -depth.
+func runGraph(args []string, stdout, stderr io.Writer) (err error) {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	clientFlags := addClientFlags(fs)
+	depth := fs.Int("depth", 2, "maximum distance from the package whose importers are listed; 1 lists the direct importers only")
+	maxPackages := fs.Int("max-packages", pkgimporters.DefaultMaxGraphPackages, "maximum number of packages whose importers are listed")
+	rateFlags := addRateFlags(fs)
+	format := fs.String("format", "text", "output `format`: 'text' (\"importer imported\" lines), 'dot' (Graphviz), or 'graphml'")
+	output := fs.String("o", "", "write the graph to `file` instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s graph - print the transitive importers of a Go package from pkg.go.dev\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s graph [-depth N] [-max-packages N] [-rps rate] [-burst N] [-workers N]\n"+
+			"        [-base-url URL] [-proxy URL] [-user-agent header] [-timeout duration] [-format text|dot|graphml] [-o file] package\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s graph lists the importers of package, then the importers of those, and so on, up to -depth,\n"+
+			"    listing each package once, and prints one \"importer imported\" edge per line, like go mod graph.\n"+
+			"    Edges are ordered by the distance of the imported package from package.\n"+
+			"    With -format dot or graphml, the graph is printed for Graphviz or other graph visualization tools.\n"+
+			"    The graph shows the blast radius of a breaking change in package.\n"+
+			"    It is incomplete if -max-packages is reached or pkg.go.dev lists only some importers of a package;\n"+
+			"    a warning is then logged to stderr.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEXAMPLES\n"+
+			"    %[1]s graph github.com/spf13/pflag\n"+
+			"        Print the importers of github.com/spf13/pflag and their importers\n\n"+
+			"    %[1]s graph -depth 3 -max-packages 5000 -o graph.txt example.com/lib\n"+
+			"        Save a deeper graph of example.com/lib to graph.txt\n\n"+
+			"    %[1]s graph -format dot github.com/spf13/pflag | dot -Tsvg -o pflag.svg\n"+
+			"        Draw the graph of github.com/spf13/pflag with Graphviz\n", progName)
+	}
+	args, err = parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	pkgPath, err := parsePackageArg("graph", args)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *depth <= 0:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -depth value: %d (must be positive)", *depth)}
+	case *maxPackages <= 0:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -max-packages value: %d (must be positive)", *maxPackages)}
+	}
+	write, ok := graphWriters[*format]
+	if !ok {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -format value: %q (must be 'text', 'dot', or 'graphml')", *format)}
+	}
+	logger, err := newLogger(stderr, "text", false, false)
+	if err != nil {
+		return err
+	}
+	client, err := clientFlags.client(logger)
+	if err != nil {
+		return err
+	}
+	if err := rateFlags.apply(client); err != nil {
+		return err
+	}
+
+	graph, err := client.Graph(context.Background(), pkgPath, pkgimporters.GraphOptions{Depth: *depth, MaxPackages: *maxPackages})
+	if err != nil {
+		return err
+	}
+	if graph.Truncated {
+		logger.Warn("graph is incomplete", "pkg", pkgPath, "packages", len(graph.Depth))
+	}
+
+	out, closeOutput, err := createOutput(stdout, *output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = cmp.Or(err, closeOutput())
+	}()
+	if err := write(graph, out); err != nil {
+		return err
+	}
+	return graphErrors(graph)
+}
+
+// graphWriters write a graph in the formats of -format.
+var graphWriters = map[string]func(pkgimporters.Graph, io.Writer) error{
+	"text":    writeGraphText,
+	"dot":     pkgimporters.Graph.WriteDOT,
+	"graphml": pkgimporters.Graph.WriteGraphML,
+}
+
+// writeGraphText writes one "importer imported" edge per line, like go mod graph.
+func writeGraphText(graph pkgimporters.Graph, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, pkg := range graph.Packages() {
+		for _, importer := range graph.Importers[pkg] {
+			fmt.Fprintln(bw, importer, pkg)
+		}
+	}
+	return bw.Flush()
+}
+
+// graphErrors returns an error listing the packages of graph whose importers could not be listed,
+// or nil if there are none.
+func graphErrors(graph pkgimporters.Graph) error {
+	if len(graph.Errors) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	for _, pkg := range graph.Packages() {
+		if msg, ok := graph.Errors[pkg]; ok {
+			fmt.Fprintf(&b, "\n    %s: %s", pkg, msg)
+		}
+	}
+	return fmt.Errorf("the importers of %d of %d packages could not be listed:%s", len(graph.Errors), len(graph.Importers)+len(graph.Errors), b.String())
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunGraph(t *testing.T) {
+	importers := map[string][]string{
+		"/example.com/lib": {"example.com/a", "example.com/b"},
+		"/example.com/a":   {"example.com/b", "example.com/c"},
+		"/example.com/b":   {"example.com/lib"},
+		"/example.com/c":   {"example.com/d"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths, ok := importers[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<div class="ImportedBy"><strong>Known importers:</strong> %d<ul>`, len(paths))
+		for _, path := range paths {
+			fmt.Fprintf(w, `<li><a href="/%s">%[1]s</a></li>`, path)
+		}
+		fmt.Fprint(w, `</ul></div>`)
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	if err := runGraph([]string{"-base-url", srv.URL, "-rps", "100", "example.com/lib", "-depth", "2"}, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	want := "example.com/a example.com/lib\n" +
+		"example.com/b example.com/lib\n" +
+		"example.com/b example.com/a\n" +
+		"example.com/c example.com/a\n" +
+		"example.com/lib example.com/b\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	stdout.Reset()
+	if err := runGraph([]string{"-base-url", srv.URL, "-rps", "100", "-depth", "1", "-format", "dot", "example.com/lib"}, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if got := stdout.String(); !strings.HasPrefix(got, "digraph importers {\n") || !strings.Contains(got, `"example.com/a" -> "example.com/lib";`) {
+		t.Errorf("expected a DOT graph, got:\n%s", got)
+	}
+
+	var stderr bytes.Buffer
+	stdout.Reset()
+	err := runGraph([]string{"-base-url", srv.URL, "-rps", "100", "-depth", "3", "-max-packages", "2", "example.com/lib"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), "graph is incomplete") {
+		t.Errorf("expected a warning about the incomplete graph, got:\n%s", stderr.String())
+	}
+
+	importers["/example.com/c"] = []string{"example.com/gone"}
+	err = runGraph([]string{"-base-url", srv.URL, "-rps", "100", "-depth", "4", "example.com/lib"}, io.Discard, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "example.com/gone: package not found") {
+		t.Errorf("expected an error listing example.com/gone, got %v", err)
+	}
+}
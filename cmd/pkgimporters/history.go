@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// sparkBars are the bars of a chart, from the lowest to the highest count.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// historyRow holds the importer counts of packages in a run of the history.
+// Packages missing from the run, or whose count could not be fetched, have no entry in Counts.
+type historyRow struct {
+	Time   time.Time      `json:"time"`
+	Counts map[string]int `json:"counts"`
+}
+
+// versionedHistoryRow is the JSON object of a run in the JSON format.
+type versionedHistoryRow struct {
+	SchemaVersion int `json:"schema_version"`
+	historyRow
+}
+
+// runCompareHistory runs the compare subcommand, which prints the importer counts of packages over time
+// from a history written by runs with -append.
+func runCompareHistory(args []string, stdout, stderr io.Writer) error {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	historyFile := fs.String("history", "", "history `file` written with -o file -append, as CSV or JSON Lines")
+	since := fs.String("since", "", "print only the runs at or after `time`: RFC 3339, YYYY-MM-DD, or a duration like 720h meaning 720 hours ago")
+	until := fs.String("until", "", "print only the runs at or before `time`, in the format of -since")
+	format := fs.String("format", "text", "output `format`: text, json, or csv")
+	chart := fs.Bool("chart", false, "print a chart of the counts of each package instead of a table")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s compare - compare the importer counts of packages over time\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s compare -history file [-since time] [-until time] [-format text|json|csv] [-chart] package ...\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s compare reads the history written by runs of %[1]s with -o file -append\n"+
+			"    and prints the counts of the packages in each run side by side, one row per run.\n"+
+			"    With -chart, it prints a chart of the counts of each package over the runs instead,\n"+
+			"    with the first and last counts and the change between them.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEXAMPLES\n"+
+			"    %[1]s compare -history std.csv -since 2024-01-01 fmt encoding/json\n"+
+			"        Print the counts of fmt and encoding/json in each run since 2024\n\n"+
+			"    %[1]s compare -history std.csv -since 2160h -chart fmt encoding/json log/slog\n"+
+			"        Chart the counts of three packages over the last 90 days\n", progName)
+	}
+	pkgPaths, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *historyFile == "" {
+		return &cmdError{code: 2, msg: "compare requires -history; use compare -h for help"}
+	}
+	if len(pkgPaths) == 0 {
+		return &cmdError{code: 2, msg: "compare requires at least one package; use compare -h for help"}
+	}
+	if *format != "text" && *format != "json" && *format != "csv" {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -format value: %q (must be 'text', 'json', or 'csv')", *format)}
+	}
+	if *chart && *format != "text" {
+		return &cmdError{code: 2, msg: "-chart requires -format text"}
+	}
+	now := time.Now()
+	var sinceTime, untilTime time.Time
+	if *since != "" {
+		if sinceTime, err = parseTime(*since, now); err != nil {
+			return &cmdError{code: 2, msg: fmt.Sprintf("invalid -since value: %v", err)}
+		}
+	}
+	if *until != "" {
+		if untilTime, err = parseTime(*until, now); err != nil {
+			return &cmdError{code: 2, msg: fmt.Sprintf("invalid -until value: %v", err)}
+		}
+	}
+
+	results, err := readHistory(*historyFile)
+	if err != nil {
+		return err
+	}
+	rows := historyRows(results, pkgPaths, sinceTime, untilTime)
+	if len(rows) == 0 {
+		return fmt.Errorf("%s has no runs of %s in the time range", *historyFile, strings.Join(pkgPaths, ", "))
+	}
+	switch {
+	case *chart:
+		return writeHistoryChart(stdout, pkgPaths, rows)
+	case *format == "json":
+		versioned := make([]versionedHistoryRow, 0, len(rows))
+		for _, row := range rows {
+			versioned = append(versioned, versionedHistoryRow{SchemaVersion: pkgimporters.SchemaVersion, historyRow: row})
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(versioned)
+	case *format == "csv":
+		return writeHistoryCSV(stdout, pkgPaths, rows)
+	default:
+		return writeHistoryText(stdout, pkgPaths, rows)
+	}
+}
+
+// readHistory reads the results of file, written with -append as CSV or JSON Lines.
+// Results without the time of their run are an error, as they cannot be placed in the history.
+func readHistory(file string) ([]pkgimporters.Result, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	results, err := readResults(data)
+	if err != nil {
+		return nil, fmt.Errorf("read history %s: %w", file, err)
+	}
+	for _, r := range results {
+		if r.Time.IsZero() {
+			return nil, fmt.Errorf("read history %s: %s has no time of its run; write the history with -append", file, r.Path)
+		}
+	}
+	return results, nil
+}
+
+// readResults reads results written by the main command in the JSON format, as JSON Lines, or in the CSV format.
+func readResults(data []byte) ([]pkgimporters.Result, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		var results []pkgimporters.Result
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, err
+		}
+		return results, nil
+	case bytes.HasPrefix(data, []byte("{")):
+		var results []pkgimporters.Result
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var r pkgimporters.Result
+			if err := dec.Decode(&r); errors.Is(err, io.EOF) {
+				return results, nil
+			} else if err != nil {
+				return nil, err
+			}
+			results = append(results, r)
+		}
+	}
+	return readResultsCSV(bytes.NewReader(data))
+}
+
+// readResultsCSV reads the path, count, and status columns of results in the CSV format, and the time column if any.
+func readResultsCSV(r io.Reader) ([]pkgimporters.Result, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range []string{"path", "count", "status"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("no %s column", name)
+		}
+	}
+
+	var results []pkgimporters.Result
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return results, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		r := pkgimporters.Result{Path: record[columns["path"]], Status: pkgimporters.Status(record[columns["status"]])}
+		if r.Count, err = strconv.Atoi(record[columns["count"]]); err != nil {
+			return nil, fmt.Errorf("%s: invalid count: %w", r.Path, err)
+		}
+		if i, ok := columns["time"]; ok && record[i] != "" {
+			if r.Time, err = time.Parse(time.RFC3339, record[i]); err != nil {
+				return nil, fmt.Errorf("%s: invalid time: %w", r.Path, err)
+			}
+		}
+		results = append(results, r)
+	}
+}
+
+// historyRows returns the counts of pkgPaths in the runs of results between since and until, if not zero,
+// ordered by time. Runs without a fetched count of any of the packages are left out.
+func historyRows(results []pkgimporters.Result, pkgPaths []string, since, until time.Time) []historyRow {
+	byTime := make(map[time.Time]map[string]int)
+	for _, r := range results {
+		if r.Status != pkgimporters.StatusOK || !slices.Contains(pkgPaths, r.Path) ||
+			!since.IsZero() && r.Time.Before(since) || !until.IsZero() && r.Time.After(until) {
+			continue
+		}
+		// Runs are identified by their time, whatever its time zone.
+		t := r.Time.UTC()
+		if byTime[t] == nil {
+			byTime[t] = make(map[string]int)
+		}
+		byTime[t][r.Path] = r.Count
+	}
+	rows := make([]historyRow, 0, len(byTime))
+	for t, counts := range byTime {
+		rows = append(rows, historyRow{Time: t, Counts: counts})
+	}
+	slices.SortFunc(rows, func(a, b historyRow) int {
+		return a.Time.Compare(b.Time)
+	})
+	return rows
+}
+
+// writeHistoryText writes rows as a table with a time column and a right-aligned column per package,
+// with "-" for missing counts.
+func writeHistoryText(w io.Writer, pkgPaths []string, rows []historyRow) error {
+	table := [][]string{append([]string{"time"}, pkgPaths...)}
+	for _, row := range rows {
+		cells := []string{row.Time.Format("2006-01-02 15:04")}
+		for _, path := range pkgPaths {
+			count, ok := row.Counts[path]
+			if !ok {
+				cells = append(cells, "-")
+				continue
+			}
+			cells = append(cells, pkgimporters.FormatCount(count))
+		}
+		table = append(table, cells)
+	}
+	widths := make([]int, len(table[0]))
+	for _, cells := range table {
+		for i, cell := range cells {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	var b strings.Builder
+	for _, cells := range table {
+		fmt.Fprintf(&b, "%-*s", widths[0], cells[0])
+		for i, cell := range cells[1:] {
+			fmt.Fprintf(&b, "  %*s", widths[i+1], cell)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeHistoryCSV writes rows as CSV with a time column and a column per package, empty for missing counts.
+func writeHistoryCSV(w io.Writer, pkgPaths []string, rows []historyRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"schema_version", "time"}, pkgPaths...)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{strconv.Itoa(pkgimporters.SchemaVersion), row.Time.Format(time.RFC3339)}
+		for _, path := range pkgPaths {
+			count, ok := row.Counts[path]
+			if !ok {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, strconv.Itoa(count))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeHistoryChart writes a line per package with a bar per run, scaled between its lowest and highest count,
+// followed by its first and last counts and the change between them. Missing counts are blank.
+func writeHistoryChart(w io.Writer, pkgPaths []string, rows []historyRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, path := range pkgPaths {
+		var counts []int
+		for _, row := range rows {
+			if count, ok := row.Counts[path]; ok {
+				counts = append(counts, count)
+			}
+		}
+		if len(counts) == 0 {
+			fmt.Fprintf(tw, "%s\t%s\tno counts\n", path, strings.Repeat(" ", len(rows)))
+			continue
+		}
+		lowest, highest := slices.Min(counts), slices.Max(counts)
+		var bars strings.Builder
+		for _, row := range rows {
+			count, ok := row.Counts[path]
+			switch {
+			case !ok:
+				bars.WriteRune(' ')
+			case highest == lowest:
+				bars.WriteRune(sparkBars[len(sparkBars)/2])
+			default:
+				bars.WriteRune(sparkBars[(count-lowest)*(len(sparkBars)-1)/(highest-lowest)])
+			}
+		}
+		first, last := counts[0], counts[len(counts)-1]
+		fmt.Fprintf(tw, "%s\t%s\t%s → %s (%s)\n", path, bars.String(),
+			pkgimporters.FormatCount(first), pkgimporters.FormatCount(last), formatChange(first, last))
+	}
+	return tw.Flush()
+}
+
+// formatChange returns the change from first to last as a signed percentage, e.g., "+7.1%".
+func formatChange(first, last int) string {
+	switch {
+	case first == 0 && last == 0:
+		return "+0.0%"
+	case first == 0:
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", float64(last-first)/float64(first)*100)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// writeHistory appends a run per element of counts, a day apart from 2024-01-01, to a history file in format.
+func writeHistory(t *testing.T, format string, counts []map[string]int) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "history."+format)
+	for i, run := range counts {
+		var results []pkgimporters.Result
+		for path, count := range run {
+			results = append(results, pkgimporters.Result{Path: path, Count: count, Status: pkgimporters.StatusOK})
+		}
+		results = append(results, pkgimporters.Result{Path: "example.com/unknown", Status: pkgimporters.StatusNotFound, Error: "package not found"})
+		if err := appendResults(file, format, time.Date(2024, 1, 1+i, 3, 0, 0, 0, time.UTC), results); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return file
+}
+
+func TestRunCompareHistory(t *testing.T) {
+	counts := []map[string]int{
+		{"fmt": 5000000, "encoding/json": 1000000},
+		{"fmt": 5200000},
+		{"fmt": 5400000, "encoding/json": 1100000},
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "text",
+			args: []string{"fmt", "encoding/json"},
+			want: "time                    fmt  encoding/json\n" +
+				"2024-01-01 03:00  5,000,000      1,000,000\n" +
+				"2024-01-02 03:00  5,200,000              -\n" +
+				"2024-01-03 03:00  5,400,000      1,100,000\n",
+		},
+		{
+			name: "since",
+			args: []string{"-since", "2024-01-02", "-format", "csv", "fmt", "encoding/json"},
+			want: "schema_version,time,fmt,encoding/json\n" +
+				"1,2024-01-02T03:00:00Z,5200000,\n" +
+				"1,2024-01-03T03:00:00Z,5400000,1100000\n",
+		},
+		{
+			name: "chart",
+			args: []string{"-chart", "fmt", "encoding/json", "log/slog"},
+			want: "fmt            ▁▄█  5,000,000 → 5,400,000 (+8.0%)\n" +
+				"encoding/json  ▁ █  1,000,000 → 1,100,000 (+10.0%)\n" +
+				"log/slog            no counts\n",
+		},
+	}
+	for _, format := range []string{"csv", "json"} {
+		file := writeHistory(t, format, counts)
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				var stdout bytes.Buffer
+				if err := runCompareHistory(append([]string{"-history", file}, tt.args...), &stdout, os.Stderr); err != nil {
+					t.Fatal(err)
+				}
+				if got := stdout.String(); got != tt.want {
+					t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestRunCompareHistoryErrors(t *testing.T) {
+	file := writeHistory(t, "csv", []map[string]int{{"fmt": 5000000}})
+	plain := filepath.Join(t.TempDir(), "plain.csv")
+	var buf bytes.Buffer
+	if err := (pkgimporters.CSVRenderer{}).Render(&buf, []pkgimporters.Result{{Path: "fmt", Count: 1, Status: pkgimporters.StatusOK}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(plain, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"fmt"}, want: "requires -history"},
+		{args: []string{"-history", file}, want: "requires at least one package"},
+		{args: []string{"-history", file, "-format", "csv", "-chart", "fmt"}, want: "-chart requires -format text"},
+		{args: []string{"-history", file, "-since", "yesterday", "fmt"}, want: "invalid -since value"},
+		{args: []string{"-history", file, "-since", "2025-01-01", "fmt"}, want: "no runs of fmt"},
+		{args: []string{"-history", plain, "fmt"}, want: "has no time of its run"},
+	}
+	for _, tt := range tests {
+		err := runCompareHistory(tt.args, &bytes.Buffer{}, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: expected an error containing %q, got %v", tt.args, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const moduleIndexURL = "https://index.golang.org"
+
+// indexPageSize is the maximum number of entries served by the module index per request.
+const indexPageSize = 2000
+
+type indexEntry struct {
+	Path      string
+	Version   string
+	Timestamp time.Time
+}
+
+// loadIndexModulePaths returns the paths of up to limit distinct modules published
+// to the module index between since and until, in publication order.
+// A zero until means no upper bound.
+func loadIndexModulePaths(ctx context.Context, client *http.Client, since, until time.Time, limit int) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	for {
+		entries, err := fetchIndexPage(ctx, client, since)
+		if err != nil {
+			return nil, fmt.Errorf("fetch module index since %s: %w", since.Format(time.RFC3339), err)
+		}
+
+		for _, e := range entries {
+			if !until.IsZero() && !e.Timestamp.Before(until) {
+				return paths, nil
+			}
+			if seen[e.Path] {
+				continue
+			}
+			seen[e.Path] = true
+			paths = append(paths, e.Path)
+			if len(paths) == limit {
+				return paths, nil
+			}
+		}
+
+		if len(entries) < indexPageSize {
+			return paths, nil
+		}
+		// The index is inclusive of since, so entries sharing the last timestamp
+		// are returned again on the next page and skipped as already seen.
+		next := entries[len(entries)-1].Timestamp
+		if !next.After(since) {
+			return paths, nil
+		}
+		since = next
+	}
+}
+
+// fetchIndexPage returns a page of module index entries published at or after since.
+func fetchIndexPage(ctx context.Context, client *http.Client, since time.Time) ([]indexEntry, error) {
+	q := url.Values{}
+	q.Set("since", since.UTC().Format(time.RFC3339Nano))
+	q.Set("limit", strconv.Itoa(indexPageSize))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, moduleIndexURL+"/index?"+q.Encode(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	var entries []indexEntry
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e indexEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("decode entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// parseTime parses s as an RFC 3339 timestamp, a YYYY-MM-DD date,
+// or a duration relative to now, such as "24h" meaning 24 hours ago.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (must be RFC 3339, YYYY-MM-DD, or a duration like 24h)", s)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLoadIndexModulePaths(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://index.golang.org/index?limit=2000&since=2026-10-01T00%3A00%3A00Z": []byte(
+			`{"Path":"example.com/a","Version":"v1.0.0","Timestamp":"2026-10-01T00:00:01Z"}
+{"Path":"example.com/b","Version":"v0.1.0","Timestamp":"2026-10-01T00:00:02Z"}
+{"Path":"example.com/a","Version":"v1.0.1","Timestamp":"2026-10-01T00:00:03Z"}
+{"Path":"example.com/c","Version":"v0.0.1","Timestamp":"2026-10-01T00:00:04Z"}
+`),
+	}}
+	client := &http.Client{Transport: transport}
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		until time.Time
+		limit int
+		want  []string
+	}{
+		{
+			name:  "deduplicated modules",
+			limit: 10,
+			want:  []string{"example.com/a", "example.com/b", "example.com/c"},
+		},
+		{
+			name:  "limit",
+			limit: 2,
+			want:  []string{"example.com/a", "example.com/b"},
+		},
+		{
+			name:  "until",
+			until: time.Date(2026, 10, 1, 0, 0, 4, 0, time.UTC),
+			limit: 10,
+			want:  []string{"example.com/a", "example.com/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadIndexModulePaths(t.Context(), client, since, tt.until, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{in: "2026-10-01T08:30:00Z", want: time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC)},
+		{in: "2026-10-01", want: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "36h", want: time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTime(tt.in, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q): expected %v, got %v", tt.in, tt.want, got)
+		}
+	}
+
+	if _, err := parseTime("yesterday", now); err == nil {
+		t.Error("expected error for invalid time")
+	}
+}
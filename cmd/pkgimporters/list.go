@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// runList runs the list subcommand, which prints the importers of a package, one per line,
+// as listed on its pkg.go.dev "importedby" tab.
+// It warns on stderr if the list is incomplete.
+func runList(args []string, stdout, stderr io.Writer) (err error) {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	clientFlags := addClientFlags(fs)
+	output := fs.String("o", "", "write the importers to `file` instead of stdout")
+	importerMatch := fs.String("importer-match", "", "comma-separated list of package `patterns`, e.g. 'github.com/myorg/...'; print only the importers matching any of them")
+	strict := fs.Bool("strict", false, "fail instead of printing an incomplete list of importers")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s list - list the known importers of a Go package from pkg.go.dev\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s list [-base-url URL] [-proxy URL] [-user-agent header] [-timeout duration] [-importer-match pattern,...] [-o file] [-strict] package\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s list prints the packages importing package, one per line, as listed on its importedby tab.\n"+
+			"    pkg.go.dev lists at most 20,000 importers, so the list of a popular package is incomplete;\n"+
+			"    a warning is then logged to stderr, or %[1]s list fails with -strict.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEXAMPLES\n"+
+			"    %[1]s list golang.org/x/tools/go/analysis\n"+
+			"        Print the importers of golang.org/x/tools/go/analysis\n\n"+
+			"    %[1]s list -o importers.txt github.com/spf13/cobra\n"+
+			"        Save the importers of github.com/spf13/cobra to importers.txt\n\n"+
+			"    %[1]s list -importer-match github.com/myorg/... github.com/spf13/cobra\n"+
+			"        Print which packages of the myorg GitHub organization import github.com/spf13/cobra\n", progName)
+	}
+	args, err = parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	pkgPath, err := parsePackageArg("list", args)
+	if err != nil {
+		return err
+	}
+	logger, err := newLogger(stderr, "text", false, false)
+	if err != nil {
+		return err
+	}
+	client, err := clientFlags.client(logger)
+	if err != nil {
+		return err
+	}
+
+	importers, err := client.Importers(context.Background(), pkgPath)
+	if err != nil {
+		return err
+	}
+	if importers.Truncated {
+		if *strict {
+			return fmt.Errorf("%s: only %s of %s importers are listed", pkgPath,
+				pkgimporters.FormatCount(len(importers.Paths)), pkgimporters.FormatCount(importers.Count))
+		}
+		logger.Warn("importers list is incomplete", "pkg", pkgPath, "listed", len(importers.Paths), "count", importers.Count)
+	}
+
+	paths := importers.Paths
+	if *importerMatch != "" {
+		paths = filterPackages(paths, strings.Split(*importerMatch, ","))
+	}
+
+	out, closeOutput, err := createOutput(stdout, *output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = cmp.Or(err, closeOutput())
+	}()
+	w := bufio.NewWriter(out)
+	for _, path := range paths {
+		if _, err := fmt.Fprintln(w, path); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
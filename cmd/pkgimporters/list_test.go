@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunList(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counts := map[string]int{"/example.com/lib": 2, "/example.com/popular": 30000}
+		count, ok := counts[r.URL.Path]
+		if !ok || r.URL.Query().Get("tab") != "importedby" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<div class="ImportedBy"><strong>Known importers:</strong> %d
+			<ul><li><a href="/example.com/a">example.com/a</a></li><li><a href="/example.com/b">example.com/b</a></li></ul></div>`, count)
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	if err := runList([]string{"-base-url", srv.URL, "example.com/lib"}, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	want := "example.com/a\nexample.com/b\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	out := filepath.Join(t.TempDir(), "importers.txt")
+	stdout.Reset()
+	if err := runList([]string{"-base-url", srv.URL, "-o", out, "https://pkg.go.dev/example.com/lib?tab=importedby"}, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected nothing on stdout with -o, got %q", stdout.String())
+	}
+	if got, err := os.ReadFile(out); err != nil || string(got) != want {
+		t.Errorf("expected %s to contain:\n%s\ngot (err %v):\n%s", out, want, err, got)
+	}
+
+	if err := runList([]string{"-base-url", srv.URL, "example.com/missing"}, &stdout, io.Discard); err == nil {
+		t.Error("expected an error for an unknown package")
+	}
+	stdout.Reset()
+	if err := runList([]string{"-base-url", srv.URL, "-importer-match", "example.com/b/...", "example.com/lib"}, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if got := stdout.String(); got != "example.com/b\n" {
+		t.Errorf("expected only the matching importer with -importer-match, got:\n%s", got)
+	}
+
+	var stderr bytes.Buffer
+	stdout.Reset()
+	if err := runList([]string{"-base-url", srv.URL, "example.com/popular"}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != want || !strings.Contains(stderr.String(), "importers list is incomplete") {
+		t.Errorf("expected the listed importers and a warning, got stdout:\n%s\nstderr:\n%s", stdout.String(), stderr.String())
+	}
+	if err := runList([]string{"-base-url", srv.URL, "-strict", "example.com/popular"}, &stdout, io.Discard); err == nil || !strings.Contains(err.Error(), "only 2 of 30,000 importers") {
+		t.Errorf("expected an error for an incomplete list with -strict, got %v", err)
+	}
+
+	var cmdErr *cmdError
+	if err := runList([]string{"example.com/a", "example.com/b"}, &stdout, io.Discard); !errors.As(err, &cmdErr) || cmdErr.code != 2 {
+		t.Errorf("expected a usage error for two packages, got %v", err)
+	}
+}
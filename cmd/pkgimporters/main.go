@@ -0,0 +1,1318 @@
+// pkgimporters fetches the number of known importers for Go packages from pkg.go.dev.
+// It supports multiple ways to specify packages:
+// via positional arguments, comma-separated list with -pkgs, all stdlib with -pkgs std,
+// or all Go distribution commands with -pkgs cmd.
+//
+// Usage:
+//
+//	pkgimporters fmt bufio net/http          # specific packages
+//	pkgimporters -pkgs fmt,bufio,net/http    # comma-separated packages
+//	pkgimporters std                         # all standard library packages
+//	pkgimporters cmd                         # all Go distribution command packages
+//	pkgimporters -pkgs std -sort count       # sort by importer count descending
+//	pkgimporters -workers auto -pkgs std     # with concurrency sized to the rate limit
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/go/packages"
+)
+
+type cmdError struct {
+	code int
+	msg  string
+}
+
+func (e *cmdError) Error() string {
+	return e.msg
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		var e *cmdError
+		if errors.As(err, &e) {
+			os.Exit(e.code)
+		}
+		os.Exit(1)
+	}
+}
+
+func run() (err error) {
+	if len(os.Args) > 1 {
+		if subcommand, ok := subcommands[os.Args[1]]; ok {
+			if err := subcommand(os.Args[2:], os.Stdout, os.Stderr); !errors.Is(err, flag.ErrHelp) {
+				return err
+			}
+			return nil
+		}
+	}
+
+	sourceName := flag.String("source", "pkggodev", "source of importer counts: "+strings.Join(sourceNames, ", "))
+	proxy := flag.String("proxy", "", "`URL` of an HTTP, HTTPS, or SOCKS5 proxy for all requests (default $HTTPS_PROXY or $HTTP_PROXY; $NO_PROXY is honored)")
+	caCert := flag.String("cacert", "", "PEM `file` with extra CA certificates to trust, e.g., for a TLS-intercepting proxy")
+	insecureSkipVerify := flag.Bool("insecure-skip-verify", false, "disable TLS certificate verification (insecure)")
+	maxIdleConnsPerHost := flag.Int("max-idle-conns-per-host", pkgimporters.DefaultMaxIdleConnsPerHost, "number of idle connections kept per host for reuse")
+	idleConnTimeout := flag.Duration("idle-conn-timeout", pkgimporters.DefaultIdleConnTimeout, "how long an idle connection is kept before it is closed")
+	keepAlive := flag.Duration("keep-alive", pkgimporters.DefaultKeepAlive, "interval between TCP keep-alive probes; negative disables them")
+	disableHTTP2 := flag.Bool("disable-http2", false, "restrict connections to HTTP/1.1")
+	userAgent := flag.String("user-agent", defaultUserAgent(), "User-Agent `header` sent with every request")
+	baseURL := flag.String("base-url", pkgimporters.DefaultBaseURL, "`URL` of the pkgsite instance to scrape and search, e.g., a private deployment")
+	sourceFallback := flag.String("source-fallback", "", "comma-separated list of sources to try in order when -source fails or does not know a package")
+	sourcesList := flag.String("sources", "", "comma-separated list of sources to compare side by side, e.g. 'pkggodev,depsdev'")
+	verify := flag.Bool("verify", false, "cross-check pkg.go.dev counts against deps.dev and fail if they diverge beyond -verify-tolerance")
+	modules := flag.Bool("modules", false, "also report the number of unique modules among the importers, reading the whole importedby tab")
+	withExamples := flag.Int("with-examples", 0, "include the first `N` importer paths of each package in JSON and CSV output")
+	withLicense := flag.Bool("with-license", false, "also report the license of each package from its pkg.go.dev page, with a second request per package")
+	withVersion := flag.Bool("with-version", false, "also report the latest version of each package and when it was published, from its pkg.go.dev page")
+	withImports := flag.Bool("with-imports", false, "also report the number of packages each package imports, from its pkg.go.dev page")
+	withRedistributable := flag.Bool("with-redistributable", false, "also report whether pkg.go.dev considers each package redistributable, from its pkg.go.dev page")
+	withStars := flag.Bool("with-stars", false, "also report the stars and forks of the GitHub repository of each package; set GITHUB_TOKEN to authenticate")
+	withScorecard := flag.Bool("with-scorecard", false, "also report the OpenSSF Scorecard score of the repository of each package")
+	withAge := flag.Bool("with-age", false, "also report the days since the latest version of the module of each package was published, from the module proxy")
+	withVulns := flag.Bool("with-vulns", false, "also report the known vulnerabilities of each package from osv.dev")
+	verifyTolerance := flag.Float64("verify-tolerance", 0.5, "maximum `fraction` by which -verify counts may differ, relative to the larger count")
+	sourcegraphURL := flag.String("sourcegraph-url", cmp.Or(os.Getenv("SRC_ENDPOINT"), pkgimporters.DefaultSourcegraphURL), "Sourcegraph instance `URL` for -source sourcegraph (default $SRC_ENDPOINT)")
+	sourcegraphToken := flag.String("sourcegraph-token", os.Getenv("SRC_ACCESS_TOKEN"), "Sourcegraph access `token` for -source sourcegraph (default $SRC_ACCESS_TOKEN)")
+	librariesIOKey := flag.String("librariesio-key", os.Getenv("LIBRARIES_IO_API_KEY"), "libraries.io API `key` for -source librariesio (default $LIBRARIES_IO_API_KEY)")
+	rps := flag.Float64("rps", pkgimporters.DefaultRequestsPerSecond, "maximum sustained `rate` of requests per second, per source")
+	burst := flag.Int("burst", pkgimporters.DefaultBurst, "maximum number of requests made at once before -rps applies")
+	retryAttempts := flag.Int("retry-attempts", pkgimporters.DefaultRetryAttempts, "maximum number of requests per package, including retries of transient failures; 1 disables retries")
+	retryDelay := flag.Duration("retry-delay", pkgimporters.DefaultRetryDelay, "delay before the first retry, doubled for each further retry")
+	retryMaxDelay := flag.Duration("retry-max-delay", pkgimporters.DefaultRetryMaxDelay, "maximum delay between retries")
+	breakerThreshold := flag.Int("breaker-threshold", pkgimporters.DefaultBreakerThreshold, "consecutive transient failures that pause all requests until a probe succeeds; 0 disables the circuit breaker")
+	breakerCooldown := flag.Duration("breaker-cooldown", pkgimporters.DefaultBreakerCooldown, "how long requests are paused before a probe when the circuit breaker opens")
+	cacheDir := flag.String("cache-dir", "", "`directory` in which to keep fetched counts between runs; empty keeps them in memory only")
+	cacheTTL := flag.Duration("cache-ttl", pkgimporters.DefaultCacheTTL, "how long fetched counts are reused before fetching them again")
+	offline := flag.Bool("offline", false, "answer exclusively from -cache-dir without making requests, marking stale counts and reporting missing ones")
+	failFast := flag.Bool("fail-fast", false, "stop at the first package that cannot be fetched instead of reporting it and continuing")
+	strict := flag.Bool("strict", false, "fail for unknown packages and pages without an importer count instead of only reporting them")
+	recordDir := flag.String("record", "", "save every upstream response in `directory`, so the run can be repeated with -replay")
+	replayDir := flag.String("replay", "", "answer requests with the responses saved in `directory` by -record, without network access")
+	checkpointFile := flag.String("checkpoint", "", "JSON `file` recording completed packages, so an interrupted run resumes where it stopped")
+	timeout := flag.Duration("timeout", pkgimporters.DefaultTimeout, "timeout of each request")
+	hedgeDelay := flag.Duration("hedge-delay", 0, "make a second request for a package if the first has not completed after `duration`, keeping the faster; 0 disables hedging")
+	deadline := flag.Duration("deadline", 0, "maximum `duration` of the whole run; 0 means no limit")
+	workersFlag := flag.String("workers", strconv.Itoa(pkgimporters.DefaultWorkers), "number of concurrent requests, or 'auto' to size it from -rps and the measured latency")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile of the run to `file`")
+	memProfile := flag.String("memprofile", "", "write a heap profile at the end of the run to `file`")
+	showStats := flag.Bool("stats", false, "print the wall time, request and retry counts, request rate, and slowest packages to stderr; implied by -v")
+	noProgress := flag.Bool("no-progress", false, "do not show a progress bar on stderr while fetching")
+	tui := flag.Bool("tui", false, "show results in an interactive table as they arrive, sortable, filterable, and openable on pkg.go.dev")
+	stream := flag.Bool("stream", false, "print each result as soon as it is fetched, unsorted; JSON is printed as JSON Lines")
+	format := flag.String("format", "text", "output `format`: "+strings.Join(pkgimporters.RendererNames(), ", "))
+	verbose := flag.Bool("v", false, "verbose logging: log each package result")
+	veryVerbose := flag.Bool("vv", false, "debug logging: also log fetch start, cache hits, and rate limit waits")
+	output := flag.String("o", "", "write the results to `file` instead of stdout")
+	appendOutput := flag.Bool("append", false, "append the results with the time of the run to the -o file instead of overwriting it, as CSV or JSON Lines")
+	uploadURL := flag.String("upload", "", "upload the results after the run to `URL` s3://bucket/key or gs://bucket/key, where a key ending in / is a prefix for a timestamped name")
+	sheet := flag.String("sheet", "", "replace the content of a Google Sheet tab with the results after the run, given as `spreadsheet-id!tab`")
+	quiet := flag.Bool("q", false, "print only the result rows, or only the count of a single package in the text format, without progress or log output")
+	logFormat := flag.String("log-format", "text", "log `format`: 'text' or 'json'")
+	sortBy := flag.String("sort", "name", "sort results by 'name' (default) or 'count' (descending)")
+	pkgsList := flag.String("pkgs", "", "comma-separated list of packages to fetch, 'std' for all standard library packages, 'cmd' for all Go commands, or '@name' for a package set of the config file")
+	modulePath := flag.String("module", "", "module `path[@version]` whose packages to fetch, listed via the module proxy")
+	githubOrg := flag.String("github-org", "", "GitHub `organization` whose Go repositories to fetch; set GITHUB_TOKEN to authenticate")
+	searchQuery := flag.String("search", "", "pkg.go.dev search `query` whose top results to fetch")
+	indexSince := flag.String("index-since", "", "fetch root packages of modules published to index.golang.org since `time` (RFC 3339, YYYY-MM-DD, or a duration ago like 24h)")
+	indexUntil := flag.String("index-until", "", "with -index-since, only include modules published before `time`")
+	limit := flag.Int("limit", 10, "maximum number of packages to fetch with -search or -index-since")
+	vanity := flag.Bool("vanity", false, "resolve vanity import paths via go-import meta tags and also fetch the repository paths")
+	exclude := flag.String("exclude", "", "comma-separated list of package patterns to skip, e.g. 'crypto/...,testing/...'")
+	includeInternal := flag.Bool("include-internal", false, "include internal packages when loading 'std' or 'cmd'")
+	includeVendor := flag.Bool("include-vendor", false, "include vendor packages when loading 'std' or 'cmd'")
+	showSchema := flag.Bool("schema", false, "print the JSON Schema of the JSON format, and exit")
+	showVersion := flag.Bool("version", false, "print the module version, VCS revision, and Go version, and exit")
+	configFile := flag.String("config", "", "YAML `file` with flag defaults (default $PKGIMPORTERS_CONFIG or $XDG_CONFIG_HOME/pkgimporters/config.yaml)")
+	progName := filepath.Base(os.Args[0])
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "NAME\n"+
+			"    %[1]s - fetch known importers for Go packages from pkg.go.dev\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s [-pkgs pkg1,pkg2,...|std|cmd|@set] [-module path[@version]]\n"+
+			"        [-github-org org] [-search query [-limit N]]\n"+
+			"        [-index-since time [-index-until time] [-limit N]]\n"+
+			"        [-base-url URL] [-proxy URL] [-cacert file] [-insecure-skip-verify] [-user-agent header]\n"+
+			"        [-max-idle-conns-per-host N] [-idle-conn-timeout duration] [-keep-alive duration] [-disable-http2]\n"+
+			"        [-source name [-source-fallback name,...]|-sources name,...] [-verify]\n"+
+			"        [-modules] [-with-examples N] [-with-license] [-with-version] [-with-imports] [-with-redistributable]\n"+
+			"        [-with-stars] [-with-scorecard] [-with-age] [-with-vulns]\n"+
+			"        [-rps rate] [-burst N] [-retry-attempts N] [-retry-delay duration] [-retry-max-delay duration]\n"+
+			"        [-breaker-threshold N] [-breaker-cooldown duration]\n"+
+			"        [-cache-dir dir] [-cache-ttl duration] [-offline] [-record dir|-replay dir] [-checkpoint file]\n"+
+			"        [-fail-fast] [-strict]\n"+
+			"        [-timeout duration] [-hedge-delay duration] [-deadline duration] [-workers N|auto] [-sort name|count|-stream|-tui] [-format text|json|csv]\n"+
+			"        [-o file [-append]] [-upload s3://bucket/key|gs://bucket/key] [-sheet spreadsheet-id!tab]\n"+
+			"        [-stats] [-cpuprofile file] [-memprofile file] [-no-progress] [-q|-v|-vv] [-log-format text|json] [-vanity] [-exclude pattern,...]\n"+
+			"        [-include-internal] [-include-vendor] [-config file] [-schema] [-version] [package ...]\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s fetches the number of known importers for Go packages from https://pkg.go.dev.\n"+
+			"    Packages can be specified via positional arguments,\n"+
+			"    comma-separated list with -pkgs, all stdlib with -pkgs std,\n"+
+			"    or all Go distribution commands with -pkgs cmd.\n"+
+			"    With -module, every package of a module is fetched, followed by a\n"+
+			"    \"MODULE (total)\" row with the module-level total in every format.\n"+
+			"    With -github-org, the modules of an organization's Go repositories on GitHub are fetched.\n"+
+			"    With -search, the top results of a pkg.go.dev search are fetched.\n"+
+			"    With -index-since, the root packages of recently published modules are fetched.\n"+
+			"    Flags not set on the command line are read from PKGIMPORTERS_* environment variables,\n"+
+			"    e.g., PKGIMPORTERS_WORKERS=8 for -workers 8, then from the YAML config file,\n"+
+			"    whose keys are flag names, e.g., 'workers: 8'. Its sets key defines named package sets,\n"+
+			"    e.g., 'sets: {myorg: [github.com/myorg/a, github.com/myorg/b]}', referenced as -pkgs @myorg.\n"+
+			"    JSON and CSV output, also of -sources and the subcommands, has a schema_version field and column;\n"+
+			"    within a schema version, fields and columns are only added, and -schema prints the JSON Schema\n"+
+			"    of the JSON format.\n"+
+			"    With -append, the results are appended to the -o file with the time of the run.\n"+
+			"    With -upload, the output is uploaded to S3 with the AWS_* credentials of the environment,\n"+
+			"    or to Google Cloud Storage with the access token GOOGLE_OAUTH_ACCESS_TOKEN.\n"+
+			"    With -sheet, the results replace a Google Sheet tab, authenticated as the service account\n"+
+			"    of the key file GOOGLE_APPLICATION_CREDENTIALS or with GOOGLE_OAUTH_ACCESS_TOKEN.\n"+
+			"    Run '%[1]s list -h' to list the importers of a package instead of counting them,\n"+
+			"    '%[1]s graph -h' to print its transitive importers,\n"+
+			"    '%[1]s annotate -h' to annotate go mod graph output with importer counts,\n"+
+			"    '%[1]s audit -h' to flag the dependencies of a project with few importers,\n"+
+			"    '%[1]s compare -h' to compare the counts of packages over the history written with -append,\n"+
+			"    '%[1]s movers -h' to rank packages by the change of their rank between two runs,\n"+
+			"    '%[1]s serve -h' to serve importer counts over HTTP,\n"+
+			"    '%[1]s completion -h' to generate a shell completion script,\n"+
+			"    '%[1]s doctor -h' to diagnose connectivity, sources, the parser, and the cache,\n"+
+			"    '%[1]s probe -h' to measure the request rate pkg.go.dev allows,\n"+
+			"    and '%[1]s man -h' to generate this page as a man page.\n\n"+
+			"OPTIONS\n", progName)
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nEXAMPLES\n"+
+			"    %[1]s fmt\n"+
+			"        Fetch importers for the fmt package\n\n"+
+			"    %[1]s fmt bufio net/http golang.org/x/tools/go/analysis\n"+
+			"        Fetch importers for multiple packages\n\n"+
+			"    %[1]s -pkgs fmt,bufio,net/http\n"+
+			"        Fetch importers using comma-separated packages\n\n"+
+			"    %[1]s -pkgs std\n"+
+			"        Fetch importers for all standard library packages\n\n"+
+			"    %[1]s cmd\n"+
+			"        Fetch importers for all Go distribution command packages\n\n"+
+			"    %[1]s -module github.com/spf13/cobra\n"+
+			"        Fetch importers for every package of the github.com/spf13/cobra module\n\n"+
+			"    GITHUB_TOKEN=... %[1]s -github-org myorg -sort count\n"+
+			"        Rank the modules of the myorg GitHub organization by importer count\n\n"+
+			"    %[1]s -search \"yaml parser\" -limit 20 -sort count\n"+
+			"        Compare the top 20 pkg.go.dev search results for \"yaml parser\"\n\n"+
+			"    %[1]s -index-since 24h -limit 100 -sort count\n"+
+			"        Rank up to 100 modules published in the last 24 hours by importer count\n\n"+
+			"    %[1]s -format json fmt io\n"+
+			"        Print importer counts as JSON\n\n"+
+			"    %[1]s -modules github.com/spf13/cobra github.com/urfave/cli/v2\n"+
+			"        Also print how many unique modules import each package\n\n"+
+			"    %[1]s -with-examples 3 -format json github.com/spf13/cobra\n"+
+			"        Print the importer count of github.com/spf13/cobra with three of its importers\n\n"+
+			"    %[1]s -with-license -format csv github.com/spf13/cobra gopkg.in/yaml.v3\n"+
+			"        Print importer counts alongside the licenses, as a license inventory\n\n"+
+			"    %[1]s -with-version -sort count github.com/pkg/errors github.com/sirupsen/logrus\n"+
+			"        Show whether popular packages are still actively released\n\n"+
+			"    %[1]s -with-imports -sort count -pkgs std\n"+
+			"        Find heavily used but lightweight stdlib packages\n\n"+
+			"    %[1]s -with-redistributable -with-license -format csv github.com/spf13/cobra gopkg.in/yaml.v3\n"+
+			"        Check whether dependencies may be redistributed, for compliance reviews\n\n"+
+			"    GITHUB_TOKEN=... %[1]s -with-stars -sort count github.com/spf13/cobra github.com/urfave/cli/v2\n"+
+			"        Compare importer counts with the stars and forks of the repositories\n\n"+
+			"    %[1]s -with-scorecard -with-vulns -with-license -format json github.com/spf13/cobra\n"+
+			"        Print a dependency health report combining popularity, security practices, and licensing\n\n"+
+			"    %[1]s -with-age -sort count github.com/pkg/errors github.com/sirupsen/logrus\n"+
+			"        Flag popular but stale packages by the age of their latest release\n\n"+
+			"    %[1]s -with-vulns -format csv golang.org/x/net/html gopkg.in/yaml.v3\n"+
+			"        Print importer counts alongside the IDs of known vulnerabilities\n\n"+
+			"    %[1]s -source depsdev github.com/spf13/cobra\n"+
+			"        Fetch the number of dependents of a module from deps.dev\n\n"+
+			"    %[1]s -base-url https://pkgsite.internal.corp corp.example.com/lib\n"+
+			"        Fetch importers from a private pkgsite deployment\n\n"+
+			"    %[1]s -proxy socks5://127.0.0.1:1080 fmt\n"+
+			"        Reach pkg.go.dev through a SOCKS5 proxy\n\n"+
+			"    %[1]s -cacert corp-ca.pem -pkgs std\n"+
+			"        Trust the CA of a TLS-intercepting corporate proxy\n\n"+
+			"    %[1]s -max-idle-conns-per-host 32 -disable-http2 -workers 20 -rps 20 -burst 20 -base-url https://pkgsite.internal.corp -pkgs std\n"+
+			"        Reuse HTTP/1.1 connections for 20 concurrent requests to a private pkgsite instance\n\n"+
+			"    %[1]s -user-agent \"acme-audit/1.0 (ops@acme.example)\" -pkgs std\n"+
+			"        Identify the traffic to pkg.go.dev and corporate proxies\n\n"+
+			"    %[1]s -source-fallback depsdev github.com/spf13/cobra\n"+
+			"        Fetch from pkg.go.dev, falling back to deps.dev if the request fails\n\n"+
+			"    %[1]s -source sourcegraph -sourcegraph-url https://sourcegraph.example.com net/http\n"+
+			"        Count repositories importing net/http on a private Sourcegraph instance\n\n"+
+			"    LIBRARIES_IO_API_KEY=... %[1]s -source librariesio github.com/spf13/cobra\n"+
+			"        Fetch the number of dependent repositories of a module from libraries.io\n\n"+
+			"    %[1]s -source ecosystems github.com/spf13/cobra\n"+
+			"        Fetch the number of dependent packages of a module from ecosyste.ms\n\n"+
+			"    %[1]s -sources pkggodev,depsdev,github-dependents github.com/spf13/cobra\n"+
+			"        Compare importer counts from three sources, marking disagreements with !\n\n"+
+			"    %[1]s -verify github.com/spf13/cobra github.com/urfave/cli/v2\n"+
+			"        Fail if the scraped pkg.go.dev counts diverge from deps.dev by more than 50%%\n\n"+
+			"    %[1]s -base-url https://pkgsite.internal.corp -rps 20 -burst 20 -pkgs std\n"+
+			"        Scrape a private pkgsite instance faster than the pkg.go.dev default\n\n"+
+			"    %[1]s -retry-attempts 6 -retry-max-delay 2m -pkgs std\n"+
+			"        Ride out longer network hiccups when fetching all stdlib packages\n\n"+
+			"    %[1]s -timeout 1m -deadline 30m -pkgs std\n"+
+			"        Allow slow requests, but give up on the whole run after 30 minutes\n\n"+
+			"    %[1]s -hedge-delay 3s -index-since 24h -limit 1000\n"+
+			"        Retry requests slower than 3s in parallel, so a few slow responses do not stall the run\n\n"+
+			"    %[1]s -cache-dir ~/.cache/pkgimporters -offline -pkgs std\n"+
+			"        Regenerate a stdlib report from previously fetched counts without network access\n\n"+
+			"    %[1]s -record testdata/cassettes github.com/spf13/cobra\n"+
+			"    %[1]s -replay testdata/cassettes github.com/spf13/cobra\n"+
+			"        Save the upstream responses of a run, then repeat it deterministically without network access\n\n"+
+			"    count=$(%[1]s -q fmt)\n"+
+			"        Store the importer count of fmt in a shell variable\n\n"+
+			"    %[1]s -stream -pkgs std\n"+
+			"        Print each stdlib package as soon as its count is fetched\n\n"+
+			"    %[1]s -pkgs std -format csv -o std.csv -append\n"+
+			"        Append the stdlib counts with the time of the run to std.csv, e.g., daily from cron\n\n"+
+			"    %[1]s -pkgs std -format json -upload s3://reports/pkgimporters/\n"+
+			"        Upload the stdlib counts to S3 under a name with the time of the run\n\n"+
+			"    %[1]s -sheet '1AbC...xyz!Importers' github.com/spf13/cobra gopkg.in/yaml.v3\n"+
+			"        Write the counts to the Importers tab of a Google Sheet\n\n"+
+			"    %[1]s -tui -pkgs std\n"+
+			"        Explore stdlib importer counts in an interactive table as they are fetched\n\n"+
+			"    %[1]s -checkpoint run.json -pkgs std\n"+
+			"        Fetch all stdlib packages, skipping those completed by an interrupted run\n\n"+
+			"    %[1]s -stats -workers 2 -rps 2 -pkgs std\n"+
+			"        Fetch all stdlib packages and report timing to tune -workers and -rps\n\n"+
+			"    %[1]s -cpuprofile cpu.pprof -memprofile mem.pprof -index-since 24h -limit 5000\n"+
+			"        Profile a large run; analyze the profiles with go tool pprof\n\n"+
+			"    %[1]s -vv -log-format json -pkgs std 2>fetch.log\n"+
+			"        Fetch all stdlib packages, writing JSON debug logs to fetch.log\n\n"+
+			"    %[1]s -vanity go.uber.org/zap\n"+
+			"        Fetch importers for go.uber.org/zap and its repository path github.com/uber-go/zap\n\n"+
+			"    %[1]s -workers 10 -rps 5 -burst 5 -pkgs std\n"+
+			"        Use 10 concurrent requests at up to 5 requests per second when fetching all stdlib packages\n\n"+
+			"    %[1]s -base-url https://pkgsite.internal.corp -rps 20 -burst 20 -workers auto -pkgs std\n"+
+			"        Let the number of concurrent requests follow the rate limit and latency\n\n"+
+			"    %[1]s -pkgs std -sort count\n"+
+			"        Fetch all stdlib packages and sort by importer count descending\n\n"+
+			"    %[1]s -pkgs std,golang.org/x/net/http2\n"+
+			"        Fetch all stdlib packages plus golang.org/x/net/http2\n\n"+
+			"    %[1]s -pkgs std -exclude crypto/...,testing/...\n"+
+			"        Fetch all stdlib packages except the crypto and testing subtrees\n\n"+
+			"    %[1]s -config ci.yaml -pkgs std\n"+
+			"        Fetch all stdlib packages with the workers, rate, format, and cache of a CI config file\n\n"+
+			"    %[1]s -pkgs @myorg -sort count\n"+
+			"        Fetch the packages of the myorg set defined in the config file\n\n"+
+			"    %[1]s -version\n"+
+			"        Print the version, VCS revision, and Go version of the binary\n\n"+
+			"    PKGIMPORTERS_FORMAT=json PKGIMPORTERS_CACHE_DIR=/cache %[1]s -pkgs std\n"+
+			"        Configure a containerized run through the environment instead of flags\n", progName)
+	}
+	if len(os.Args) > 1 {
+		if subcommand, ok := mainSubcommands[os.Args[1]]; ok {
+			if err := subcommand(flag.CommandLine, os.Args[2:], os.Stdout, os.Stderr); !errors.Is(err, flag.ErrHelp) {
+				return err
+			}
+			return nil
+		}
+	}
+	flag.Parse()
+	if *showVersion {
+		info, _ := debug.ReadBuildInfo()
+		return writeVersion(os.Stdout, info)
+	}
+	if *showSchema {
+		_, err := io.WriteString(os.Stdout, pkgimporters.JSONSchema())
+		return err
+	}
+	if err := applyEnv(flag.CommandLine, os.Getenv); err != nil {
+		return &cmdError{code: 2, msg: err.Error()}
+	}
+	sets, err := applyConfig(flag.CommandLine, cmp.Or(*configFile, defaultConfigFile()), *configFile != "")
+	if err != nil {
+		return &cmdError{code: 2, msg: err.Error()}
+	}
+
+	// Validate sort flag
+	if *sortBy != "name" && *sortBy != "count" {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -sort value: %q (must be 'name' or 'count')", *sortBy)}
+	}
+
+	if *appendOutput {
+		if *output == "" {
+			return &cmdError{code: 2, msg: "-append requires -o"}
+		}
+		if *format != "csv" && *format != "json" {
+			return &cmdError{code: 2, msg: fmt.Sprintf("-format %s does not support -append (must be 'csv' or 'json')", *format)}
+		}
+		if *stream || *sourcesList != "" {
+			return &cmdError{code: 2, msg: "-append cannot be used with -stream or -sources"}
+		}
+	}
+
+	if *quiet && (*verbose || *veryVerbose || *showStats || *tui) {
+		return &cmdError{code: 2, msg: "-q cannot be used with -v, -vv, -stats, or -tui"}
+	}
+
+	logger, err := newLogger(os.Stderr, *logFormat, *verbose, *veryVerbose)
+	if err != nil {
+		return &cmdError{code: 2, msg: err.Error()}
+	}
+	if *quiet {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	renderer, ok := pkgimporters.LookupRenderer(*format)
+	if !ok {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -format value: %q (must be one of %s)", *format, strings.Join(pkgimporters.RendererNames(), ", "))}
+	}
+
+	var streamRenderer pkgimporters.StreamRenderer
+	if *stream {
+		if streamRenderer, ok = renderer.(pkgimporters.StreamRenderer); !ok {
+			return &cmdError{code: 2, msg: fmt.Sprintf("-format %s does not support -stream", *format)}
+		}
+		if *sortBy != "name" || *sourcesList != "" {
+			return &cmdError{code: 2, msg: "-stream cannot be used with -sort count or -sources"}
+		}
+	}
+
+	if *tui {
+		if *stream || *sourcesList != "" {
+			return &cmdError{code: 2, msg: "-tui cannot be used with -stream or -sources"}
+		}
+		if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+			return &cmdError{code: 2, msg: "-tui requires a terminal"}
+		}
+	}
+
+	var sourceList []string
+	if *sourcesList != "" {
+		if *sourceName != "pkggodev" || *sourceFallback != "" {
+			return &cmdError{code: 2, msg: "-source and -source-fallback cannot be used with -sources"}
+		}
+		if *format != "text" && *format != "json" && *format != "csv" {
+			return &cmdError{code: 2, msg: fmt.Sprintf("invalid -format value for -sources: %q (must be 'text', 'json', or 'csv')", *format)}
+		}
+		sourceList = splitSourceNames(*sourcesList)
+	}
+
+	if *withExamples < 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -with-examples value: %d (must not be negative)", *withExamples)}
+	}
+	// The pkg.go.dev-only flags read pages that only the pkg.go.dev source fetches.
+	pkgGoDevOnly := []struct {
+		name string
+		set  bool
+	}{
+		{"-modules", *modules},
+		{"-with-examples", *withExamples > 0},
+		{"-with-license", *withLicense},
+		{"-with-version", *withVersion},
+		{"-with-imports", *withImports},
+		{"-with-redistributable", *withRedistributable},
+	}
+	for _, f := range pkgGoDevOnly {
+		if f.set && (*sourceName != "pkggodev" || *sourceFallback != "" || len(sourceList) > 0) {
+			return &cmdError{code: 2, msg: f.name + " cannot be used with -source, -source-fallback, or -sources"}
+		}
+	}
+	singleSource := []struct {
+		name string
+		set  bool
+	}{
+		{"-with-vulns", *withVulns},
+		{"-with-stars", *withStars},
+		{"-with-scorecard", *withScorecard},
+		{"-with-age", *withAge},
+	}
+	for _, f := range singleSource {
+		if f.set && len(sourceList) > 0 {
+			return &cmdError{code: 2, msg: f.name + " cannot be used with -sources"}
+		}
+	}
+
+	if *verify {
+		if *sourceName != "pkggodev" || len(sourceList) > 0 {
+			return &cmdError{code: 2, msg: "-verify cannot be used with -source or -sources"}
+		}
+		if *verifyTolerance < 0 || *verifyTolerance >= 1 {
+			return &cmdError{code: 2, msg: fmt.Sprintf("invalid -verify-tolerance value: %v (must be in [0, 1))", *verifyTolerance)}
+		}
+	}
+
+	args := flag.Args()
+
+	// Validate input: cannot use both -pkgs and positional arguments
+	if *pkgsList != "" && len(args) > 0 {
+		return &cmdError{code: 2, msg: "-pkgs and positional arguments cannot be used together"}
+	}
+
+	// Validate input: only one source of packages can be used
+	inputs := 0
+	for _, set := range []bool{*pkgsList != "" || len(args) > 0, *modulePath != "", *githubOrg != "", *searchQuery != "", *indexSince != ""} {
+		if set {
+			inputs++
+		}
+	}
+	if inputs > 1 {
+		return &cmdError{code: 2, msg: "only one of -pkgs or positional arguments, -module, -github-org, -search, and -index-since can be used"}
+	}
+
+	if *rps <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -rps value: %v (must be positive)", *rps)}
+	}
+	if *burst <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -burst value: %d (must be positive)", *burst)}
+	}
+
+	workers, err := parseWorkers(*workersFlag)
+	if err != nil {
+		return &cmdError{code: 2, msg: err.Error()}
+	}
+	if workers != pkgimporters.AutoWorkers {
+		if warning := workersWarning(workers, *rps, *burst); warning != "" {
+			logger.Warn(warning)
+		}
+	}
+
+	if *retryAttempts <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -retry-attempts value: %d (must be positive)", *retryAttempts)}
+	}
+	if *retryDelay <= 0 || *retryMaxDelay <= 0 {
+		return &cmdError{code: 2, msg: "-retry-delay and -retry-max-delay must be positive"}
+	}
+
+	if *breakerThreshold < 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -breaker-threshold value: %d (must not be negative)", *breakerThreshold)}
+	}
+	if *breakerCooldown <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -breaker-cooldown value: %v (must be positive)", *breakerCooldown)}
+	}
+
+	if *cacheTTL <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -cache-ttl value: %v (must be positive)", *cacheTTL)}
+	}
+	if *offline {
+		if *cacheDir == "" {
+			return &cmdError{code: 2, msg: "-offline requires -cache-dir"}
+		}
+		if *modulePath != "" || *githubOrg != "" || *searchQuery != "" || *indexSince != "" || *vanity || *verify {
+			return &cmdError{code: 2, msg: "-offline cannot be used with -module, -github-org, -search, -index-since, -vanity, or -verify"}
+		}
+	}
+
+	if *recordDir != "" && *replayDir != "" {
+		return &cmdError{code: 2, msg: "-record cannot be used with -replay"}
+	}
+
+	if *checkpointFile != "" && (*offline || len(sourceList) > 0) {
+		return &cmdError{code: 2, msg: "-checkpoint cannot be used with -offline or -sources"}
+	}
+
+	if *maxIdleConnsPerHost <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -max-idle-conns-per-host value: %d (must be positive)", *maxIdleConnsPerHost)}
+	}
+	if *idleConnTimeout <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -idle-conn-timeout value: %v (must be positive)", *idleConnTimeout)}
+	}
+	if *keepAlive == 0 {
+		return &cmdError{code: 2, msg: "invalid -keep-alive value: 0 (must be positive, or negative to disable keep-alive probes)"}
+	}
+
+	if *timeout <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -timeout value: %v (must be positive)", *timeout)}
+	}
+	if *hedgeDelay < 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -hedge-delay value: %v (must not be negative)", *hedgeDelay)}
+	}
+	if *deadline < 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -deadline value: %v (must not be negative)", *deadline)}
+	}
+
+	if *limit <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -limit value: %d (must be positive)", *limit)}
+	}
+
+	if *indexUntil != "" && *indexSince == "" {
+		return &cmdError{code: 2, msg: "-index-until requires -index-since"}
+	}
+	var since, until time.Time
+	if *indexSince != "" {
+		now := time.Now()
+		var err error
+		if since, err = parseTime(*indexSince, now); err != nil {
+			return &cmdError{code: 2, msg: fmt.Sprintf("invalid -index-since value: %v", err)}
+		}
+		if *indexUntil != "" {
+			if until, err = parseTime(*indexUntil, now); err != nil {
+				return &cmdError{code: 2, msg: fmt.Sprintf("invalid -index-until value: %v", err)}
+			}
+		}
+	}
+
+	// Validate input: must provide at least one
+	if inputs == 0 {
+		return &cmdError{code: 2, msg: "no packages specified; use -h for help"}
+	}
+
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = cmp.Or(err, stopProfiling())
+	}()
+
+	transport, err := newTransport(transportOptions{
+		proxy:              *proxy,
+		caCert:             *caCert,
+		insecureSkipVerify: *insecureSkipVerify,
+		tuning: pkgimporters.TransportOptions{
+			MaxIdleConnsPerHost: *maxIdleConnsPerHost,
+			IdleConnTimeout:     *idleConnTimeout,
+			KeepAlive:           *keepAlive,
+			DisableHTTP2:        *disableHTTP2,
+		},
+	})
+	if err != nil {
+		return &cmdError{code: 2, msg: err.Error()}
+	}
+	if *insecureSkipVerify {
+		logger.Warn("TLS certificate verification is disabled")
+	}
+	var up *uploader
+	if *uploadURL != "" {
+		uploadClient := &http.Client{Transport: pkgimporters.WithHeader("User-Agent", *userAgent)(transport)}
+		if up, err = newUploader(*uploadURL, uploadClient, os.Getenv); err != nil {
+			return &cmdError{code: 2, msg: err.Error()}
+		}
+	}
+	var sheets *sheetWriter
+	if *sheet != "" {
+		if len(sourceList) > 0 {
+			return &cmdError{code: 2, msg: "-sheet cannot be used with -sources"}
+		}
+		sheetClient := &http.Client{Transport: pkgimporters.WithHeader("User-Agent", *userAgent)(transport)}
+		if sheets, err = newSheetWriter(*sheet, sheetClient, os.Getenv); err != nil {
+			return &cmdError{code: 2, msg: err.Error()}
+		}
+	}
+	var rt http.RoundTripper = transport
+	switch {
+	case *recordDir != "":
+		rt = pkgimporters.Record(*recordDir)(rt)
+	case *replayDir != "":
+		rt = pkgimporters.Replay(*replayDir)
+	}
+	httpClient := &http.Client{Transport: pkgimporters.WithHeader("User-Agent", *userAgent)(rt)}
+	srcOpts := sourceOptions{
+		httpClient:       httpClient,
+		baseURL:          *baseURL,
+		sourcegraphURL:   *sourcegraphURL,
+		sourcegraphToken: *sourcegraphToken,
+		librariesIOKey:   *librariesIOKey,
+		countModules:     *modules,
+		examples:         *withExamples,
+		license:          *withLicense,
+		version:          *withVersion,
+		imports:          *withImports,
+		redistributable:  *withRedistributable,
+	}
+	// newClient returns a client of the source with the given name,
+	// falling back to the sources with the fallback names in order.
+	newClient := func(name string, fallback ...string) (*pkgimporters.Client, error) {
+		source, err := newSource(name, srcOpts)
+		if err != nil {
+			return nil, err
+		}
+		if len(fallback) > 0 {
+			chain := &pkgimporters.Fallback{Sources: []pkgimporters.Source{source}}
+			for _, fallbackName := range fallback {
+				source, err := newSource(fallbackName, srcOpts)
+				if err != nil {
+					return nil, err
+				}
+				chain.Sources = append(chain.Sources, source)
+			}
+			source = chain
+			name = strings.Join(append([]string{name}, fallback...), "+")
+		}
+		clientLogger := logger
+		if len(sourceList) > 0 {
+			clientLogger = logger.With("source", name)
+		}
+		var breaker *pkgimporters.CircuitBreaker
+		if *breakerThreshold > 0 {
+			breaker = &pkgimporters.CircuitBreaker{Threshold: *breakerThreshold, Cooldown: *breakerCooldown}
+		}
+		var cache pkgimporters.Cache
+		if *cacheDir != "" {
+			cache = &pkgimporters.DiskCache{Dir: filepath.Join(*cacheDir, cacheName(name, srcOpts))}
+		}
+		return &pkgimporters.Client{
+			HTTPClient: httpClient,
+			BaseURL:    *baseURL,
+			Source:     source,
+			Cache:      cache,
+			CacheTTL:   *cacheTTL,
+			Offline:    *offline,
+			Retry: pkgimporters.RetryPolicy{
+				MaxAttempts: *retryAttempts,
+				BaseDelay:   *retryDelay,
+				MaxDelay:    *retryMaxDelay,
+			},
+			Breaker:           breaker,
+			Timeout:           *timeout,
+			HedgeDelay:        *hedgeDelay,
+			RequestsPerSecond: *rps,
+			Burst:             *burst,
+			Workers:           workers,
+			Logger:            clientLogger,
+			OnResult: func(r pkgimporters.Result, err error) {
+				if err != nil {
+					clientLogger.Info("fetch failed", "pkg", r.Path, "err", err)
+					return
+				}
+				clientLogger.Info("fetched", "pkg", r.Path, "count", r.Count)
+			},
+		}, nil
+	}
+	client, err := newClient(*sourceName, splitSourceNames(*sourceFallback)...)
+	if err != nil {
+		return &cmdError{code: 2, msg: err.Error()}
+	}
+	if *withVulns {
+		client.Enrichers = append(client.Enrichers, &pkgimporters.OSV{HTTPClient: httpClient})
+	}
+	if *withStars {
+		client.Enrichers = append(client.Enrichers, &pkgimporters.GitHubStars{HTTPClient: httpClient, Token: os.Getenv("GITHUB_TOKEN")})
+	}
+	if *withScorecard {
+		client.Enrichers = append(client.Enrichers, &pkgimporters.Scorecard{HTTPClient: httpClient})
+	}
+	if *withAge {
+		client.Enrichers = append(client.Enrichers, &pkgimporters.ReleaseAge{HTTPClient: httpClient})
+	}
+	compareClients := make([]*pkgimporters.Client, 0, len(sourceList))
+	for _, name := range sourceList {
+		c, err := newClient(name)
+		if err != nil {
+			return &cmdError{code: 2, msg: err.Error()}
+		}
+		compareClients = append(compareClients, c)
+	}
+
+	ctx := context.Background()
+	if *deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *deadline)
+		defer cancel()
+	}
+
+	opts := loadOptions{
+		includeInternal: *includeInternal,
+		includeVendor:   *includeVendor,
+		sets:            sets,
+	}
+	var pkgPaths []string
+	switch {
+	case *modulePath != "":
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		pkgPaths, err = loadModulePackagePaths(ctx, httpClient, *modulePath, opts)
+		cancel()
+	case *githubOrg != "":
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		pkgPaths, err = loadGitHubOrgModulePaths(ctx, httpClient, *githubOrg, os.Getenv("GITHUB_TOKEN"))
+		cancel()
+	case *searchQuery != "":
+		ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		pkgPaths, err = client.Search(ctx, *searchQuery, *limit)
+		cancel()
+	case *indexSince != "":
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		pkgPaths, err = loadIndexModulePaths(ctx, httpClient, since, until, *limit)
+		cancel()
+	default:
+		pkgPaths, err = resolvePackages(*pkgsList, args, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	pkgPaths, dups := normalizePackages(pkgPaths)
+	if len(dups) > 0 {
+		logger.Warn("skipping duplicate packages", "count", len(dups), "pkgs", dups)
+	}
+
+	if *exclude != "" {
+		pkgPaths = excludePackages(pkgPaths, strings.Split(*exclude, ","))
+	}
+
+	if err := validatePackages(pkgPaths); err != nil {
+		return &cmdError{code: 2, msg: err.Error()}
+	}
+
+	if *vanity {
+		pkgPaths = appendVanityRepoPaths(ctx, httpClient, logger, pkgPaths)
+	}
+
+	// With -append, the results are appended to the -o file after the run.
+	var out io.Writer = os.Stdout
+	if *output != "" && !*appendOutput {
+		var closeOutput func() error
+		if out, closeOutput, err = createOutput(os.Stdout, *output); err != nil {
+			return err
+		}
+		defer func() {
+			err = cmp.Or(err, closeOutput())
+		}()
+	}
+	runTime := time.Now()
+	// The uploaded report is the output, or with -append the whole -o file.
+	var report bytes.Buffer
+	uploadReport := func() error {
+		if up == nil {
+			return nil
+		}
+		data := report.Bytes()
+		if *appendOutput {
+			var err error
+			if data, err = os.ReadFile(*output); err != nil {
+				return err
+			}
+		}
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		defer cancel()
+		objectURL, err := up.upload(ctx, reportName(runTime, *format), reportContentType(*format), data)
+		if err != nil {
+			return err
+		}
+		logger.Info("uploaded", "url", objectURL)
+		return nil
+	}
+	if up != nil {
+		out = io.MultiWriter(out, &report)
+	}
+
+	if len(sourceList) > 0 {
+		comparisons, err := compareSources(ctx, sourceList, compareClients, pkgPaths)
+		if err != nil {
+			return err
+		}
+		if *sortBy == "count" {
+			// Sort descending by the count of the first source, then by name for ties
+			slices.SortFunc(comparisons, func(a, b comparison) int {
+				return cmp.Or(cmp.Compare(b.Counts[sourceList[0]], a.Counts[sourceList[0]]), cmp.Compare(a.Path, b.Path))
+			})
+		} else {
+			slices.SortFunc(comparisons, func(a, b comparison) int {
+				return cmp.Compare(a.Path, b.Path)
+			})
+		}
+		if err := renderComparisons(out, *format, sourceList, comparisons); err != nil {
+			return err
+		}
+		return uploadReport()
+	}
+
+	var stats *runStats
+	if *showStats || *verbose || *veryVerbose {
+		stats = newRunStats()
+		stats.hook(client)
+	}
+
+	fetchOpts := fetchOptions{failFast: *failFast, strict: *strict}
+	if streamRenderer != nil {
+		if fetchOpts.emit, err = streamRenderer.RenderStream(out); err != nil {
+			return err
+		}
+	}
+	streamEmit := fetchOpts.emit
+	// The progress bar would be garbled by log lines and the TUI, and offline runs finish instantly.
+	var bar *progress
+	if !*noProgress && !*quiet && !*verbose && !*veryVerbose && !*offline && !*tui && isTerminal(os.Stderr) {
+		bar = newProgress(os.Stderr, len(pkgPaths))
+		emit := fetchOpts.emit
+		fetchOpts.emit = func(r pkgimporters.Result) error {
+			if emit != nil {
+				bar.clear()
+				if err := emit(r); err != nil {
+					return err
+				}
+			}
+			bar.add()
+			return nil
+		}
+	}
+	var cp *checkpoint
+	if *checkpointFile != "" {
+		if cp, err = loadCheckpoint(*checkpointFile); err != nil {
+			return err
+		}
+	}
+	fetch := func(ctx context.Context, opts fetchOptions) (results, failures []pkgimporters.Result, err error) {
+		switch {
+		case *offline:
+			results, err = offlineCounts(ctx, client, logger, pkgPaths)
+			if err == nil && opts.emit != nil {
+				for _, r := range results {
+					if err = opts.emit(r); err != nil {
+						break
+					}
+				}
+			}
+			return results, nil, err
+		case cp != nil:
+			return checkpointCounts(ctx, client, cp, pkgPaths, opts)
+		default:
+			return fetchCounts(ctx, client, pkgPaths, opts, nil)
+		}
+	}
+	var results, failures []pkgimporters.Result
+	if *tui {
+		results, failures, err = runTUI(ctx, newTUIModel(*baseURL, len(pkgPaths), *sortBy), fetch, fetchOpts)
+	} else {
+		results, failures, err = fetch(ctx, fetchOpts)
+	}
+	if bar != nil {
+		bar.clear()
+	}
+	if stats != nil {
+		if err := stats.write(os.Stderr); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w (-deadline %v exceeded)", err, *deadline)
+		}
+		var pkgErr *pkgimporters.PackageError
+		if errors.As(err, &pkgErr) && errors.Is(err, pkgimporters.ErrNotFound) {
+			if suggestion := suggestPackage(ctx, client, pkgErr.Path); suggestion != "" {
+				err = fmt.Errorf("%w; did you mean %s?", err, suggestion)
+			}
+		}
+		return err
+	}
+
+	// The total of a module is rendered as a last row in every format, aligned with the rows of its packages in text.
+	var total []pkgimporters.Result
+	if *modulePath != "" && !*quiet {
+		modPath, _, _ := strings.Cut(*modulePath, "@")
+		row := pkgimporters.Result{Path: modPath + " (total)", Status: pkgimporters.StatusOK}
+		for _, r := range results {
+			row.Count += r.Count
+		}
+		total = append(total, row)
+	}
+
+	if streamRenderer == nil {
+		switch *sortBy {
+		case "name":
+			slices.SortFunc(results, func(a, b pkgimporters.Result) int {
+				return cmp.Compare(a.Path, b.Path)
+			})
+		case "count":
+			slices.SortFunc(results, func(a, b pkgimporters.Result) int {
+				// Sort descending by count, then by name for ties
+				return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Path, b.Path))
+			})
+		}
+
+		switch {
+		case *quiet && *format == "text" && len(pkgPaths) == 1 && len(results) == 1:
+			// Only the count, e.g., for count=$(pkgimporters -q fmt).
+			r := results[0]
+			if r.Error != "" {
+				return fmt.Errorf("%s: %s", r.Path, r.Error)
+			}
+			if _, err := fmt.Fprintln(out, r.Count); err != nil {
+				return err
+			}
+		case *appendOutput:
+			if err := appendResults(*output, *format, runTime, results); err != nil {
+				return err
+			}
+		default:
+			if err := renderer.Render(out, slices.Concat(results, total)); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, r := range total {
+			if err := streamEmit(r); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := uploadReport(); err != nil {
+		return err
+	}
+	if sheets != nil {
+		rows, err := sheetRows(results)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		err = sheets.write(ctx, rows)
+		cancel()
+		if err != nil {
+			return err
+		}
+		logger.Info("wrote sheet", "spreadsheet", sheets.spreadsheetID, "tab", sheets.tab, "rows", len(rows))
+	}
+
+	if *verify {
+		depsDev, err := newClient("depsdev")
+		if err != nil {
+			return err
+		}
+		fetched := slices.DeleteFunc(slices.Clone(results), func(r pkgimporters.Result) bool {
+			return r.Error != ""
+		})
+		if err := verifyCounts(ctx, depsDev, fetched, *verifyTolerance); err != nil {
+			return err
+		}
+	}
+
+	if err := failuresError(failures, len(results)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newLogger returns a logger writing to w in the given format.
+// It logs warnings by default, informational messages if verbose,
+// and debug messages if veryVerbose.
+func newLogger(w io.Writer, format string, verbose, veryVerbose bool) (*slog.Logger, error) {
+	level := slog.LevelWarn
+	switch {
+	case veryVerbose:
+		level = slog.LevelDebug
+	case verbose:
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid -log-format value: %q (must be 'text' or 'json')", format)
+	}
+}
+
+// sourceOptions configures the sources created by newSource.
+type sourceOptions struct {
+	httpClient       *http.Client
+	baseURL          string
+	sourcegraphURL   string
+	sourcegraphToken string
+	librariesIOKey   string
+	countModules     bool
+	examples         int
+	license          bool
+	version          bool
+	imports          bool
+	redistributable  bool
+}
+
+// cacheName returns the name of the -cache-dir subdirectory of the source with the given name,
+// or of a -source-fallback chain named by its sources joined with "+", e.g., "pkggodev+depsdev".
+// Counts from different sources are not comparable, so each source and chain has its own cache,
+// and entries cached without -modules or the -with flags of pkg.go.dev details lack what they add,
+// so those have their own too.
+func cacheName(name string, opts sourceOptions) string {
+	if opts.countModules {
+		name += "-modules"
+	}
+	if opts.examples > 0 {
+		name += fmt.Sprintf("-examples%d", opts.examples)
+	}
+	if opts.license {
+		name += "-license"
+	}
+	if opts.version {
+		name += "-version"
+	}
+	if opts.imports {
+		name += "-imports"
+	}
+	if opts.redistributable {
+		name += "-redistributable"
+	}
+	return name
+}
+
+// sourceNames are the names accepted by newSource.
+var sourceNames = []string{"pkggodev", "depsdev", "sourcegraph", "github-dependents", "librariesio", "ecosystems"}
+
+// splitSourceNames splits a comma-separated list of source names, dropping blanks and duplicates.
+func splitSourceNames(list string) []string {
+	var names []string
+	for name := range strings.SplitSeq(list, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// newSource returns the importer count source with the given name.
+func newSource(name string, opts sourceOptions) (pkgimporters.Source, error) {
+	switch name {
+	case "pkggodev":
+		return &pkgimporters.PkgGoDev{HTTPClient: opts.httpClient, BaseURL: opts.baseURL, CountModules: opts.countModules, Examples: opts.examples, License: opts.license, Version: opts.version, Imports: opts.imports, Redistributable: opts.redistributable}, nil
+	case "depsdev":
+		return &pkgimporters.DepsDev{HTTPClient: opts.httpClient}, nil
+	case "sourcegraph":
+		return &pkgimporters.Sourcegraph{
+			HTTPClient: opts.httpClient,
+			Endpoint:   opts.sourcegraphURL,
+			Token:      opts.sourcegraphToken,
+		}, nil
+	case "github-dependents":
+		return &pkgimporters.GitHubDependents{HTTPClient: opts.httpClient}, nil
+	case "librariesio":
+		if opts.librariesIOKey == "" {
+			return nil, errors.New("-source librariesio requires an API key; set -librariesio-key or LIBRARIES_IO_API_KEY")
+		}
+		return &pkgimporters.LibrariesIO{HTTPClient: opts.httpClient, APIKey: opts.librariesIOKey}, nil
+	case "ecosystems":
+		return &pkgimporters.Ecosystems{HTTPClient: opts.httpClient}, nil
+	default:
+		return nil, fmt.Errorf("invalid -source value: %q (must be one of %s)", name, strings.Join(sourceNames, ", "))
+	}
+}
+
+// resolvePackages resolves packages from either the -pkgs flag or positional arguments.
+// The special names "std" and "cmd" expand to all standard library
+// or Go distribution command packages, and "@name" to the members of a package set of the config file;
+// they may be mixed with regular package paths.
+// Caller must ensure that exactly one of pkgsList or args is non-empty.
+func resolvePackages(pkgsList string, args []string, opts loadOptions) ([]string, error) {
+	names := args
+	if len(names) == 0 {
+		names = strings.Split(pkgsList, ",")
+	}
+
+	names, err := expandSets(names, opts.sets)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !isMetaPackage(name) {
+			pkgs = append(pkgs, name)
+			continue
+		}
+
+		paths, err := loadPackagePaths(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, paths...)
+	}
+	return pkgs, nil
+}
+
+// expandSets replaces the names of package sets in names, "@name", with their members.
+// Sets cannot reference other sets.
+func expandSets(names []string, sets map[string][]string) ([]string, error) {
+	var expanded []string
+	for _, name := range names {
+		setName, ok := strings.CutPrefix(strings.TrimSpace(name), "@")
+		if !ok {
+			expanded = append(expanded, name)
+			continue
+		}
+		members, ok := sets[setName]
+		if !ok {
+			return nil, fmt.Errorf("unknown package set %q; define it under %s in the config file", name, setsKey)
+		}
+		if slices.ContainsFunc(members, func(m string) bool { return strings.HasPrefix(m, "@") }) {
+			return nil, fmt.Errorf("package set %q cannot reference another set", name)
+		}
+		expanded = append(expanded, members...)
+	}
+	return expanded, nil
+}
+
+// normalizePackages cleans up package paths pasted from various sources:
+// it trims spaces and trailing slashes, strips pkg.go.dev URL prefixes, queries, and fragments,
+// and drops empty paths.
+// It returns the normalized paths in their original order without duplicates,
+// and the duplicate paths that were removed.
+func normalizePackages(pkgPaths []string) (paths, dups []string) {
+	seen := make(map[string]bool, len(pkgPaths))
+	for _, path := range pkgPaths {
+		path = normalizePackagePath(path)
+		if path == "" {
+			continue
+		}
+		if seen[path] {
+			dups = append(dups, path)
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths, dups
+}
+
+// normalizePackagePath returns the package path for a path or a pkg.go.dev URL,
+// e.g., "https://pkg.go.dev/net/http?tab=importedby" becomes "net/http".
+func normalizePackagePath(path string) string {
+	path = strings.TrimSpace(path)
+	for _, prefix := range []string{"https://", "http://"} {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	path = strings.TrimPrefix(path, "pkg.go.dev/")
+	path, _, _ = strings.Cut(path, "#")
+	path, _, _ = strings.Cut(path, "?")
+	return strings.TrimRight(path, "/")
+}
+
+// validatePackages checks that every path is a valid import path,
+// so that garbage input fails fast instead of wasting rate-limited requests.
+// It reports all invalid paths at once.
+func validatePackages(pkgPaths []string) error {
+	var errs []error
+	for _, path := range pkgPaths {
+		if err := module.CheckImportPath(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// appendVanityRepoPaths returns pkgPaths extended with the repository paths of the vanity paths,
+// logging each resolution. Paths that cannot be resolved are logged and skipped.
+func appendVanityRepoPaths(ctx context.Context, client *http.Client, logger *slog.Logger, pkgPaths []string) []string {
+	for _, path := range pkgPaths {
+		if !isVanityPath(path) {
+			continue
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		repoPath, err := resolveVanityPath(reqCtx, client, path)
+		cancel()
+		if err != nil {
+			logger.Warn("resolve vanity path", "pkg", path, "err", err)
+			continue
+		}
+		if repoPath == path || slices.Contains(pkgPaths, repoPath) {
+			continue
+		}
+
+		logger.Info("resolved vanity path", "pkg", path, "repo", repoPath)
+		pkgPaths = append(pkgPaths, repoPath)
+	}
+	return pkgPaths
+}
+
+// excludePackages returns pkgPaths without the paths matching any of the patterns.
+// A pattern is a package path that may contain "..." wildcards, as in the go command.
+func excludePackages(pkgPaths, patterns []string) []string {
+	return slices.DeleteFunc(pkgPaths, matchPatterns(patterns))
+}
+
+// filterPackages returns the paths of pkgPaths matching any of the patterns, as in excludePackages.
+func filterPackages(pkgPaths, patterns []string) []string {
+	match := matchPatterns(patterns)
+	return slices.DeleteFunc(pkgPaths, func(path string) bool {
+		return !match(path)
+	})
+}
+
+// matchPatterns returns a function that reports whether a package path matches any of the patterns.
+// Empty patterns are ignored.
+func matchPatterns(patterns []string) func(path string) bool {
+	var matchers []func(string) bool
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		matchers = append(matchers, matchPattern(pattern))
+	}
+	return func(path string) bool {
+		return slices.ContainsFunc(matchers, func(match func(string) bool) bool {
+			return match(path)
+		})
+	}
+}
+
+// matchPattern returns a function that reports whether a package path matches pattern.
+// The "..." wildcard matches any string, and "foo/..." also matches "foo" itself.
+func matchPattern(pattern string) func(path string) bool {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+	return regexp.MustCompile(`^` + re + `$`).MatchString
+}
+
+// isMetaPackage reports whether name is a meta-package name understood by the go command.
+func isMetaPackage(name string) bool {
+	return name == "std" || name == "cmd"
+}
+
+// loadOptions controls which packages loadPackagePaths returns.
+type loadOptions struct {
+	includeInternal bool
+	includeVendor   bool
+
+	// sets are the named package sets of the config file, referenced as "@name".
+	sets map[string][]string
+}
+
+// loadPackagePaths returns a list of all package paths matching the meta-package pattern,
+// such as "std" or "cmd".
+// Internal and vendor packages are excluded unless opts asks to include them.
+func loadPackagePaths(pattern string, opts loadOptions) ([]string, error) {
+	pkgs, err := packages.Load(nil, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("load %s packages: %w", pattern, err)
+	}
+
+	var paths []string
+	for _, pkg := range pkgs {
+		if !opts.includeInternal && hasPathElem(pkg.PkgPath, "internal") {
+			continue
+		}
+		if !opts.includeVendor && hasPathElem(pkg.PkgPath, "vendor") {
+			continue
+		}
+		paths = append(paths, pkg.PkgPath)
+	}
+	return paths, nil
+}
+
+// hasPathElem reports whether the slash-separated path contains elem as one of its elements.
+func hasPathElem(path, elem string) bool {
+	for p := range strings.SplitSeq(path, "/") {
+		if p == elem {
+			return true
+		}
+	}
+	return false
+}
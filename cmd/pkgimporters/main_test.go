@@ -0,0 +1,547 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestResolvePackages(t *testing.T) {
+	t.Run("comma-separated packages", func(t *testing.T) {
+		got, err := resolvePackages(" fmt, io ,net/http", nil, loadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"fmt", "io", "net/http"}
+		if !slices.Equal(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("package sets", func(t *testing.T) {
+		opts := loadOptions{sets: map[string][]string{"myorg": {"github.com/myorg/a", "github.com/myorg/b"}, "nested": {"@myorg"}}}
+		got, err := resolvePackages("fmt,@myorg", nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"fmt", "github.com/myorg/a", "github.com/myorg/b"}; !slices.Equal(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		for _, name := range []string{"@unknown", "@nested"} {
+			if _, err := resolvePackages("", []string{name}, opts); err == nil {
+				t.Errorf("expected an error for %s", name)
+			}
+		}
+	})
+
+	t.Run("std mixed with extra packages", func(t *testing.T) {
+		got, err := resolvePackages("", []string{"std", "golang.org/x/net/http2"}, loadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range []string{"fmt", "net/http", "golang.org/x/net/http2"} {
+			if !slices.Contains(got, want) {
+				t.Errorf("expected %q in %v", want, got)
+			}
+		}
+		if slices.ContainsFunc(got, func(path string) bool { return hasPathElem(path, "internal") }) {
+			t.Errorf("expected no internal packages, got %v", got)
+		}
+	})
+}
+
+func TestLoadPackagePaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"mod.go",
+		"sub/sub.go",
+		"internal/impl/impl.go",
+		"sub/internal/x/x.go",
+		"third_party/vendor/lib/lib.go",
+		"testdata/fixture.go",
+		"_examples/example.go",
+		".hidden/hidden.go",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package "+strings.TrimSuffix(filepath.Base(name), ".go")+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/mod\n\ngo 1.25\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOWORK", "off")
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Chdir(dir)
+
+	tests := []struct {
+		name     string
+		pattern  string
+		opts     loadOptions
+		want     []string
+		wantDirs []string
+	}{
+		{
+			name:     "internal and vendor excluded by default",
+			pattern:  "./...",
+			want:     []string{"example.com/mod", "example.com/mod/sub"},
+			wantDirs: []string{".", "sub"},
+		},
+		{
+			name:     "include internal",
+			pattern:  "./...",
+			opts:     loadOptions{includeInternal: true},
+			want:     []string{"example.com/mod", "example.com/mod/internal/impl", "example.com/mod/sub", "example.com/mod/sub/internal/x"},
+			wantDirs: []string{".", "internal/impl", "sub", "sub/internal/x"},
+		},
+		{
+			name:     "vendor excluded by default",
+			pattern:  "./third_party/vendor/...",
+			wantDirs: []string{".", "sub"},
+		},
+		{
+			name:     "include vendor",
+			pattern:  "./third_party/vendor/...",
+			opts:     loadOptions{includeVendor: true},
+			want:     []string{"example.com/mod/third_party/vendor/lib"},
+			wantDirs: []string{".", "sub", "third_party/vendor/lib"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadPackagePaths(tt.pattern, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("loadPackagePaths(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+
+			var gotDirs []string
+			err = filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() || filepath.Ext(path) != ".go" {
+					return err
+				}
+				if dir := filepath.ToSlash(filepath.Dir(path)); !isIgnoredDir(dir, tt.opts) {
+					gotDirs = append(gotDirs, dir)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(gotDirs)
+			if !slices.Equal(gotDirs, tt.wantDirs) {
+				t.Errorf("directories not ignored = %v, want %v", gotDirs, tt.wantDirs)
+			}
+		})
+	}
+}
+
+func TestNormalizePackages(t *testing.T) {
+	gotPaths, gotDups := normalizePackages([]string{
+		"fmt",
+		" net/http/ ",
+		"https://pkg.go.dev/net/http?tab=importedby",
+		"pkg.go.dev/golang.org/x/tools/go/analysis#section-readme",
+		"",
+		"fmt",
+		"http://github.com/spf13/cobra/",
+	})
+
+	wantPaths := []string{"fmt", "net/http", "golang.org/x/tools/go/analysis", "github.com/spf13/cobra"}
+	if !slices.Equal(gotPaths, wantPaths) {
+		t.Errorf("expected paths %v, got %v", wantPaths, gotPaths)
+	}
+	wantDups := []string{"net/http", "fmt"}
+	if !slices.Equal(gotDups, wantDups) {
+		t.Errorf("expected duplicates %v, got %v", wantDups, gotDups)
+	}
+}
+
+func TestValidatePackages(t *testing.T) {
+	if err := validatePackages([]string{"fmt", "net/http", "golang.org/x/tools/go/analysis", "gopkg.in/yaml.v3"}); err != nil {
+		t.Errorf("expected valid paths, got %v", err)
+	}
+
+	err := validatePackages([]string{"fmt", "net//http", "bad path", "-flag"})
+	if err == nil {
+		t.Fatal("expected error for invalid paths")
+	}
+	for _, path := range []string{"net//http", "bad path", "-flag"} {
+		if !strings.Contains(err.Error(), strconv.Quote(path)) {
+			t.Errorf("error should mention %q, got:\n%v", path, err)
+		}
+	}
+}
+
+func TestExcludePackages(t *testing.T) {
+	pkgPaths := []string{"crypto", "crypto/tls", "cryptography", "fmt", "net/http", "net/http/httptest", "testing/fstest"}
+
+	got := excludePackages(pkgPaths, []string{"crypto/...", " testing/... ", "net/.../httptest", ""})
+
+	want := []string{"cryptography", "fmt", "net/http"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestFilterPackages(t *testing.T) {
+	pkgPaths := []string{"github.com/myorg/api", "github.com/myorg", "github.com/myorganization/cli", "github.com/other/lib"}
+
+	got := filterPackages(pkgPaths, []string{"github.com/myorg/...", ""})
+
+	want := []string{"github.com/myorg/api", "github.com/myorg"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCacheName(t *testing.T) {
+	tests := []struct {
+		name string
+		opts sourceOptions
+		want string
+	}{
+		{name: "pkggodev", opts: sourceOptions{}, want: "pkggodev"},
+		{name: "pkggodev+depsdev", opts: sourceOptions{}, want: "pkggodev+depsdev"},
+		{name: "pkggodev", opts: sourceOptions{countModules: true}, want: "pkggodev-modules"},
+		{name: "pkggodev", opts: sourceOptions{countModules: true, examples: 3}, want: "pkggodev-modules-examples3"},
+		{name: "pkggodev", opts: sourceOptions{license: true}, want: "pkggodev-license"},
+		{name: "pkggodev", opts: sourceOptions{license: true, version: true}, want: "pkggodev-license-version"},
+		{name: "pkggodev", opts: sourceOptions{imports: true}, want: "pkggodev-imports"},
+		{name: "pkggodev", opts: sourceOptions{license: true, redistributable: true}, want: "pkggodev-license-redistributable"},
+	}
+	for _, tt := range tests {
+		if got := cacheName(tt.name, tt.opts); got != tt.want {
+			t.Errorf("cacheName(%q, %+v) = %q, want %q", tt.name, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		verbose     bool
+		veryVerbose bool
+		want        []string
+		wantAbsent  []string
+	}{
+		{
+			name:       "warnings by default",
+			format:     "text",
+			want:       []string{"level=WARN"},
+			wantAbsent: []string{"level=INFO", "level=DEBUG"},
+		},
+		{
+			name:       "verbose",
+			format:     "text",
+			verbose:    true,
+			want:       []string{"level=WARN", "level=INFO"},
+			wantAbsent: []string{"level=DEBUG"},
+		},
+		{
+			name:        "very verbose json",
+			format:      "json",
+			veryVerbose: true,
+			want:        []string{`"level":"WARN"`, `"level":"INFO"`, `"level":"DEBUG"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := newLogger(&buf, tt.format, tt.verbose, tt.veryVerbose)
+			if err != nil {
+				t.Fatal(err)
+			}
+			logger.Debug("debug")
+			logger.Info("info")
+			logger.Warn("warn")
+
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("expected %q in logs:\n%s", want, buf.String())
+				}
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(buf.String(), absent) {
+					t.Errorf("unexpected %q in logs:\n%s", absent, buf.String())
+				}
+			}
+		})
+	}
+
+	if _, err := newLogger(io.Discard, "xml", false, false); err == nil {
+		t.Error("expected error for invalid format")
+	}
+}
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binPath := buildBinary(t)
+
+	tests := []struct {
+		name        string
+		args        []string
+		checkOutput func(t *testing.T, output string)
+		checkStderr func(t *testing.T, output string)
+	}{
+		{
+			name: "no arguments should fail",
+			args: []string{},
+			checkStderr: func(t *testing.T, output string) {
+				if !strings.Contains(output, "no packages specified") {
+					t.Errorf("stderr should mention missing packages, got:\n%s", output)
+				}
+			},
+		},
+		{
+			name: "one package",
+			args: []string{"fmt"},
+			checkOutput: func(t *testing.T, output string) {
+				if !strings.HasPrefix(output, "fmt") {
+					t.Errorf("output should start with 'fmt', got:\n%s", output)
+				}
+				assertCountsArePositive(t, output)
+			},
+		},
+		{
+			name: "comma-separated packages",
+			args: []string{"-pkgs", "fmt,io"},
+			checkOutput: func(t *testing.T, output string) {
+				if !strings.Contains(output, "fmt") || !strings.Contains(output, "io") {
+					t.Errorf("output should contain both 'fmt' and 'io', got:\n%s", output)
+				}
+				assertCountsArePositive(t, output)
+			},
+		},
+		{
+			name: "sort by count",
+			args: []string{"-sort", "count", "fmt", "io"},
+			checkOutput: func(t *testing.T, output string) {
+				fmtIdx := strings.Index(output, "fmt ")
+				ioIdx := strings.Index(output, "io ")
+				if fmtIdx < 0 || ioIdx < 0 {
+					t.Fatalf("could not find 'fmt' or 'io' in output:\n%s", output)
+				}
+				if fmtIdx > ioIdx {
+					t.Errorf("'fmt' should appear before 'io' when sorted by count descending, got:\n%s", output)
+				}
+				assertCountsArePositive(t, output)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(binPath, tt.args...)
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+
+			err := cmd.Run()
+
+			stderrOutput := stderr.String()
+			if tt.checkStderr != nil {
+				if err == nil {
+					t.Error("expected command to fail, but it succeeded")
+					return
+				}
+				tt.checkStderr(t, stderrOutput)
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("command failed: %v\nstdout:\n%s\nstderr:\n%s", err, stdout.String(), stderr.String())
+			}
+
+			output := stdout.String()
+			if tt.checkOutput != nil {
+				tt.checkOutput(t, output)
+			}
+		})
+	}
+}
+
+func TestRunBaseURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counts := map[string]string{"/fmt": "5,485,422", "/io": "1,533,321"}
+		count, ok := counts[r.URL.Path]
+		if !ok || r.URL.Query().Get("tab") != "importedby" {
+			http.NotFound(w, r)
+			return
+		}
+		if ua := r.Header.Get("User-Agent"); !strings.HasPrefix(ua, "pkgimporters/") {
+			http.Error(w, "unexpected User-Agent "+ua, http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, "<strong>Known importers:</strong> %s", count)
+	}))
+	defer srv.Close()
+
+	binPath := buildBinary(t)
+
+	cmd := exec.Command(binPath, "-base-url", srv.URL, "-sort", "count", "io", "fmt")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("command failed: %v\n%s", err, out)
+	}
+	want := "fmt                  5,485,422\nio                   1,533,321\n"
+	if got := string(out); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte("base-url: "+srv.URL+"\nformat: csv\nsort: name\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command(binPath, "-config", config, "-sort", "count", "io", "fmt")
+	out, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("command failed with -config: %v\n%s", err, out)
+	}
+	if got := string(out); !strings.HasPrefix(got, "schema_version,path,count,") || !strings.Contains(got, "\n1,fmt,5485422,OK,") || strings.Index(got, "fmt,") > strings.Index(got, "io,") {
+		t.Errorf("expected counts sorted by -sort count in the CSV format of the config file, got:\n%s", got)
+	}
+
+	counts := filepath.Join(t.TempDir(), "counts.csv")
+	for range 2 {
+		if out, err := exec.Command(binPath, "-base-url", srv.URL, "-format", "csv", "-o", counts, "-append", "fmt").CombinedOutput(); err != nil {
+			t.Fatalf("command failed with -append: %v\n%s", err, out)
+		}
+	}
+	if data, err := os.ReadFile(counts); err != nil {
+		t.Fatal(err)
+	} else if got := strings.Count(string(data), "\n1,fmt,5485422,OK,"); got != 2 || !strings.HasPrefix(string(data), "schema_version,") {
+		t.Errorf("expected a header and two appended rows, got:\n%s", data)
+	}
+	if err := exec.Command(binPath, "-append", "fmt").Run(); err == nil {
+		t.Error("expected -append without -o to fail")
+	}
+
+	out, err = exec.Command(binPath, "-schema").Output()
+	if err != nil {
+		t.Fatalf("command failed with -schema: %v\n%s", err, out)
+	}
+	if string(out) != pkgimporters.JSONSchema() {
+		t.Errorf("expected -schema to print the JSON Schema, got:\n%s", out)
+	}
+
+	cmd = exec.Command(binPath, "-base-url", srv.URL, "-q", "fmt")
+	out, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("command failed with -q: %v\n%s", err, out)
+	}
+	if got := string(out); got != "5485422\n" {
+		t.Errorf("expected only the count with -q and a single package, got %q", got)
+	}
+	cmd = exec.Command(binPath, "-base-url", srv.URL, "-q", "io", "fmt")
+	out, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("command failed with -q: %v\n%s", err, out)
+	}
+	if got := string(out); got != "fmt                  5,485,422\nio                   1,533,321\n" {
+		t.Errorf("expected only the result rows with -q, got %q", got)
+	}
+	if err := exec.Command(binPath, "-base-url", srv.URL, "-q", "example.com/missing").Run(); err == nil {
+		t.Error("expected -q to fail for a single unknown package")
+	}
+
+	cmd = exec.Command(binPath, "-base-url", srv.URL, "example.com/missing")
+	out, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("command failed for an unknown package without -strict: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "example.com/missing  NOT_FOUND package not found") {
+		t.Errorf("output should report the unknown package, got:\n%s", out)
+	}
+
+	cmd = exec.Command(binPath, "-base-url", srv.URL, "-strict", "example.com/missing")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected command to fail for an unknown package with -strict")
+	}
+	if !strings.Contains(stderr.String(), "package not found") {
+		t.Errorf("stderr should mention the unknown package, got:\n%s", stderr.String())
+	}
+
+	for _, args := range [][]string{
+		{"-modules", "-source", "depsdev"},
+		{"-with-license", "-source-fallback", "depsdev"},
+		{"-with-examples", "3", "-sources", "pkggodev,depsdev"},
+		{"-with-stars", "-sources", "pkggodev,depsdev"},
+	} {
+		cmd = exec.Command(binPath, append(args, "fmt")...)
+		stderr.Reset()
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 || !strings.Contains(stderr.String(), args[0]+" cannot be used with") {
+			t.Errorf("%v: expected a usage error for %s, got %v:\n%s", args, args[0], err, stderr.String())
+		}
+	}
+}
+
+// buildBinary builds the command into a temporary directory and returns its path.
+func buildBinary(t *testing.T) string {
+	t.Helper()
+
+	binPath := filepath.Join(t.TempDir(), "pkgimporters")
+	cmd := exec.Command("go", "build", "-o", binPath, ".")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build binary: %v\n%s", err, out)
+	}
+	return binPath
+}
+
+func assertCountsArePositive(t *testing.T, output string) {
+	t.Helper()
+
+	for line := range strings.Lines(output) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			t.Fatalf("unexpected output line: %s", line)
+		}
+
+		countStr := strings.ReplaceAll(fields[len(fields)-1], ",", "")
+		count, err := strconv.Atoi(countStr)
+		if err != nil {
+			t.Fatalf("failed to parse count %q in line: %s", fields[len(fields)-1], line)
+		}
+
+		if count <= 0 {
+			t.Errorf("expected count > 0, got %d in line: %s", count, line)
+		}
+	}
+}
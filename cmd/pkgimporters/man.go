@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// runMan runs the man subcommand, which prints a man page of the main command
+// generated from its usage and the flags of main.
+func runMan(main *flag.FlagSet, args []string, stdout, stderr io.Writer) (err error) {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("man", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "write the man page to `file` instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s man - generate a man page\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s man [-o file]\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s man prints the man page of %[1]s in roff, generated from its usage and flags.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEXAMPLES\n"+
+			"    %[1]s man -o %[1]s.1\n"+
+			"        Write the man page to %[1]s.1 for packaging\n\n"+
+			"    %[1]s man | man -l -\n"+
+			"        Read the man page without installing it\n", progName)
+	}
+	args, err = parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return &cmdError{code: 2, msg: "man takes no arguments; use man -h for help"}
+	}
+
+	var usage bytes.Buffer
+	out := main.Output()
+	main.SetOutput(&usage)
+	main.Usage()
+	main.SetOutput(out)
+
+	w, closeOutput, err := createOutput(stdout, *output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := closeOutput(); err == nil {
+			err = cerr
+		}
+	}()
+	return writeMan(w, progName, version(), usage.String(), main)
+}
+
+// writeMan writes the man page of prog in roff to w.
+// Its sections are those of usage, the output of a flag.Usage function, whose headings are upper-case lines
+// and whose content is indented, except that the OPTIONS section lists the flags of fs.
+// Paragraphs are separated by empty lines, and in EXAMPLES, the commands are followed by their more indented descriptions.
+func writeMan(w io.Writer, prog, version, usage string, fs *flag.FlagSet) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1 \"\" \"%s %s\" \"User Commands\"\n", strings.ToUpper(prog), prog, version)
+	for _, section := range usageSections(usage) {
+		fmt.Fprintf(&b, ".SH %s\n", section.heading)
+		switch section.heading {
+		case "NAME":
+			b.WriteString(strings.Replace(roffText(strings.Join(section.lines, " ")), " - ", ` \- `, 1) + "\n")
+		case "SYNOPSIS":
+			b.WriteString(".nf\n")
+			for _, line := range section.lines {
+				b.WriteString(roffText(line) + "\n")
+			}
+			b.WriteString(".fi\n")
+		case "OPTIONS":
+			fs.VisitAll(func(f *flag.Flag) {
+				name, usage := flag.UnquoteUsage(f)
+				fmt.Fprintf(&b, ".TP\n\\fB%s\\fR", roffText("-"+f.Name))
+				if name != "" {
+					fmt.Fprintf(&b, " \\fI%s\\fR", roffText(name))
+				}
+				b.WriteString("\n" + roffText(usage))
+				if !slices.Contains([]string{"", "0", "false", "0s"}, f.DefValue) {
+					b.WriteString(" (default " + roffText(f.DefValue) + ")")
+				}
+				b.WriteString("\n")
+			})
+		case "EXAMPLES":
+			for _, line := range section.lines {
+				switch {
+				case line == "":
+				case strings.HasPrefix(line, "    "):
+					b.WriteString(roffText(strings.TrimSpace(line)) + "\n")
+				default:
+					fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n", roffText(line))
+				}
+			}
+		default:
+			for i, line := range section.lines {
+				switch {
+				case line != "":
+					b.WriteString(roffText(strings.TrimSpace(line)) + "\n")
+				case i > 0 && section.lines[i-1] != "":
+					b.WriteString(".PP\n")
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// usageSection is a section of usage output, with the lines of its content unindented by one level.
+type usageSection struct {
+	heading string
+	lines   []string
+}
+
+// usageSections splits usage output into its sections, dropping empty lines around their content.
+func usageSections(usage string) []usageSection {
+	var sections []usageSection
+	for line := range strings.Lines(usage) {
+		line = strings.TrimRight(line, " \t\r\n")
+		if line != "" && line == strings.TrimSpace(line) && line == strings.ToUpper(line) {
+			sections = append(sections, usageSection{heading: line})
+			continue
+		}
+		if len(sections) == 0 {
+			continue
+		}
+		s := &sections[len(sections)-1]
+		s.lines = append(s.lines, strings.TrimPrefix(line, "    "))
+	}
+	for i := range sections {
+		lines := sections[i].lines
+		for len(lines) > 0 && lines[0] == "" {
+			lines = lines[1:]
+		}
+		for len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		sections[i].lines = lines
+	}
+	return sections
+}
+
+// roffText escapes s for a line of roff text: backslashes, hyphens, which would otherwise be rendered
+// as typographic hyphens and break copying flags, and a leading control character.
+func roffText(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
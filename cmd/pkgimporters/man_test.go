@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteMan(t *testing.T) {
+	fs := flag.NewFlagSet("pkgimporters", flag.ContinueOnError)
+	fs.String("cache-dir", "", "`directory` of the cache")
+	fs.Int("workers", 4, "number of concurrent requests")
+	fs.Bool("v", false, "log requests")
+	usage := "NAME\n" +
+		"    pkgimporters - fetch known importers\n\n" +
+		"SYNOPSIS\n" +
+		"    pkgimporters [-workers N] [package ...]\n" +
+		"        [-v]\n\n" +
+		"DESCRIPTION\n" +
+		"    pkgimporters fetches counts.\n" +
+		"    'std' is all packages.\n\n" +
+		"    Second paragraph with a \\ backslash.\n\n" +
+		"OPTIONS\n" +
+		"  -workers int\n" +
+		"    \tnumber of concurrent requests (default 4)\n\n" +
+		"EXAMPLES\n" +
+		"    pkgimporters -pkgs std\n" +
+		"        Fetch all stdlib packages\n\n" +
+		"    pkgimporters fmt\n" +
+		"        Fetch fmt\n"
+
+	var b strings.Builder
+	if err := writeMan(&b, "pkgimporters", "v1.2.3", usage, fs); err != nil {
+		t.Fatal(err)
+	}
+	want := `.TH PKGIMPORTERS 1 "" "pkgimporters v1.2.3" "User Commands"
+.SH NAME
+pkgimporters \- fetch known importers
+.SH SYNOPSIS
+.nf
+pkgimporters [\-workers N] [package ...]
+    [\-v]
+.fi
+.SH DESCRIPTION
+pkgimporters fetches counts.
+\&'std' is all packages.
+.PP
+Second paragraph with a \e backslash.
+.SH OPTIONS
+.TP
+\fB\-cache\-dir\fR \fIdirectory\fR
+directory of the cache
+.TP
+\fB\-v\fR
+log requests
+.TP
+\fB\-workers\fR \fIint\fR
+number of concurrent requests (default 4)
+.SH EXAMPLES
+.TP
+\fBpkgimporters \-pkgs std\fR
+Fetch all stdlib packages
+.TP
+\fBpkgimporters fmt\fR
+Fetch fmt
+`
+	if got := b.String(); got != want {
+		t.Errorf("expected man page:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestRunMan(t *testing.T) {
+	var cmdErr *cmdError
+	if err := runMan(flag.NewFlagSet("pkgimporters", flag.ContinueOnError), []string{"extra"}, io.Discard, io.Discard); !errors.As(err, &cmdErr) || cmdErr.code != 2 {
+		t.Errorf("expected a usage error, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+const moduleProxyURL = "https://proxy.golang.org"
+
+// maxModuleZipSize is the maximum size of a module zip file accepted by the go command.
+const maxModuleZipSize = 500 << 20
+
+// loadModulePackagePaths returns the paths of all packages in the module,
+// discovered by listing the module zip served by the module proxy.
+// The module may be given as "path" for the latest version or "path@version".
+// Internal and vendor packages are excluded unless opts asks to include them.
+func loadModulePackagePaths(ctx context.Context, client *http.Client, mod string, opts loadOptions) ([]string, error) {
+	modPath, version, _ := strings.Cut(mod, "@")
+	if err := module.CheckPath(modPath); err != nil {
+		return nil, err
+	}
+	escPath, err := module.EscapePath(modPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == "" || version == "latest" {
+		version, err = fetchLatestVersion(ctx, client, escPath)
+		if err != nil {
+			return nil, fmt.Errorf("resolve latest version of %s: %w", modPath, err)
+		}
+	}
+	escVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := fetchProxy(ctx, client, escPath+"/@v/"+escVersion+".zip", maxModuleZipSize)
+	if err != nil {
+		return nil, fmt.Errorf("download %s@%s: %w", modPath, version, err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("open %s@%s zip: %w", modPath, version, err)
+	}
+
+	prefix := modPath + "@" + version + "/"
+	var paths []string
+	for _, f := range zr.File {
+		rel, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok || !strings.HasSuffix(rel, ".go") || strings.HasSuffix(rel, "_test.go") {
+			continue
+		}
+
+		dir := path.Dir(rel)
+		if isIgnoredDir(dir, opts) {
+			continue
+		}
+
+		pkgPath := modPath
+		if dir != "." {
+			pkgPath += "/" + dir
+		}
+		paths = append(paths, pkgPath)
+	}
+
+	slices.Sort(paths)
+	return slices.Compact(paths), nil
+}
+
+// isIgnoredDir reports whether the slash-separated directory inside a module
+// is not a package to report, like the go command ignores testdata and directories
+// beginning with "." or "_".
+func isIgnoredDir(dir string, opts loadOptions) bool {
+	if dir == "." {
+		return false
+	}
+	for elem := range strings.SplitSeq(dir, "/") {
+		if elem == "testdata" || strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") {
+			return true
+		}
+		if !opts.includeInternal && elem == "internal" {
+			return true
+		}
+		if !opts.includeVendor && elem == "vendor" {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchLatestVersion returns the latest version of the module with escaped path escPath.
+func fetchLatestVersion(ctx context.Context, client *http.Client, escPath string) (string, error) {
+	body, err := fetchProxy(ctx, client, escPath+"/@latest", 1<<20)
+	if err != nil {
+		return "", err
+	}
+
+	var info struct {
+		Version string
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", fmt.Errorf("decode version info: %w", err)
+	}
+	return info.Version, nil
+}
+
+// fetchProxy downloads at most limit bytes of the file at the given path from the module proxy.
+func fetchProxy(ctx context.Context, client *http.Client, path string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, moduleProxyURL+"/"+path, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	return body, nil
+}
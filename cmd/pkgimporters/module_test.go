@@ -0,0 +1,93 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestLoadModulePackagePaths(t *testing.T) {
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for _, name := range []string{
+		"example.com/mod@v1.2.0/go.mod",
+		"example.com/mod@v1.2.0/mod.go",
+		"example.com/mod@v1.2.0/mod_test.go",
+		"example.com/mod@v1.2.0/sub/sub.go",
+		"example.com/mod@v1.2.0/sub/doc.go",
+		"example.com/mod@v1.2.0/testonly/x_test.go",
+		"example.com/mod@v1.2.0/internal/impl/impl.go",
+		"example.com/mod@v1.2.0/testdata/fixture.go",
+		"example.com/mod@v1.2.0/_examples/main.go",
+	} {
+		if _, err := zw.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &urlTransport{files: map[string][]byte{
+		"https://proxy.golang.org/example.com/mod/@latest":       []byte(`{"Version":"v1.2.0"}`),
+		"https://proxy.golang.org/example.com/mod/@v/v1.2.0.zip": zipBuf.Bytes(),
+	}}
+	client := &http.Client{Transport: transport}
+
+	tests := []struct {
+		name string
+		mod  string
+		opts loadOptions
+		want []string
+	}{
+		{
+			name: "latest version",
+			mod:  "example.com/mod",
+			want: []string{"example.com/mod", "example.com/mod/sub"},
+		},
+		{
+			name: "explicit version with internal packages",
+			mod:  "example.com/mod@v1.2.0",
+			opts: loadOptions{includeInternal: true},
+			want: []string{"example.com/mod", "example.com/mod/internal/impl", "example.com/mod/sub"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadModulePackagePaths(t.Context(), client, tt.mod, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+type urlTransport struct {
+	files map[string][]byte
+}
+
+func (t *urlTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	content, ok := t.files[req.URL.String()]
+	if !ok {
+		return &http.Response{
+			Status:     "404 Not Found",
+			StatusCode: http.StatusNotFound,
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Body:          io.NopCloser(bytes.NewReader(content)),
+		ContentLength: int64(len(content)),
+		Request:       req,
+	}, nil
+}
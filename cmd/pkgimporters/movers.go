@@ -0,0 +1,236 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// mover is the change of the rank of a package by importer count between two runs.
+// Rank 1 is the package with the most importers; packages with equal counts share a rank.
+type mover struct {
+	Path          string `json:"path"`
+	BaselineRank  int    `json:"baseline_rank"`
+	Rank          int    `json:"rank"`
+	Change        int    `json:"change"` // positive if the package rose
+	BaselineCount int    `json:"baseline_count"`
+	Count         int    `json:"count"`
+}
+
+// versionedMover is the JSON object of a mover in the JSON format.
+type versionedMover struct {
+	SchemaVersion int `json:"schema_version"`
+	mover
+}
+
+// runMovers runs the movers subcommand, which ranks the packages of two runs by the change of their rank.
+func runMovers(args []string, stdout, stderr io.Writer) error {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("movers", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	baseline := fs.String("baseline", "", "`file` with the results of the earlier run, in the JSON, JSON Lines, or CSV format")
+	limit := fs.Int("limit", 20, "print at most `N` packages, or all if 0")
+	format := fs.String("format", "text", "output `format`: text, json, or csv")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s movers - rank packages by the change of their rank between two runs\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s movers -baseline file [-limit N] [-format text|json|csv] [file]\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s movers ranks the packages of the baseline run and of the current run, read from file\n"+
+			"    or stdin, by importer count, and prints the packages in both runs whose rank changed the most,\n"+
+			"    rising or declining, surfacing the movers of a set like std that count changes alone hide.\n"+
+			"    The runs are read from the JSON, JSON Lines, or CSV output of %[1]s; in a history written\n"+
+			"    with -append, the latest count of each package is used.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEXAMPLES\n"+
+			"    %[1]s -pkgs std -format json | %[1]s movers -baseline std-2024.json\n"+
+			"        Print the standard library packages that moved the most since a saved run\n\n"+
+			"    %[1]s movers -baseline old.csv -limit 0 -format csv new.csv\n"+
+			"        Print the rank changes of all packages of two saved runs as CSV\n", progName)
+	}
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *baseline == "" {
+		return &cmdError{code: 2, msg: "movers requires -baseline; use movers -h for help"}
+	}
+	if len(args) > 1 {
+		return &cmdError{code: 2, msg: "movers takes at most one file; use movers -h for help"}
+	}
+	if *limit < 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -limit value: %d (must not be negative)", *limit)}
+	}
+	if *format != "text" && *format != "json" && *format != "csv" {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -format value: %q (must be 'text', 'json', or 'csv')", *format)}
+	}
+
+	baselineData, err := os.ReadFile(*baseline)
+	if err != nil {
+		return fmt.Errorf("read baseline: %w", err)
+	}
+	baselineResults, err := readResults(baselineData)
+	if err != nil {
+		return fmt.Errorf("read baseline %s: %w", *baseline, err)
+	}
+	var currentData []byte
+	if len(args) == 1 {
+		currentData, err = os.ReadFile(args[0])
+	} else {
+		currentData, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("read current run: %w", err)
+	}
+	currentResults, err := readResults(currentData)
+	if err != nil {
+		return fmt.Errorf("read current run: %w", err)
+	}
+
+	movers := rankMovers(baselineResults, currentResults)
+	if *limit > 0 && len(movers) > *limit {
+		movers = movers[:*limit]
+	}
+	switch *format {
+	case "json":
+		versioned := make([]versionedMover, 0, len(movers))
+		for _, m := range movers {
+			versioned = append(versioned, versionedMover{SchemaVersion: pkgimporters.SchemaVersion, mover: m})
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(versioned)
+	case "csv":
+		return writeMoversCSV(stdout, movers)
+	default:
+		return writeMoversText(stdout, movers)
+	}
+}
+
+// rankMovers returns the packages fetched in both baseline and current whose rank changed,
+// ordered by the size of the change, largest first, then by path.
+func rankMovers(baseline, current []pkgimporters.Result) []mover {
+	baselineCounts, currentCounts := latestCounts(baseline), latestCounts(current)
+	baselineRanks, currentRanks := ranks(baselineCounts), ranks(currentCounts)
+
+	var movers []mover
+	for path, rank := range currentRanks {
+		baselineRank, ok := baselineRanks[path]
+		if !ok || rank == baselineRank {
+			continue
+		}
+		movers = append(movers, mover{
+			Path:          path,
+			BaselineRank:  baselineRank,
+			Rank:          rank,
+			Change:        baselineRank - rank,
+			BaselineCount: baselineCounts[path],
+			Count:         currentCounts[path],
+		})
+	}
+	slices.SortFunc(movers, func(a, b mover) int {
+		return cmp.Or(cmp.Compare(abs(b.Change), abs(a.Change)), cmp.Compare(a.Path, b.Path))
+	})
+	return movers
+}
+
+// latestCounts returns the counts of the fetched packages of results,
+// the count of the latest run if results hold several runs of a package.
+func latestCounts(results []pkgimporters.Result) map[string]int {
+	counts := make(map[string]int, len(results))
+	latest := make(map[string]pkgimporters.Result, len(results))
+	for _, r := range results {
+		if r.Status != pkgimporters.StatusOK {
+			continue
+		}
+		if l, ok := latest[r.Path]; ok && r.Time.Before(l.Time) {
+			continue
+		}
+		latest[r.Path] = r
+		counts[r.Path] = r.Count
+	}
+	return counts
+}
+
+// ranks returns the rank of each package by count, 1 for the highest;
+// packages with equal counts share the rank, and the next rank is skipped for each, e.g., 1, 2, 2, 4.
+func ranks(counts map[string]int) map[string]int {
+	paths := make([]string, 0, len(counts))
+	for path := range counts {
+		paths = append(paths, path)
+	}
+	slices.SortFunc(paths, func(a, b string) int {
+		return cmp.Compare(counts[b], counts[a])
+	})
+	ranks := make(map[string]int, len(paths))
+	for i, path := range paths {
+		if i > 0 && counts[path] == counts[paths[i-1]] {
+			ranks[path] = ranks[paths[i-1]]
+			continue
+		}
+		ranks[path] = i + 1
+	}
+	return ranks
+}
+
+func abs(n int) int {
+	return max(n, -n)
+}
+
+// writeMoversText writes movers as a table with their ranks, the change, and their counts.
+func writeMoversText(w io.Writer, movers []mover) error {
+	table := [][]string{{"package", "rank", "change", "count"}}
+	for _, m := range movers {
+		table = append(table, []string{
+			m.Path,
+			strconv.Itoa(m.BaselineRank) + " → " + strconv.Itoa(m.Rank),
+			fmt.Sprintf("%+d", m.Change),
+			pkgimporters.FormatCount(m.BaselineCount) + " → " + pkgimporters.FormatCount(m.Count),
+		})
+	}
+	widths := make([]int, len(table[0]))
+	for _, cells := range table {
+		for i, cell := range cells {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	var b strings.Builder
+	for _, cells := range table {
+		line := ""
+		for i, cell := range cells {
+			line += cell + strings.Repeat(" ", widths[i]-len([]rune(cell))+2)
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMoversCSV writes movers as CSV with a header row.
+func writeMoversCSV(w io.Writer, movers []mover) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"schema_version", "path", "baseline_rank", "rank", "change", "baseline_count", "count"}); err != nil {
+		return err
+	}
+	for _, m := range movers {
+		record := []string{strconv.Itoa(pkgimporters.SchemaVersion), m.Path, strconv.Itoa(m.BaselineRank), strconv.Itoa(m.Rank), strconv.Itoa(m.Change), strconv.Itoa(m.BaselineCount), strconv.Itoa(m.Count)}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestRankMovers(t *testing.T) {
+	ok := func(path string, count int) pkgimporters.Result {
+		return pkgimporters.Result{Path: path, Count: count, Status: pkgimporters.StatusOK}
+	}
+	baseline := []pkgimporters.Result{
+		ok("fmt", 5000), ok("errors", 3000), ok("strings", 3000), ok("io", 2000), ok("log/slog", 100), ok("container/ring", 50),
+	}
+	current := []pkgimporters.Result{
+		ok("fmt", 5500), ok("errors", 3100), ok("strings", 3300), ok("io", 2100), ok("log/slog", 4000), ok("maps", 900),
+		{Path: "container/ring", Status: pkgimporters.StatusNotFound, Error: "package not found"},
+	}
+
+	// Baseline ranks: fmt 1, errors and strings 2, io 4, log/slog 5, container/ring 6.
+	// Current ranks: fmt 1, log/slog 2, strings 3, errors 4, io 5, maps 6.
+	want := []mover{
+		{Path: "log/slog", BaselineRank: 5, Rank: 2, Change: 3, BaselineCount: 100, Count: 4000},
+		{Path: "errors", BaselineRank: 2, Rank: 4, Change: -2, BaselineCount: 3000, Count: 3100},
+		{Path: "io", BaselineRank: 4, Rank: 5, Change: -1, BaselineCount: 2000, Count: 2100},
+		{Path: "strings", BaselineRank: 2, Rank: 3, Change: -1, BaselineCount: 3000, Count: 3300},
+	}
+	if got := rankMovers(baseline, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestRunMovers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, results []pkgimporters.Result) string {
+		var buf bytes.Buffer
+		if err := (pkgimporters.JSONRenderer{}).Render(&buf, results); err != nil {
+			t.Fatal(err)
+		}
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	baseline := write("old.json", []pkgimporters.Result{
+		{Path: "fmt", Count: 5000, Status: pkgimporters.StatusOK},
+		{Path: "io", Count: 2000, Status: pkgimporters.StatusOK},
+		{Path: "log/slog", Count: 100, Status: pkgimporters.StatusOK},
+	})
+	// The current run is a CSV history, whose latest run is used.
+	current := filepath.Join(dir, "new.csv")
+	for i, counts := range [][]int{{5200, 2050, 1500}, {5500, 2100, 2500}} {
+		results := []pkgimporters.Result{
+			{Path: "fmt", Count: counts[0], Status: pkgimporters.StatusOK},
+			{Path: "io", Count: counts[1], Status: pkgimporters.StatusOK},
+			{Path: "log/slog", Count: counts[2], Status: pkgimporters.StatusOK},
+		}
+		if err := appendResults(current, "csv", time.Date(2024, 1, 1+i, 3, 0, 0, 0, time.UTC), results); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			args: []string{"-baseline", baseline, current},
+			want: "package   rank   change  count\n" +
+				"io        2 → 3  -1      2,000 → 2,100\n" +
+				"log/slog  3 → 2  +1      100 → 2,500\n",
+		},
+		{
+			args: []string{"-baseline", baseline, "-limit", "1", "-format", "csv", current},
+			want: "schema_version,path,baseline_rank,rank,change,baseline_count,count\n" +
+				"1,io,2,3,-1,2000,2100\n",
+		},
+	}
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		if err := runMovers(tt.args, &stdout, os.Stderr); err != nil {
+			t.Fatal(err)
+		}
+		if got := stdout.String(); got != tt.want {
+			t.Errorf("%v: expected:\n%s\ngot:\n%s", tt.args, tt.want, got)
+		}
+	}
+
+	for _, args := range [][]string{{current}, {"-baseline", baseline, "-limit", "-1", current}, {"-baseline", baseline, current, current}} {
+		var cmdErr *cmdError
+		if err := runMovers(args, &bytes.Buffer{}, &bytes.Buffer{}); !errors.As(err, &cmdErr) || cmdErr.code != 2 {
+			t.Errorf("%v: expected a usage error, got %v", args, err)
+		}
+	}
+	if err := runMovers([]string{"-baseline", filepath.Join(dir, "missing.json"), current}, &bytes.Buffer{}, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "read baseline") {
+		t.Errorf("expected a baseline read error, got %v", err)
+	}
+}
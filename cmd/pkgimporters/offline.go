@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// offlineCounts returns the cached counts of pkgPaths from an offline client in the order of pkgPaths.
+// Packages missing from the cache are left out of the results,
+// and both missing packages and stale counts are reported as warnings.
+func offlineCounts(ctx context.Context, client *pkgimporters.Client, logger *slog.Logger, pkgPaths []string) ([]pkgimporters.Result, error) {
+	byPath := make(map[string]pkgimporters.Result, len(pkgPaths))
+	for r, err := range client.Stream(ctx, pkgPaths) {
+		if err != nil && !errors.Is(err, pkgimporters.ErrNotCached) {
+			return nil, err
+		}
+		if err == nil {
+			byPath[r.Path] = r
+		}
+	}
+
+	var results []pkgimporters.Result
+	var stale, missing []string
+	for _, path := range pkgPaths {
+		r, ok := byPath[path]
+		switch {
+		case !ok:
+			missing = append(missing, path)
+			continue
+		case r.Stale:
+			stale = append(stale, path)
+		}
+		results = append(results, r)
+	}
+	if len(stale) > 0 {
+		logger.Warn("cached counts are stale", "count", len(stale), "pkgs", stale)
+	}
+	if len(missing) > 0 {
+		logger.Warn("packages missing from cache", "count", len(missing), "pkgs", missing)
+	}
+	return results, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestOfflineCounts(t *testing.T) {
+	ctx := t.Context()
+	cache := &pkgimporters.DiskCache{Dir: t.TempDir()}
+	if err := cache.Set(ctx, "fmt", pkgimporters.CacheEntry{Count: 7}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Set(ctx, "io", pkgimporters.CacheEntry{Count: 5}, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	client := &pkgimporters.Client{Source: mapSource{}, Cache: cache, Offline: true}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	got, err := offlineCounts(ctx, client, logger, []string{"io", "bufio", "fmt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []pkgimporters.Result{
+		{Path: "io", Count: 5, Status: pkgimporters.StatusOK, Stale: true},
+		{Path: "fmt", Count: 7, Status: pkgimporters.StatusOK},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for _, msg := range []string{`"cached counts are stale" count=1 pkgs=[io]`, `"packages missing from cache" count=1 pkgs=[bufio]`} {
+		if !strings.Contains(logs.String(), msg) {
+			t.Errorf("expected log %s, got:\n%s", msg, logs.String())
+		}
+	}
+}
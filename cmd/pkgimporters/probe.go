@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// probeGrowth is the factor by which probe increases the request rate after each step without throttling.
+	probeGrowth = 1.5
+
+	// probeMargin is the fraction of the highest rate without throttling suggested for -rps.
+	probeMargin = 0.8
+)
+
+// runProbe runs the probe subcommand, which increases the request rate to pkg.go.dev step by step
+// until it is throttled, and suggests -rps and -workers values below that rate.
+func runProbe(args []string, stdout, stderr io.Writer) (err error) {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("probe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	clientFlags := addClientFlags(fs)
+	startRPS := fs.Float64("start-rps", 1, "request `rate` of the first step, in requests per second")
+	maxRPS := fs.Float64("max-rps", 20, "request `rate` at which probing stops if it is not throttled")
+	stepRequests := fs.Int("step", 10, "number of requests made at each rate")
+	maxRequests := fs.Int("max-requests", 200, "maximum total number of requests")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s probe - measure the request rate pkg.go.dev allows\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s probe [-start-rps rate] [-max-rps rate] [-step N] [-max-requests N]\n"+
+			"        [-base-url URL] [-proxy URL] [-user-agent header] [-timeout duration] [packages]\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s probe requests the importedby tabs of the packages (default all standard library packages)\n"+
+			"    in steps of -step requests, starting at -start-rps and increasing the rate by half after each step,\n"+
+			"    until a response is throttled or blocked, -max-rps is reached, or -max-requests were made.\n"+
+			"    It stops at the first throttled response and suggests -rps and -workers values\n"+
+			"    below the highest rate that was not throttled.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEXAMPLES\n"+
+			"    %[1]s probe\n"+
+			"        Suggest -rps and -workers values for pkg.go.dev\n\n"+
+			"    %[1]s probe -base-url https://pkgsite.corp.example -max-rps 100\n"+
+			"        Probe a private pkgsite deployment up to 100 requests per second\n", progName)
+	}
+	args, err = parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *startRPS <= 0:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -start-rps value: %v (must be positive)", *startRPS)}
+	case *maxRPS < *startRPS:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -max-rps value: %v (must be at least -start-rps)", *maxRPS)}
+	case *stepRequests <= 0:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -step value: %d (must be positive)", *stepRequests)}
+	case *maxRequests < *stepRequests:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -max-requests value: %d (must be at least -step)", *maxRequests)}
+	}
+	pkgPaths := args
+	if len(pkgPaths) == 0 {
+		if pkgPaths, err = loadPackagePaths("std", loadOptions{}); err != nil {
+			return err
+		}
+	}
+	logger, err := newLogger(stderr, "text", false, false)
+	if err != nil {
+		return err
+	}
+	client, err := clientFlags.client(logger)
+	if err != nil {
+		return err
+	}
+	p := prober{
+		source:       &pkgimporters.PkgGoDev{HTTPClient: client.HTTPClient, BaseURL: client.BaseURL},
+		pkgPaths:     pkgPaths,
+		timeout:      client.Timeout,
+		startRPS:     *startRPS,
+		maxRPS:       *maxRPS,
+		stepRequests: *stepRequests,
+		maxRequests:  *maxRequests,
+	}
+	return p.run(context.Background(), stdout)
+}
+
+// prober measures the request rate a source allows before it throttles requests.
+type prober struct {
+	source       pkgimporters.Source
+	pkgPaths     []string // packages requested in turn
+	timeout      time.Duration
+	startRPS     float64
+	maxRPS       float64
+	stepRequests int
+	maxRequests  int
+}
+
+// probeStep is the outcome of the requests made at a rate.
+type probeStep struct {
+	throttled bool
+	latency   time.Duration // median latency of the responses that were not throttled
+}
+
+// run probes the source step by step, writing the outcome of each step and the suggested flags to w.
+func (p prober) run(ctx context.Context, w io.Writer) error {
+	next := 0
+	var allowed float64 // highest rate that was not throttled
+	var latency time.Duration
+	throttledAt := 0.0
+	for rps, requests := p.startRPS, 0; requests+p.stepRequests <= p.maxRequests; rps = math.Min(rps*probeGrowth, p.maxRPS) {
+		pkgPaths := make([]string, p.stepRequests)
+		for i := range pkgPaths {
+			pkgPaths[i] = p.pkgPaths[next%len(p.pkgPaths)]
+			next++
+		}
+		step, err := p.step(ctx, rps, pkgPaths)
+		if err != nil {
+			return err
+		}
+		requests += p.stepRequests
+		if step.throttled {
+			fmt.Fprintf(w, "%6.1f requests/s: throttled\n", rps)
+			throttledAt = rps
+			break
+		}
+		fmt.Fprintf(w, "%6.1f requests/s: %d requests, median latency %v\n", rps, p.stepRequests, step.latency.Round(time.Millisecond))
+		allowed, latency = rps, step.latency
+		if rps >= p.maxRPS {
+			break
+		}
+	}
+
+	switch {
+	case allowed == 0:
+		fmt.Fprintf(w, "Throttled at the starting rate of %.1f requests/s; probe again with a lower -start-rps.\n", p.startRPS)
+		return nil
+	case throttledAt > 0:
+		fmt.Fprintf(w, "Throttling began at %.1f requests/s.\n", throttledAt)
+	default:
+		fmt.Fprintf(w, "Not throttled up to %.1f requests/s; the upstream may allow more.\n", allowed)
+	}
+	// The suggested rate is rounded to two significant digits.
+	rps, _ := strconv.ParseFloat(strconv.FormatFloat(allowed*probeMargin, 'g', 2, 64), 64)
+	// Sustaining rps requests of the median latency takes rps × latency concurrent requests.
+	workers := max(1, int(math.Ceil(rps*latency.Seconds())))
+	_, err := fmt.Fprintf(w, "Suggested flags: -rps %v -workers %d\n", rps, workers)
+	return err
+}
+
+// step requests pkgPaths at rps, concurrently if a request takes longer than the interval between requests.
+// A throttled or blocked response ends the step early without making further requests.
+// It returns an error if a request fails for another reason than an unknown package or an unparsable page.
+func (p prober) step(ctx context.Context, rps float64, pkgPaths []string) (probeStep, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	limiter := rate.NewLimiter(rate.Limit(rps), 1)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		latencies []time.Duration
+		throttled bool
+		failure   error
+	)
+	for _, pkgPath := range pkgPaths {
+		if err := limiter.Wait(ctx); err != nil {
+			break
+		}
+		wg.Go(func() {
+			reqCtx, reqCancel := context.WithTimeout(ctx, p.timeout)
+			defer reqCancel()
+			start := time.Now()
+			_, err := p.source.Count(reqCtx, pkgPath)
+			elapsed := time.Since(start)
+
+			mu.Lock()
+			defer mu.Unlock()
+			var statusErr *pkgimporters.StatusError
+			switch {
+			case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode == http.StatusServiceUnavailable),
+				errors.Is(err, pkgimporters.ErrBlocked):
+				throttled = true
+				cancel()
+			case err == nil, errors.Is(err, pkgimporters.ErrNotFound), errors.Is(err, pkgimporters.ErrParse):
+				latencies = append(latencies, elapsed)
+			case ctx.Err() == nil && failure == nil:
+				failure = fmt.Errorf("probe %s: %w", pkgPath, err)
+				cancel()
+			}
+		})
+	}
+	wg.Wait()
+	if throttled {
+		return probeStep{throttled: true}, nil
+	}
+	if failure != nil {
+		return probeStep{}, failure
+	}
+	if err := ctx.Err(); err != nil && len(latencies) < len(pkgPaths) {
+		return probeStep{}, err
+	}
+	slices.Sort(latencies)
+	return probeStep{latency: latencies[len(latencies)/2]}, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+	"github.com/alexandear/pkgimporters/pkgimporterstest"
+)
+
+// newThrottlingServer returns a server of importedby tabs that responds with 429 Too Many Requests
+// once it served limit requests, or never if limit is 0.
+func newThrottlingServer(t *testing.T, limit int64) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := requests.Add(1); limit > 0 && n > limit {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write(pkgimporterstest.ImportedByPage(strings.TrimPrefix(r.URL.Path, "/"), pkgimporterstest.Package{Importers: 42}))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestProber(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		limit        int64
+		want         []string
+		wantRequests int64
+	}{
+		{
+			name:  "throttled",
+			limit: 12,
+			want: []string{
+				"  50.0 requests/s: 4 requests, median latency ",
+				"  75.0 requests/s: 4 requests, median latency ",
+				" 112.5 requests/s: 4 requests, median latency ",
+				" 168.8 requests/s: throttled\n",
+				"Throttling began at 168.8 requests/s.\nSuggested flags: -rps 90 -workers ",
+			},
+			wantRequests: 13,
+		},
+		{
+			name:  "throttled at the start",
+			limit: 2,
+			want: []string{
+				"  50.0 requests/s: throttled\n",
+				"Throttled at the starting rate of 50.0 requests/s; probe again with a lower -start-rps.\n",
+			},
+			wantRequests: 3,
+		},
+		{
+			name: "not throttled",
+			want: []string{
+				" 200.0 requests/s: 4 requests, median latency ",
+				"Not throttled up to 200.0 requests/s; the upstream may allow more.\nSuggested flags: -rps 160 -workers ",
+			},
+			wantRequests: 20,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newThrottlingServer(t, tt.limit)
+			p := prober{
+				source:       &pkgimporters.PkgGoDev{HTTPClient: srv.Client(), BaseURL: srv.URL},
+				pkgPaths:     []string{"fmt", "io", "os"},
+				timeout:      time.Minute,
+				startRPS:     50,
+				maxRPS:       200,
+				stepRequests: 4,
+				maxRequests:  40,
+			}
+			var b strings.Builder
+			if err := p.run(context.Background(), &b); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, b.String())
+				}
+			}
+			// Requests in flight when the first response is throttled may still be made.
+			if got := requests.Load(); got < tt.wantRequests || got > tt.wantRequests+int64(p.stepRequests) {
+				t.Errorf("expected about %d requests, got %d", tt.wantRequests, got)
+			}
+		})
+	}
+}
+
+func TestProberFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	p := prober{
+		source:       &pkgimporters.PkgGoDev{HTTPClient: srv.Client(), BaseURL: srv.URL},
+		pkgPaths:     []string{"fmt"},
+		timeout:      time.Minute,
+		startRPS:     50,
+		maxRPS:       50,
+		stepRequests: 2,
+		maxRequests:  2,
+	}
+	if err := p.run(context.Background(), io.Discard); err == nil || !strings.Contains(err.Error(), "probe fmt: ") {
+		t.Errorf("expected an error for a failed request, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartProfiling(t *testing.T) {
+	dir := t.TempDir()
+	cpuFile, memFile := filepath.Join(dir, "cpu.pprof"), filepath.Join(dir, "mem.pprof")
+
+	stop, err := startProfiling(cpuFile, memFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{cpuFile, memFile} {
+		if fi, err := os.Stat(file); err != nil || fi.Size() == 0 {
+			t.Errorf("expected a non-empty profile %s, got %v", file, err)
+		}
+	}
+
+	if _, err := startProfiling(filepath.Join(dir, "missing", "cpu.pprof"), ""); err == nil {
+		t.Error("expected an error for an uncreatable CPU profile")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressInterval is the minimum time between redraws of the progress bar.
+const progressInterval = 100 * time.Millisecond
+
+// progress draws a progress bar with the number of completed packages,
+// the rate, and the estimated time remaining on a single terminal line.
+type progress struct {
+	w     io.Writer
+	total int
+	start time.Time
+	now   func() time.Time
+
+	mu    sync.Mutex
+	done  int
+	drawn time.Time
+}
+
+func newProgress(w io.Writer, total int) *progress {
+	p := &progress{w: w, total: total, start: time.Now(), now: time.Now}
+	p.mu.Lock()
+	p.draw()
+	p.mu.Unlock()
+	return p
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// add records a completed package and redraws the bar,
+// at most every progressInterval unless all packages are done.
+func (p *progress) add() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if p.done < p.total && p.now().Sub(p.drawn) < progressInterval {
+		return
+	}
+	p.draw()
+}
+
+// clear erases the bar, e.g., before other output is written to the terminal.
+// The bar is drawn again by the next add.
+func (p *progress) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprint(p.w, "\r\x1b[K")
+	p.drawn = time.Time{}
+}
+
+func (p *progress) draw() {
+	p.drawn = p.now()
+	fmt.Fprint(p.w, "\r\x1b[K"+p.line(p.drawn.Sub(p.start)))
+}
+
+// line returns the bar after elapsed time, e.g.,
+// "[=========>          ]  120/250  2.0/s  ETA 1m05s".
+func (p *progress) line(elapsed time.Duration) string {
+	const width = 20
+	filled := width
+	if p.total > 0 {
+		filled = width * p.done / p.total
+	}
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+
+	rate, eta := 0.0, "--"
+	if p.done > 0 && elapsed > 0 {
+		rate = float64(p.done) / elapsed.Seconds()
+		remaining := time.Duration(float64(p.total-p.done) / rate * float64(time.Second))
+		eta = remaining.Round(time.Second).String()
+	}
+	return fmt.Sprintf("[%s] %*d/%d  %.1f/s  ETA %s", bar, len(fmt.Sprint(p.total)), p.done, p.total, rate, eta)
+}
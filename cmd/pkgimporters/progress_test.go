@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressLine(t *testing.T) {
+	tests := []struct {
+		done    int
+		elapsed time.Duration
+		want    string
+	}{
+		{done: 0, elapsed: 0, want: "[>                   ]   0/250  0.0/s  ETA --"},
+		{done: 120, elapsed: time.Minute, want: "[=========>          ] 120/250  2.0/s  ETA 1m5s"},
+		{done: 250, elapsed: 2 * time.Minute, want: "[====================] 250/250  2.1/s  ETA 0s"},
+	}
+	for _, tt := range tests {
+		p := &progress{total: 250, done: tt.done}
+		if got := p.line(tt.elapsed); got != tt.want {
+			t.Errorf("line(%d, %v) = %q, want %q", tt.done, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestProgressAdd(t *testing.T) {
+	var b strings.Builder
+	now := time.Now()
+	p := &progress{w: &b, total: 3, start: now, now: func() time.Time { return now }}
+
+	p.add()
+	p.add()
+	if got := strings.Count(b.String(), "\r"); got != 1 {
+		t.Errorf("expected 1 draw within the interval, got %d: %q", got, b.String())
+	}
+	p.add()
+	if !strings.HasSuffix(b.String(), "3/3  0.0/s  ETA --") {
+		t.Errorf("expected the completed bar to be drawn, got %q", b.String())
+	}
+}
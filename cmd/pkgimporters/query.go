@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// maxQueryPackages caps the packages of a query, so a single request cannot make unbounded requests to pkg.go.dev.
+const maxQueryPackages = 100
+
+// queryRequest is the body of a POST /api/query request.
+type queryRequest struct {
+	Packages []string `json:"packages"`
+	History  bool     `json:"history"`
+}
+
+// queryResult is the result of a package in the response to a query: its servedResult, with Error set if it failed,
+// and its history, oldest first, if the query asks for it and the server has one.
+type queryResult struct {
+	servedResult
+	History []historyPoint `json:"history,omitzero"`
+}
+
+// handleQuery responds with the queryResults of the packages of a queryRequest as a JSON array in their order,
+// fetching them in one batch, or with 400 Bad Request if the query is invalid or has more than maxQueryPackages packages.
+// Packages that cannot be fetched are reported in their results, like in the JSON output format.
+func (s *server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req queryRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid query: %w", err))
+		return
+	}
+	var pkgPaths []string
+	seen := make(map[string]bool)
+	for _, pkgPath := range req.Packages {
+		pkgPath = normalizePackagePath(pkgPath)
+		if !seen[pkgPath] {
+			seen[pkgPath] = true
+			pkgPaths = append(pkgPaths, pkgPath)
+		}
+	}
+	switch {
+	case len(pkgPaths) == 0:
+		writeJSONError(w, http.StatusBadRequest, errors.New("query has no packages"))
+		return
+	case len(pkgPaths) > maxQueryPackages:
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("query has %d packages, more than the maximum of %d", len(pkgPaths), maxQueryPackages))
+		return
+	}
+	if err := validatePackages(pkgPaths); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	var history []pkgimporters.Result
+	if req.History && s.history != "" {
+		var err error
+		if history, err = readHistory(s.history); err != nil {
+			s.logger.Error("reading history failed", "err", err)
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	served, errs := s.lookupAll(r.Context(), pkgPaths)
+	results := make([]queryResult, len(served))
+	for i, result := range served {
+		results[i].servedResult = result
+		if err := errs[i]; err != nil {
+			var pkgErr *pkgimporters.PackageError
+			if errors.As(err, &pkgErr) {
+				err = pkgErr.Err
+			}
+			results[i].Error = err.Error()
+		}
+		if history != nil {
+			results[i].History = historyPoints(history, result.Path)
+		}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestServerQuery(t *testing.T) {
+	var requests atomic.Int32
+	source := sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+		requests.Add(1)
+		switch pkgPath {
+		case "fmt":
+			return 5400000, nil
+		case "io":
+			return 3000000, nil
+		}
+		return 0, pkgimporters.ErrNotFound
+	})
+	client := &pkgimporters.Client{Source: source, RequestsPerSecond: 1000, Burst: 10, Workers: 4}
+	srv := newServer(client, &pkgimporters.MemoryCache{}, time.Hour, slog.New(slog.DiscardHandler))
+	srv.query = true
+	srv.history = writeHistory(t, "csv", []map[string]int{{"fmt": 5000000}, {"fmt": 5200000}})
+	ts := httptest.NewServer(srv.handler())
+	defer ts.Close()
+
+	post := func(body string) (*http.Response, []byte) {
+		t.Helper()
+		resp, err := http.Post(ts.URL+"/api/query", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, data
+	}
+
+	resp, body := post(`{"packages": ["fmt", "io", "example.com/unknown", "fmt"], "history": true}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %s: %s", resp.Status, body)
+	}
+	var results []queryResult
+	if err := json.Unmarshal(body, &results); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, fmt.Sprintf("%s %d %s %q history:%d", r.Path, r.Count, r.Status, r.Error, len(r.History)))
+	}
+	want := []string{
+		`fmt 5400000 OK "" history:2`,
+		`io 3000000 OK "" history:0`,
+		`example.com/unknown 0 NOT_FOUND "package not found" history:0`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got results\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("expected one upstream request per unique package, got %d", n)
+	}
+	if results[0].History[1].Count != 5200000 || results[1].History == nil {
+		t.Errorf("expected the history of fmt and an empty history of io, got %+v and %+v", results[0].History, results[1].History)
+	}
+
+	_, body = post(`{"packages": ["fmt"]}`)
+	if strings.Contains(string(body), `"history"`) {
+		t.Errorf("expected no history unless asked for, got %s", body)
+	}
+
+	tooMany := make([]string, maxQueryPackages+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("example.com/p%d", i)
+	}
+	tooManyBody, err := json.Marshal(queryRequest{Packages: tooMany})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests.Store(0)
+	for _, tt := range []struct {
+		body, wantErr string
+	}{
+		{`{"packages": []}`, "query has no packages"},
+		{`{"pkgs": ["fmt"]}`, "invalid query: json: unknown field"},
+		{`{"packages": ["example.com/a:b"]}`, "example.com/a:b"},
+		{string(tooManyBody), "query has 101 packages, more than the maximum of 100"},
+	} {
+		resp, body := post(tt.body)
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), tt.wantErr) {
+			t.Errorf("%.40s: expected 400 Bad Request with %q, got %s: %s", tt.body, tt.wantErr, resp.Status, body)
+		}
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("expected no upstream requests for invalid queries, got %d", n)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alexandear/pkgimporters"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// internalCommands are developer-facing subcommands of "pkgimporters internal",
+// which are not part of the supported interface.
+var internalCommands = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"refresh-testdata": runRefreshTestdata,
+}
+
+// runInternal runs the internal command named by the first argument.
+func runInternal(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return &cmdError{code: 2, msg: "usage: pkgimporters internal " + strings.Join(slices.Sorted(maps.Keys(internalCommands)), "|") + " [flags]"}
+	}
+	command, ok := internalCommands[args[0]]
+	if !ok {
+		return &cmdError{code: 2, msg: fmt.Sprintf("unknown internal command %q", args[0])}
+	}
+	return command(args[1:], stdout, stderr)
+}
+
+// runRefreshTestdata runs "internal refresh-testdata", which re-downloads the importedby tabs
+// of the packages listed in the counts.json file of the testdata directory,
+// saves them as the HTML fixtures of the parser tests, and updates their expected counts in counts.json.
+// The importer lists are trimmed so the fixtures stay small.
+func runRefreshTestdata(args []string, stdout, stderr io.Writer) error {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("internal refresh-testdata", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	clientFlags := addClientFlags(fs)
+	dir := fs.String("dir", "testdata", "testdata `directory` holding counts.json and the fixtures")
+	maxImporters := fs.Int("max-importers", 50, "keep at most `N` importers listed in each fixture")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s internal refresh-testdata - refresh the pkg.go.dev fixtures of the parser tests\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s internal refresh-testdata [-base-url URL] [-proxy URL] [-user-agent header] [-timeout duration] [-dir directory] [-max-importers N]\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s internal refresh-testdata downloads the importedby tab of every package in counts.json\n"+
+			"    to a fixture named after the package, e.g., io.html, and records the count parsed from it in counts.json,\n"+
+			"    keeping the parser tests current as pkg.go.dev evolves. It is meant for developers of %[1]s.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+	}
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return &cmdError{code: 2, msg: "internal refresh-testdata takes no arguments; add packages to counts.json"}
+	}
+	if *maxImporters <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -max-importers value: %d (must be positive)", *maxImporters)}
+	}
+	logger, err := newLogger(stderr, "text", false, false)
+	if err != nil {
+		return err
+	}
+	client, err := clientFlags.client(logger)
+	if err != nil {
+		return err
+	}
+
+	countsFile := filepath.Join(*dir, "counts.json")
+	data, err := os.ReadFile(countsFile)
+	if err != nil {
+		return err
+	}
+	var counts map[string]int
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return fmt.Errorf("decode %s: %w", countsFile, err)
+	}
+
+	var page []byte
+	client.Middleware = []pkgimporters.Middleware{capturePage(&page)}
+	for _, pkgPath := range slices.Sorted(maps.Keys(counts)) {
+		count, err := client.ImporterCount(context.Background(), pkgPath)
+		if err != nil {
+			return err
+		}
+		fixture, err := trimImporters(page, *maxImporters)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pkgPath, err)
+		}
+		name := filepath.Join(*dir, filepath.FromSlash(pkgPath)+".html")
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, fixture, 0o644); err != nil {
+			return err
+		}
+		if count != counts[pkgPath] {
+			fmt.Fprintf(stdout, "%s: %d -> %d\n", pkgPath, counts[pkgPath], count)
+		}
+		counts[pkgPath] = count
+	}
+
+	data, err = json.MarshalIndent(counts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(countsFile, append(data, '\n'), 0o644)
+}
+
+// capturePage returns a middleware that stores the body of the last successful response in page.
+func capturePage(page *[]byte) pkgimporters.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return pkgimporters.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp.StatusCode != http.StatusOK {
+				return resp, err
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			*page = body
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			return resp, nil
+		})
+	}
+}
+
+// trimImporters returns the importedby tab page with all but the first n listed importers removed.
+// The heading still states how many packages pkg.go.dev displays.
+func trimImporters(page []byte, n int) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+	var remove []*html.Node
+	kept := 0
+	for node := range doc.Descendants() {
+		if node.DataAtom != atom.Li || !inImportedBy(node) {
+			continue
+		}
+		if kept < n {
+			kept++
+			continue
+		}
+		remove = append(remove, node)
+	}
+	for _, node := range remove {
+		node.Parent.RemoveChild(node)
+	}
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// inImportedBy reports whether n is within the ImportedBy section of an importedby tab.
+func inImportedBy(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		for _, a := range p.Attr {
+			if a.Key == "class" && slices.Contains(strings.Fields(a.Val), "ImportedBy") {
+				return true
+			}
+		}
+	}
+	return false
+}
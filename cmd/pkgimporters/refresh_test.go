@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexandear/pkgimporters/pkgimporterstest"
+)
+
+func TestRunRefreshTestdata(t *testing.T) {
+	srv := pkgimporterstest.NewServer()
+	defer srv.Close()
+	var importers []string
+	for i := range 100 {
+		importers = append(importers, fmt.Sprintf("example.com/importer%d", i))
+	}
+	srv.SetPackage("io", pkgimporterstest.Package{Importers: 1600000, ImporterPaths: importers})
+	srv.SetPackage("example.com/lib", pkgimporterstest.Package{Importers: 7, ImporterPaths: importers[:7]})
+
+	dir := t.TempDir()
+	counts := filepath.Join(dir, "counts.json")
+	if err := os.WriteFile(counts, []byte(`{"io": 1533321, "example.com/lib": 7}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	if err := runInternal([]string{"refresh-testdata", "-base-url", srv.URL, "-dir", dir, "-max-importers", "10"}, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "io: 1533321 -> 1600000\n"; stdout.String() != want {
+		t.Errorf("expected changed counts:\n%s\ngot:\n%s", want, stdout.String())
+	}
+	want := "{\n  \"example.com/lib\": 7,\n  \"io\": 1600000\n}\n"
+	if got, err := os.ReadFile(counts); err != nil || string(got) != want {
+		t.Errorf("expected counts.json:\n%s\ngot (err %v):\n%s", want, err, got)
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "io.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(page), "<li>"); n != 10 || !strings.Contains(string(page), "1,600,000") {
+		t.Errorf("expected a fixture with the count and 10 importers, got %d importers:\n%s", n, page)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "example.com", "lib.html")); err != nil {
+		t.Error(err)
+	}
+
+	if err := runInternal([]string{"refresh"}, io.Discard, io.Discard); err == nil {
+		t.Error("expected an error for an unknown internal command")
+	}
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+	"github.com/alexandear/pkgimporters/prommetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// indexHTML is the web UI of the server, looking up counts with its API.
+//
+//go:embed serve.html
+var indexHTML []byte
+
+// servedResult is the response of the serve API for a package: its result in the JSON output format,
+// with when its count was fetched and its age in seconds, so clients can tell cached counts from fresh ones.
+type servedResult struct {
+	SchemaVersion int `json:"schema_version"`
+	pkgimporters.Result
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+	Age       int       `json:"age"`
+}
+
+// server answers importer count requests over HTTP, caching the counts for ttl,
+// so many clients behind it do not each reach pkg.go.dev.
+type server struct {
+	client *pkgimporters.Client
+	cache  pkgimporters.StaleCache
+	ttl    time.Duration
+	logger *slog.Logger
+
+	// stale serves the expired counts of cache when the upstream fails.
+	stale *pkgimporters.Client
+
+	// history is the file of a history written with -append, charted by the web UI, or empty.
+	history string
+
+	// query enables the query endpoint.
+	query bool
+
+	// registry holds the metrics served at /metrics: those of client, of the HTTP requests to the server,
+	// and the last served count of each package.
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	counts   *prometheus.GaugeVec
+
+	// maxCounts caps the packages with a count in counts, so clients cannot create unbounded series.
+	maxCounts int
+	countsMu  sync.Mutex
+	counted   map[string]bool
+}
+
+// defaultMaxCounts is the number of packages whose last served count is exported by default.
+const defaultMaxCounts = 1000
+
+// newServer returns a server fetching counts with client and caching them in cache for ttl.
+func newServer(client *pkgimporters.Client, cache pkgimporters.StaleCache, ttl time.Duration, logger *slog.Logger) *server {
+	metrics := prommetrics.New()
+	client.Cache, client.CacheTTL, client.Metrics = cache, ttl, metrics
+	s := &server{
+		client:   client,
+		cache:    cache,
+		ttl:      ttl,
+		logger:   logger,
+		stale:    &pkgimporters.Client{Cache: cache, Offline: true},
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pkgimporters_http_requests_total",
+			Help: "Number of HTTP requests to the server by handler and status code.",
+		}, []string{"handler", "code"}),
+		counts: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pkgimporters_package_importers",
+			Help: "Last served importer count of each requested package, for up to 1000 packages.",
+		}, []string{"package"}),
+		maxCounts: defaultMaxCounts,
+		counted:   make(map[string]bool),
+	}
+	s.registry.MustRegister(metrics, s.requests, s.counts,
+		collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return s
+}
+
+// handler returns the HTTP handler of the server's endpoints.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	s.handle(mux, "GET /api/importers/{pkg...}", "importers", http.HandlerFunc(s.handleImporters))
+	s.handle(mux, "GET /{$}", "index", http.HandlerFunc(s.handleIndex))
+	if s.history != "" {
+		s.handle(mux, "GET /api/history/{pkg...}", "history", http.HandlerFunc(s.handleHistory))
+	}
+	s.handle(mux, "GET /badge/{pkg...}", "badge", http.HandlerFunc(s.handleBadge))
+	if s.query {
+		s.handle(mux, "POST /api/query", "query", http.HandlerFunc(s.handleQuery))
+	}
+	s.handle(mux, "GET /metrics", "metrics", promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// handle registers h for pattern on mux, counting its requests under the handler label name.
+func (s *server) handle(mux *http.ServeMux, pattern, name string, h http.Handler) {
+	mux.Handle(pattern, promhttp.InstrumentHandlerCounter(s.requests.MustCurryWith(prometheus.Labels{"handler": name}), h))
+}
+
+// handleIndex responds with the web UI.
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(indexHTML)
+}
+
+// historyPoint is a count of a package in the history, as served by /api/history.
+type historyPoint struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+// handleHistory responds with the counts of a package in the history, oldest first, as JSON.
+// The history is read on each request, so runs appended to it while the server runs are served.
+func (s *server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	pkgPath := normalizePackagePath(r.PathValue("pkg"))
+	results, err := readHistory(s.history)
+	if err != nil {
+		s.logger.Error("reading history failed", "err", err)
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, historyPoints(results, pkgPath))
+}
+
+// historyPoints returns the counts of pkgPath in the history results, oldest first.
+func historyPoints(results []pkgimporters.Result, pkgPath string) []historyPoint {
+	points := []historyPoint{}
+	for _, row := range historyRows(results, []string{pkgPath}, time.Time{}, time.Time{}) {
+		points = append(points, historyPoint{Time: row.Time, Count: row.Counts[pkgPath]})
+	}
+	return points
+}
+
+// handleImporters responds with the servedResult of a package as JSON,
+// with Age and Cache-Control headers telling caches in front of the server how long the count stays fresh.
+func (s *server) handleImporters(w http.ResponseWriter, r *http.Request) {
+	pkgPath := normalizePackagePath(r.PathValue("pkg"))
+	if err := validatePackages([]string{pkgPath}); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := s.lookup(r.Context(), pkgPath)
+	if err != nil {
+		writeJSONError(w, lookupErrorStatus(err), err)
+		return
+	}
+	s.setCacheHeaders(w, result)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// setCacheHeaders sets the Age and Cache-Control headers of a response with result
+// and returns the seconds it stays fresh, or 0 if it must not be cached as it is stale or expired.
+func (s *server) setCacheHeaders(w http.ResponseWriter, result servedResult) int {
+	w.Header().Set("Age", strconv.Itoa(result.Age))
+	maxAge := int((s.ttl - time.Duration(result.Age)*time.Second).Seconds())
+	if maxAge <= 0 || result.Stale {
+		w.Header().Set("Cache-Control", "no-cache")
+		return 0
+	}
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+	return maxAge
+}
+
+// lookup returns the result for pkgPath, served from the cache while it is fresh.
+// If the upstream fails for a reason other than an unknown package, an expired count is served with Stale set.
+func (s *server) lookup(ctx context.Context, pkgPath string) (servedResult, error) {
+	results, errs := s.lookupAll(ctx, []string{pkgPath})
+	return results[0], errs[0]
+}
+
+// lookupAll looks up the unique packages pkgPaths like lookup, in one batch fetched by the workers of the client,
+// and returns their results and errors in the order of pkgPaths. The results of failed lookups have Path and Status set.
+func (s *server) lookupAll(ctx context.Context, pkgPaths []string) ([]servedResult, []error) {
+	index := make(map[string]int, len(pkgPaths))
+	for i, pkgPath := range pkgPaths {
+		index[pkgPath] = i
+	}
+	results := make([]servedResult, len(pkgPaths))
+	errs := make([]error, len(pkgPaths))
+	for r, err := range s.client.Stream(ctx, pkgPaths) {
+		i := index[r.Path]
+		results[i], errs[i] = s.served(ctx, r, err)
+	}
+	// Packages are missing from the stream if ctx is done.
+	for i, pkgPath := range pkgPaths {
+		if results[i].Path == "" {
+			err := cmp.Or(ctx.Err(), context.Canceled)
+			results[i] = servedResult{SchemaVersion: pkgimporters.SchemaVersion, Result: pkgimporters.Result{Path: pkgPath, Status: pkgimporters.StatusOf(err)}}
+			errs[i] = err
+		}
+	}
+	return results, errs
+}
+
+// served returns the servedResult of r, the result of a lookup that failed with err if it is non-nil,
+// serving the expired count of the package instead if the upstream failed.
+func (s *server) served(ctx context.Context, r pkgimporters.Result, err error) (servedResult, error) {
+	if err != nil && !errors.Is(err, pkgimporters.ErrNotFound) && ctx.Err() == nil {
+		if stale, staleErr := s.stale.ImporterCounts(ctx, []string{r.Path}); staleErr == nil {
+			s.logger.Warn("serving stale count", "pkg", r.Path, "err", err)
+			r, err = stale[0], nil
+		}
+	}
+	result := servedResult{SchemaVersion: pkgimporters.SchemaVersion, Result: r}
+	if err != nil {
+		return result, err
+	}
+	s.recordCount(r.Path, r.Count)
+	entry, ok, err := s.cache.GetStale(ctx, r.Path)
+	if err != nil {
+		return result, err
+	}
+	if ok {
+		result.FetchedAt = entry.FetchedAt
+		result.Age = int(time.Since(entry.FetchedAt).Seconds())
+	}
+	return result, nil
+}
+
+// recordCount sets the exported count of pkgPath, a package that resolved successfully,
+// unless the counts of maxCounts other packages are already exported; those are kept until the server stops.
+func (s *server) recordCount(pkgPath string, count int) {
+	s.countsMu.Lock()
+	defer s.countsMu.Unlock()
+	if !s.counted[pkgPath] {
+		if len(s.counted) >= s.maxCounts {
+			return
+		}
+		s.counted[pkgPath] = true
+	}
+	s.counts.WithLabelValues(pkgPath).Set(float64(count))
+}
+
+// lookupErrorStatus returns the HTTP status of a failed lookup:
+// 404 Not Found for unknown packages and 502 Bad Gateway if the upstream failed.
+func lookupErrorStatus(err error) int {
+	if errors.Is(err, pkgimporters.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadGateway
+}
+
+// writeJSON writes v as the JSON response with status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeJSONError writes err as a JSON response with status, e.g., {"error": "package not found"}.
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// runServe runs the serve subcommand, which answers importer count requests over HTTP.
+func runServe(args []string, stdout, stderr io.Writer) error {
+	progName := filepath.Base(os.Args[0])
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	clientFlags := addClientFlags(fs)
+	rateFlags := addRateFlags(fs)
+	addr := fs.String("addr", "localhost:8080", "`address` to listen on, host:port")
+	cacheDir := fs.String("cache-dir", "", "`dir`ectory caching counts across restarts of the server (default: in memory)")
+	cacheTTL := fs.Duration("cache-ttl", pkgimporters.DefaultCacheTTL, "how long fetched counts are served from the cache")
+	historyFile := fs.String("history", "", "history `file` written with -o file -append, charted by the web UI and queried with -query")
+	withLicense := fs.Bool("with-license", false, "also serve the license of each package from its pkg.go.dev page, with a second request per package")
+	withVersion := fs.Bool("with-version", false, "also serve the latest version of each package and when it was published, from its pkg.go.dev page")
+	query := fs.Bool("query", false, "serve POST /api/query, returning the results and history of up to 100 packages in one request")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "NAME\n"+
+			"    %[1]s serve - serve importer counts over HTTP\n\n"+
+			"SYNOPSIS\n"+
+			"    %[1]s serve [-addr host:port] [-cache-dir dir] [-cache-ttl duration] [-history file] [-query]\n"+
+			"        [-with-license] [-with-version] [-rps rate] [-burst N] [-workers N] [-base-url URL] [-proxy URL]\n"+
+			"        [-user-agent header] [-timeout duration]\n\n"+
+			"DESCRIPTION\n"+
+			"    %[1]s serve answers GET /api/importers/<package> with the result of the package in the JSON\n"+
+			"    output format, with fetched_at and age, the seconds since its count was fetched.\n"+
+			"    Counts are cached for -cache-ttl, so many clients, such as CI jobs, make one request to pkg.go.dev\n"+
+			"    per package and period; if pkg.go.dev fails, an expired count is served with stale set.\n"+
+			"    Requests to pkg.go.dev are limited by -rps and -burst like those of the main command.\n"+
+			"    GET /metrics serves Prometheus metrics: the requests to the server and to pkg.go.dev, the latency\n"+
+			"    of pkg.go.dev, the cache hit ratio, and the last served count of up to 1000 packages.\n"+
+			"    GET / serves a web UI looking up packages in a sortable, filterable table; with -history, it charts\n"+
+			"    the counts of a package over time, also served as JSON by GET /api/history/<package>.\n"+
+			"    GET /badge/<package> serves a shields.io endpoint badge of the count, e.g., 184k, and\n"+
+			"    GET /badge/<package>.svg the rendered badge; the label parameter replaces the importers label.\n"+
+			"    With -query, POST /api/query answers {\"packages\": [...], \"history\": true} with the results of up to\n"+
+			"    100 packages, fetched in one batch, and their history with -history, so dashboards need one request;\n"+
+			"    licenses and versions are included with -with-license and -with-version.\n\n"+
+			"OPTIONS\n", progName)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nEXAMPLES\n"+
+			"    %[1]s serve -addr :8080 -cache-ttl 24h\n"+
+			"        Serve counts on port 8080, fetching each package at most once a day\n\n"+
+			"    curl http://localhost:8080/api/importers/github.com/spf13/cobra\n"+
+			"        Get the count of a package from a running server\n\n"+
+			"    %[1]s serve -query -with-version -history history.csv\n"+
+			"    curl -d '{\"packages\": [\"fmt\", \"io\"], \"history\": true}' http://localhost:8080/api/query\n"+
+			"        Get the counts, versions, and history of packages in one request\n", progName)
+	}
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return &cmdError{code: 2, msg: "serve takes no arguments; use serve -h for help"}
+	}
+	if *cacheTTL <= 0 {
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -cache-ttl value: %v (must be positive)", *cacheTTL)}
+	}
+	if *historyFile != "" {
+		if _, err := readHistory(*historyFile); err != nil {
+			return err
+		}
+	}
+	logger, err := newLogger(stderr, "text", false, false)
+	if err != nil {
+		return err
+	}
+	client, err := clientFlags.client(logger)
+	if err != nil {
+		return err
+	}
+	if err := rateFlags.apply(client); err != nil {
+		return err
+	}
+	if *withLicense || *withVersion {
+		client.Source = &pkgimporters.PkgGoDev{HTTPClient: client.HTTPClient, BaseURL: client.BaseURL, License: *withLicense, Version: *withVersion}
+	}
+	var cache pkgimporters.StaleCache = &pkgimporters.MemoryCache{}
+	if *cacheDir != "" {
+		cache = &pkgimporters.DiskCache{Dir: *cacheDir}
+	}
+	srv := newServer(client, cache, *cacheTTL, logger)
+	srv.history, srv.query = *historyFile, *query
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	httpServer := &http.Server{Handler: srv.handler(), ReadHeaderTimeout: 10 * time.Second}
+	fmt.Fprintf(stdout, "serving on http://%s\n", ln.Addr())
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- httpServer.Serve(ln)
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestServerImporters(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	source := sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+		requests.Add(1)
+		switch {
+		case failing.Load():
+			return 0, errors.New("upstream down")
+		case pkgPath == "github.com/spf13/cobra":
+			return 184231, nil
+		}
+		return 0, pkgimporters.ErrNotFound
+	})
+	client := &pkgimporters.Client{Source: source, RequestsPerSecond: 100, Retry: pkgimporters.RetryPolicy{MaxAttempts: 1}}
+	srv := newServer(client, &pkgimporters.MemoryCache{}, 50*time.Millisecond, slog.New(slog.DiscardHandler))
+	ts := httptest.NewServer(srv.handler())
+	defer ts.Close()
+
+	get := func(path string) (servedResult, *http.Response) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result servedResult
+		if resp.StatusCode == http.StatusOK {
+			if err := json.Unmarshal(body, &result); err != nil {
+				t.Fatalf("decode %s: %v", body, err)
+			}
+		}
+		return result, resp
+	}
+
+	for range 2 {
+		result, resp := get("/api/importers/github.com/spf13/cobra")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 OK, got %s", resp.Status)
+		}
+		if result.Count != 184231 || result.Stale || result.FetchedAt.IsZero() || result.SchemaVersion != pkgimporters.SchemaVersion {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected the second request to be served from the cache, got %d upstream requests", n)
+	}
+
+	// An expired count is served as stale if the upstream fails.
+	time.Sleep(60 * time.Millisecond)
+	failing.Store(true)
+	result, resp := get("/api/importers/github.com/spf13/cobra")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 OK for a stale count, got %s", resp.Status)
+	}
+	if !result.Stale || result.Count != 184231 {
+		t.Errorf("expected the stale count, got %+v", result)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("expected a stale count not to be cached, got Cache-Control %q", cc)
+	}
+
+	if _, resp := get("/api/importers/example.com/uncached"); resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected 502 Bad Gateway without a cached count, got %s", resp.Status)
+	}
+	failing.Store(false)
+	if _, resp := get("/api/importers/example.com/unknown"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 Not Found, got %s", resp.Status)
+	}
+	if _, resp := get("/api/importers/example.com/a:b"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 Bad Request, got %s", resp.Status)
+	}
+}
+
+func TestServerMetrics(t *testing.T) {
+	source := sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+		if pkgPath == "example.com/unknown" {
+			return 0, pkgimporters.ErrNotFound
+		}
+		return 184231, nil
+	})
+	client := &pkgimporters.Client{Source: source, RequestsPerSecond: 100}
+	srv := newServer(client, &pkgimporters.MemoryCache{}, time.Hour, slog.New(slog.DiscardHandler))
+	srv.maxCounts = 1
+	ts := httptest.NewServer(srv.handler())
+	defer ts.Close()
+
+	// Only the count of the first package is exported: unknown packages are not, and the second is over maxCounts.
+	for _, pkgPath := range []string{"github.com/spf13/cobra", "github.com/spf13/cobra", "example.com/unknown", "github.com/spf13/pflag"} {
+		resp, err := http.Get(ts.URL + "/api/importers/" + pkgPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %s", resp.Status)
+	}
+	for _, want := range []string{
+		`pkgimporters_http_requests_total{code="200",handler="importers"} 3`,
+		`pkgimporters_http_requests_total{code="404",handler="importers"} 1`,
+		`pkgimporters_package_importers{package="github.com/spf13/cobra"} 184231`,
+		`pkgimporters_cache_hit_ratio 0.25`,
+		`pkgimporters_requests_total`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+	for _, unwanted := range []string{`package="example.com/unknown"`, `package="github.com/spf13/pflag"`} {
+		if strings.Contains(string(body), unwanted) {
+			t.Errorf("expected metrics not to contain %q, got:\n%s", unwanted, body)
+		}
+	}
+}
+
+func TestServerUI(t *testing.T) {
+	client := &pkgimporters.Client{Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+		return 0, pkgimporters.ErrNotFound
+	})}
+	srv := newServer(client, &pkgimporters.MemoryCache{}, time.Hour, slog.New(slog.DiscardHandler))
+	get := func(ts *httptest.Server, path string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(body)
+	}
+
+	ts := httptest.NewServer(srv.handler())
+	defer ts.Close()
+	resp, body := get(ts, "/")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") ||
+		!strings.Contains(body, "/api/importers/") {
+		t.Errorf("expected the web UI, got %s %q", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	if resp, _ := get(ts, "/api/history/fmt"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 Not Found for the history without -history, got %s", resp.Status)
+	}
+
+	srv.history = writeHistory(t, "csv", []map[string]int{
+		{"fmt": 5000000, "encoding/json": 1000000},
+		{"encoding/json": 1050000},
+		{"fmt": 5400000},
+	})
+	ts = httptest.NewServer(srv.handler())
+	defer ts.Close()
+	resp, body = get(ts, "/api/history/fmt")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %s", resp.Status)
+	}
+	var points []historyPoint
+	if err := json.Unmarshal([]byte(body), &points); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	want := []historyPoint{
+		{Time: time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC), Count: 5000000},
+		{Time: time.Date(2024, 1, 3, 3, 0, 0, 0, time.UTC), Count: 5400000},
+	}
+	if !slices.Equal(points, want) {
+		t.Errorf("got history %+v, want %+v", points, want)
+	}
+	if _, body := get(ts, "/api/history/example.com/unknown"); strings.TrimSpace(body) != "[]" {
+		t.Errorf("expected an empty history for a package without counts, got %s", body)
+	}
+}
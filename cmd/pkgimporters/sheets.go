@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// sheetsScope is the OAuth 2.0 scope of the Google Sheets API requests.
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+// sheetWriter replaces the content of a tab of a Google Sheet
+// using the Google Sheets API (https://developers.google.com/sheets/api/reference/rest).
+type sheetWriter struct {
+	client        *http.Client
+	baseURL       string // "https://sheets.googleapis.com"
+	spreadsheetID string
+	tab           string
+	token         func(ctx context.Context) (string, error)
+}
+
+// newSheetWriter returns a sheetWriter to the tab of value, spreadsheet-id!tab,
+// authenticated with the access token GOOGLE_OAUTH_ACCESS_TOKEN if set,
+// or else with the service account key file GOOGLE_APPLICATION_CREDENTIALS, both read by getenv.
+func newSheetWriter(value string, client *http.Client, getenv func(string) string) (*sheetWriter, error) {
+	id, tab, ok := strings.Cut(value, "!")
+	if !ok || id == "" || tab == "" {
+		return nil, fmt.Errorf("invalid -sheet value: %q (must be spreadsheet-id!tab)", value)
+	}
+	w := &sheetWriter{client: client, baseURL: "https://sheets.googleapis.com", spreadsheetID: id, tab: tab}
+	if token := getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		w.token = func(context.Context) (string, error) { return token, nil }
+		return w, nil
+	}
+	keyFile := getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if keyFile == "" {
+		return nil, errors.New("-sheet requires a service account key file in GOOGLE_APPLICATION_CREDENTIALS or an access token in GOOGLE_OAUTH_ACCESS_TOKEN")
+	}
+	key, err := readServiceAccountKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	w.token = func(ctx context.Context) (string, error) {
+		return key.accessToken(ctx, client, sheetsScope, time.Now())
+	}
+	return w, nil
+}
+
+// write replaces the content of the tab with rows, writing cells that are numbers as numbers.
+func (w *sheetWriter) write(ctx context.Context, rows [][]string) error {
+	token, err := w.token(ctx)
+	if err != nil {
+		return fmt.Errorf("sheet: %w", err)
+	}
+	// A1 notation quotes sheet names, doubling their quotes.
+	sheet := "'" + strings.ReplaceAll(w.tab, "'", "''") + "'"
+	valuesURL := w.baseURL + "/v4/spreadsheets/" + url.PathEscape(w.spreadsheetID) + "/values/"
+
+	if err := w.do(ctx, http.MethodPost, valuesURL+url.PathEscape(sheet)+":clear", token, struct{}{}); err != nil {
+		return fmt.Errorf("sheet: clear %s: %w", w.tab, err)
+	}
+	values := make([][]any, 0, len(rows))
+	for _, row := range rows {
+		cells := make([]any, 0, len(row))
+		for _, cell := range row {
+			if n, err := strconv.ParseFloat(cell, 64); err == nil {
+				cells = append(cells, n)
+				continue
+			}
+			cells = append(cells, cell)
+		}
+		values = append(values, cells)
+	}
+	body := struct {
+		Values [][]any `json:"values"`
+	}{values}
+	if err := w.do(ctx, http.MethodPut, valuesURL+url.PathEscape(sheet+"!A1")+"?valueInputOption=RAW", token, body); err != nil {
+		return fmt.Errorf("sheet: write %s: %w", w.tab, err)
+	}
+	return nil
+}
+
+// do sends body as JSON to the Google Sheets API.
+func (w *sheetWriter) do(ctx context.Context, method, apiURL, token string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+	return nil
+}
+
+// sheetRows returns the rows of results in a sheet: the rows of the CSV format, starting with its header.
+func sheetRows(results []pkgimporters.Result) ([][]string, error) {
+	var buf bytes.Buffer
+	if err := (pkgimporters.CSVRenderer{}).Render(&buf, results); err != nil {
+		return nil, err
+	}
+	return csv.NewReader(&buf).ReadAll()
+}
+
+// serviceAccountKey is the JSON key file of a Google Cloud service account.
+type serviceAccountKey struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+
+	key *rsa.PrivateKey
+}
+
+// readServiceAccountKey reads the service account key file and parses its private key.
+func readServiceAccountKey(file string) (*serviceAccountKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read service account key: %w", err)
+	}
+	var k serviceAccountKey
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, fmt.Errorf("read service account key %s: %w", file, err)
+	}
+	if k.Type != "service_account" || k.ClientEmail == "" || k.TokenURI == "" {
+		return nil, fmt.Errorf("read service account key %s: not a service account key", file)
+	}
+	block, _ := pem.Decode([]byte(k.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("read service account key %s: no PEM private key", file)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("read service account key %s: %w", file, err)
+	}
+	var ok bool
+	if k.key, ok = parsed.(*rsa.PrivateKey); !ok {
+		return nil, fmt.Errorf("read service account key %s: not an RSA private key", file)
+	}
+	return &k, nil
+}
+
+// accessToken exchanges a JWT signed with the key for an access token with scope
+// (https://developers.google.com/identity/protocols/oauth2/service-account#httprest).
+func (k *serviceAccountKey) accessToken(ctx context.Context, client *http.Client, scope string, now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iss":   k.ClientEmail,
+		"scope": scope,
+		"aud":   k.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, k.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("sign token request: %w", err)
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request access token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request access token: %w", apiError(resp))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return "", fmt.Errorf("decode access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// apiError returns an error for the unexpected status of resp with the start of its body,
+// which names the cause in the error responses of cloud APIs.
+func apiError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	return fmt.Errorf("unexpected status: %s: %s", resp.Status, bytes.TrimSpace(body))
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestSheetWriter(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type request struct {
+		method, path, auth string
+		body               string
+	}
+	var requests []request
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		jwt := strings.Split(r.PostForm.Get("assertion"), ".")
+		if len(jwt) != 3 {
+			t.Fatalf("expected a JWT, got %q", r.PostForm.Get("assertion"))
+		}
+		claims, _ := base64.RawURLEncoding.DecodeString(jwt[1])
+		signature, _ := base64.RawURLEncoding.DecodeString(jwt[2])
+		hash := sha256.Sum256([]byte(jwt[0] + "." + jwt[1]))
+		if err := rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hash[:], signature); err != nil {
+			t.Errorf("invalid JWT signature: %v", err)
+		}
+		if !strings.Contains(string(claims), `"scope":"`+sheetsScope+`"`) || !strings.Contains(string(claims), `"iss":"reporter@project.iam.gserviceaccount.com"`) {
+			t.Errorf("unexpected JWT claims %s", claims)
+		}
+		io.WriteString(w, `{"access_token":"ya29.service","expires_in":3599,"token_type":"Bearer"}`)
+	})
+	mux.HandleFunc("/v4/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{r.Method, r.URL.EscapedPath(), r.Header.Get("Authorization"), string(body)})
+		io.WriteString(w, "{}")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "key.json")
+	key, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "reporter@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    srv.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, key, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := newSheetWriter("1AbC!Go's stdlib", srv.Client(), func(name string) string {
+		return map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": keyFile}[name]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.baseURL = srv.URL
+	rows, err := sheetRows([]pkgimporters.Result{{Path: "fmt", Count: 5485422, Status: pkgimporters.StatusOK}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.write(t.Context(), rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected a clear and a write request, got %v", requests)
+	}
+	if got, want := requests[0], (request{"POST", "/v4/spreadsheets/1AbC/values/%27Go%27%27s%20stdlib%27:clear", "Bearer ya29.service", "{}"}); got != want {
+		t.Errorf("expected clear request %v, got %v", want, got)
+	}
+	if got, want := requests[1].path, "/v4/spreadsheets/1AbC/values/%27Go%27%27s%20stdlib%27%21A1"; got != want {
+		t.Errorf("expected write to %s, got %s", want, got)
+	}
+	var body struct {
+		Values [][]any `json:"values"`
+	}
+	if err := json.Unmarshal([]byte(requests[1].body), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Values) != 2 || body.Values[0][1] != "path" || !reflect.DeepEqual(body.Values[1][:4], []any{1.0, "fmt", 5485422.0, "OK"}) {
+		t.Errorf("unexpected values %v", body.Values)
+	}
+}
+
+func TestNewSheetWriterErrors(t *testing.T) {
+	getenv := func(string) string { return "" }
+	for _, value := range []string{"1AbC", "!stdlib", "1AbC!"} {
+		if _, err := newSheetWriter(value, http.DefaultClient, getenv); err == nil || !strings.Contains(err.Error(), "invalid -sheet value") {
+			t.Errorf("expected an invalid value error for %q, got %v", value, err)
+		}
+	}
+	if _, err := newSheetWriter("1AbC!stdlib", http.DefaultClient, getenv); err == nil || !strings.Contains(err.Error(), "GOOGLE_APPLICATION_CREDENTIALS") {
+		t.Errorf("expected a missing credentials error, got %v", err)
+	}
+}
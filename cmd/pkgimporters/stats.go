@@ -0,0 +1,144 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// slowestCount is the number of slowest packages listed by runStats.write.
+const slowestCount = 5
+
+// runStats collects the timing of a run from the hooks of a Client:
+// the number of requests and retries, and the latency of each fetched package
+// from its first request to its result, including retries.
+type runStats struct {
+	start time.Time
+	now   func() time.Time
+
+	mu        sync.Mutex
+	requests  int
+	retries   map[string]int
+	started   map[string]time.Time
+	latencies map[string]time.Duration
+	results   int
+}
+
+func newRunStats() *runStats {
+	return &runStats{
+		start:     time.Now(),
+		now:       time.Now,
+		retries:   make(map[string]int),
+		started:   make(map[string]time.Time),
+		latencies: make(map[string]time.Duration),
+	}
+}
+
+// hook sets the hooks of client to record into s, keeping any existing hooks.
+func (s *runStats) hook(client *pkgimporters.Client) {
+	onRequest, onRetry, onResult := client.OnRequest, client.OnRetry, client.OnResult
+	client.OnRequest = func(pkgPath string) {
+		s.request(pkgPath)
+		if onRequest != nil {
+			onRequest(pkgPath)
+		}
+	}
+	client.OnRetry = func(pkgPath string, attempt int, err error) {
+		s.retry(pkgPath)
+		if onRetry != nil {
+			onRetry(pkgPath, attempt, err)
+		}
+	}
+	client.OnResult = func(r pkgimporters.Result, err error) {
+		s.result(r.Path)
+		if onResult != nil {
+			onResult(r, err)
+		}
+	}
+}
+
+func (s *runStats) request(pkgPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if _, ok := s.started[pkgPath]; !ok {
+		s.started[pkgPath] = s.now()
+	}
+}
+
+func (s *runStats) retry(pkgPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries[pkgPath]++
+}
+
+func (s *runStats) result(pkgPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results++
+	if start, ok := s.started[pkgPath]; ok {
+		s.latencies[pkgPath] = s.now().Sub(start)
+	}
+}
+
+// write writes a summary of the run to w: the wall time, the number of requests and retries,
+// the effective request rate, and the slowest packages.
+func (s *runStats) write(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wall := s.now().Sub(s.start)
+	retries := 0
+	for _, n := range s.retries {
+		retries += n
+	}
+	rate := 0.0
+	if wall > 0 {
+		rate = float64(s.requests) / wall.Seconds()
+	}
+	if _, err := fmt.Fprintf(w, "Completed %d packages in %v: %d requests (%d retries), %.2f requests/s\n",
+		s.results, wall.Round(time.Millisecond), s.requests, retries, rate); err != nil {
+		return err
+	}
+
+	slowest := make([]string, 0, len(s.latencies))
+	for path := range s.latencies {
+		slowest = append(slowest, path)
+	}
+	slices.SortFunc(slowest, func(a, b string) int {
+		return cmp.Or(cmp.Compare(s.latencies[b], s.latencies[a]), cmp.Compare(a, b))
+	})
+	if len(slowest) > slowestCount {
+		slowest = slowest[:slowestCount]
+	}
+	if len(slowest) == 0 {
+		return nil
+	}
+
+	width := 0
+	for _, path := range slowest {
+		width = max(width, len(path))
+	}
+	if _, err := fmt.Fprintln(w, "Slowest packages:"); err != nil {
+		return err
+	}
+	for _, path := range slowest {
+		line := fmt.Sprintf("    %-*s %v", width, path, s.latencies[path].Round(time.Millisecond))
+		switch n := s.retries[path]; n {
+		case 0:
+		case 1:
+			line += " (1 retry)"
+		default:
+			line += fmt.Sprintf(" (%d retries)", n)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
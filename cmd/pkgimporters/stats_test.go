@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestRunStats(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newRunStats()
+	s.start = now
+	s.now = func() time.Time { return now }
+	advance := func(d time.Duration) { now = now.Add(d) }
+
+	s.request("io")
+	s.request("net/http")
+	advance(500 * time.Millisecond)
+	s.result("io")
+	s.retry("net/http")
+	advance(time.Second)
+	s.request("net/http")
+	advance(500 * time.Millisecond)
+	s.result("net/http")
+	s.result("fmt") // cached, no request
+
+	var b strings.Builder
+	if err := s.write(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "Completed 3 packages in 2s: 3 requests (1 retries), 1.50 requests/s\n" +
+		"Slowest packages:\n" +
+		"    net/http 2s (1 retry)\n" +
+		"    io       500ms\n"
+	if got := b.String(); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestRunStatsHook(t *testing.T) {
+	var results int
+	client := &pkgimporters.Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			if pkgPath == "example.com/broken" {
+				return 0, errors.New("bad gateway")
+			}
+			return mapSource{"io": 5}.Count(ctx, pkgPath)
+		}),
+		RequestsPerSecond: 100,
+		OnResult:          func(pkgimporters.Result, error) { results++ },
+	}
+	s := newRunStats()
+	s.hook(client)
+
+	for _, path := range []string{"io", "io", "example.com/broken"} {
+		client.ImporterCount(t.Context(), path)
+	}
+	if results != 3 {
+		t.Errorf("expected the existing OnResult hook to be called 3 times, got %d", results)
+	}
+	if s.requests != 2 || s.results != 3 || len(s.latencies) != 2 {
+		t.Errorf("expected 2 requests, 3 results, and 2 latencies, got %d, %d, and %d", s.requests, s.results, len(s.latencies))
+	}
+}
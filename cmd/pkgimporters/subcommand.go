@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// subcommands are run instead of counting importers if their name is the first argument,
+// e.g., "pkgimporters list fmt".
+var subcommands = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"list":     runList,
+	"graph":    runGraph,
+	"annotate": runAnnotate,
+	"audit":    runAudit,
+	"compare":  runCompareHistory,
+	"movers":   runMovers,
+	"serve":    runServe,
+	"internal": runInternal,
+	"probe":    runProbe,
+}
+
+// mainSubcommands are like subcommands, but run once the flags of the main command are defined,
+// as they describe them, e.g., "pkgimporters completion bash".
+var mainSubcommands = map[string]func(main *flag.FlagSet, args []string, stdout, stderr io.Writer) error{
+	"completion": runCompletion,
+	"doctor":     runDoctor,
+	"man":        runMan,
+}
+
+// parseArgs parses the flags of a subcommand, which may follow its positional arguments,
+// and returns the positional arguments.
+// It returns flag.ErrHelp if help was requested, and a usage error for invalid flags.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, &cmdError{code: 2, msg: err.Error()}
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// clientFlags are the flags of subcommands that configure how pkg.go.dev is reached.
+type clientFlags struct {
+	baseURL   *string
+	proxy     *string
+	userAgent *string
+	timeout   *time.Duration
+}
+
+func addClientFlags(fs *flag.FlagSet) clientFlags {
+	return clientFlags{
+		baseURL:   fs.String("base-url", pkgimporters.DefaultBaseURL, "`URL` of the pkgsite instance to scrape, e.g., a private deployment"),
+		proxy:     fs.String("proxy", "", "`URL` of an HTTP, HTTPS, or SOCKS5 proxy for all requests (default $HTTPS_PROXY or $HTTP_PROXY; $NO_PROXY is honored)"),
+		userAgent: fs.String("user-agent", defaultUserAgent(), "User-Agent `header` sent with every request"),
+		timeout:   fs.Duration("timeout", pkgimporters.DefaultTimeout, "timeout of each request"),
+	}
+}
+
+// client returns a Client configured by the flags, or a usage error for invalid flags.
+func (f clientFlags) client(logger *slog.Logger) (*pkgimporters.Client, error) {
+	if *f.timeout <= 0 {
+		return nil, &cmdError{code: 2, msg: fmt.Sprintf("invalid -timeout value: %v (must be positive)", *f.timeout)}
+	}
+	transport, err := newTransport(transportOptions{proxy: *f.proxy})
+	if err != nil {
+		return nil, &cmdError{code: 2, msg: err.Error()}
+	}
+	return &pkgimporters.Client{
+		HTTPClient: &http.Client{Transport: pkgimporters.WithHeader("User-Agent", *f.userAgent)(transport)},
+		BaseURL:    *f.baseURL,
+		Timeout:    *f.timeout,
+		Logger:     logger,
+	}, nil
+}
+
+// rateFlags are the flags of subcommands that make many requests, limiting their rate.
+type rateFlags struct {
+	rps     *float64
+	burst   *int
+	workers *int
+}
+
+func addRateFlags(fs *flag.FlagSet) rateFlags {
+	return rateFlags{
+		rps:     fs.Float64("rps", pkgimporters.DefaultRequestsPerSecond, "maximum sustained `rate` of requests per second"),
+		burst:   fs.Int("burst", pkgimporters.DefaultBurst, "maximum number of requests made at once before -rps applies"),
+		workers: fs.Int("workers", pkgimporters.DefaultWorkers, "number of concurrent requests"),
+	}
+}
+
+// apply sets the rate limit of client, or returns a usage error for invalid flags.
+func (f rateFlags) apply(client *pkgimporters.Client) error {
+	switch {
+	case *f.rps <= 0:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -rps value: %v (must be positive)", *f.rps)}
+	case *f.burst <= 0:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -burst value: %d (must be positive)", *f.burst)}
+	case *f.workers <= 0:
+		return &cmdError{code: 2, msg: fmt.Sprintf("invalid -workers value: %d (must be positive)", *f.workers)}
+	}
+	client.RequestsPerSecond, client.Burst, client.Workers = *f.rps, *f.burst, *f.workers
+	return nil
+}
+
+// parsePackageArg returns the single package argument of a subcommand, normalized and validated.
+func parsePackageArg(name string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", &cmdError{code: 2, msg: fmt.Sprintf("%s requires exactly one package; use %[1]s -h for help", name)}
+	}
+	pkgPath := normalizePackagePath(args[0])
+	if err := validatePackages([]string{pkgPath}); err != nil {
+		return "", &cmdError{code: 2, msg: err.Error()}
+	}
+	return pkgPath, nil
+}
+
+// createOutput returns w, or the file named name if it is not empty, with a function closing it.
+func createOutput(w io.Writer, name string) (io.Writer, func() error, error) {
+	if name == "" {
+		return w, func() error { return nil }, nil
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	depth := fs.Int("depth", 1, "")
+	verbose := fs.Bool("v", false, "")
+
+	args, err := parseArgs(fs, []string{"-v", "example.com/a", "-depth", "3", "example.com/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/a", "example.com/b"}; !slices.Equal(args, want) {
+		t.Errorf("expected arguments %v, got %v", want, args)
+	}
+	if *depth != 3 || !*verbose {
+		t.Errorf("expected -depth 3 and -v, got %d and %v", *depth, *verbose)
+	}
+
+	var cmdErr *cmdError
+	if _, err := parseArgs(fs, []string{"example.com/a", "-unknown"}); !errors.As(err, &cmdErr) || cmdErr.code != 2 {
+		t.Errorf("expected a usage error for an unknown flag, got %v", err)
+	}
+	if _, err := parseArgs(fs, []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("expected flag.ErrHelp, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// stdPackagePaths loads the standard library package paths once for suggestions.
+var stdPackagePaths = sync.OnceValue(func() []string {
+	paths, _ := loadPackagePaths("std", loadOptions{})
+	return paths
+})
+
+// suggestPackage returns a package path similar to the unknown pkgPath, or "" if there is none.
+// It prefers a close standard library package, since typos there are the most common,
+// and otherwise falls back to the top pkg.go.dev search result,
+// whose request waits for the rate limiter of client like the requests for counts.
+func suggestPackage(ctx context.Context, client *pkgimporters.Client, pkgPath string) string {
+	best, bestDist := "", len(pkgPath)/4+1
+	for _, path := range stdPackagePaths() {
+		if d := editDistance(pkgPath, path); d <= bestDist {
+			if d < bestDist || best == "" {
+				best, bestDist = path, d
+			}
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	paths, err := client.Search(ctx, pkgPath, 1)
+	if err != nil || len(paths) == 0 || paths[0] == pkgPath {
+		return ""
+	}
+	return paths[0]
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
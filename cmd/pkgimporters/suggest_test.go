@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestSuggestPackage(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://pkg.go.dev/search?limit=1&m=package&q=github.com%2Fspf13%2Fcobar": []byte(
+			`<a href="/github.com/spf13/cobra" data-gtmc="search result" data-test-id="snippet-title">cobra</a>`),
+	}}
+	client := &pkgimporters.Client{
+		HTTPClient: &http.Client{Transport: transport},
+	}
+
+	tests := []struct {
+		pkgPath string
+		want    string
+	}{
+		{pkgPath: "nett/http", want: "net/http"},
+		{pkgPath: "encodng/json", want: "encoding/json"},
+		{pkgPath: "github.com/spf13/cobar", want: "github.com/spf13/cobra"},
+		{pkgPath: "example.com/unknown", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := suggestPackage(t.Context(), client, tt.pkgPath); got != tt.want {
+			t.Errorf("suggestPackage(%q): expected %q, got %q", tt.pkgPath, tt.want, got)
+		}
+	}
+}
+
+func TestSuggestPackageRateLimited(t *testing.T) {
+	var searches atomic.Int32
+	transport := pkgimporters.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		searches.Add(1)
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: http.NoBody, Request: req}, nil
+	})
+	client := &pkgimporters.Client{
+		HTTPClient:        &http.Client{Transport: transport},
+		RequestsPerSecond: 5,
+		Burst:             1,
+	}
+
+	start := time.Now()
+	for range 2 {
+		suggestPackage(t.Context(), client, "example.com/unknown")
+	}
+	// The second search must wait for the limiter, 200ms at 5 requests per second.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected the searches to be rate limited, took %v", elapsed)
+	}
+	if n := searches.Load(); n != 2 {
+		t.Errorf("expected 2 search requests, got %d", n)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "fmt", want: 3},
+		{a: "fmt", b: "fmt", want: 0},
+		{a: "nett/http", b: "net/http", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q): expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+}
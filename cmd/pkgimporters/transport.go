@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/alexandear/pkgimporters"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// transportOptions configures the HTTP transport created by newTransport.
+type transportOptions struct {
+	// proxy is the URL of an HTTP, HTTPS, or SOCKS5 proxy used for all requests.
+	// If empty, HTTP_PROXY and HTTPS_PROXY are used.
+	proxy string
+
+	// caCert is the path of a PEM file with extra CA certificates to trust,
+	// e.g., the certificate of a TLS-intercepting proxy.
+	caCert string
+
+	// insecureSkipVerify disables TLS certificate verification.
+	insecureSkipVerify bool
+
+	// tuning configures connection reuse, keep-alive, and HTTP/2.
+	tuning pkgimporters.TransportOptions
+}
+
+// newTransport returns an HTTP transport configured by opts.
+// Requests to hosts listed in NO_PROXY bypass the proxy.
+func newTransport(opts transportOptions) (*http.Transport, error) {
+	cfg := httpproxy.FromEnvironment()
+	if opts.proxy != "" {
+		u, err := url.Parse(opts.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -proxy value: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("invalid -proxy value: %q (scheme must be http, https, socks5, or socks5h)", opts.proxy)
+		}
+		cfg.HTTPProxy = opts.proxy
+		cfg.HTTPSProxy = opts.proxy
+	}
+	proxy := cfg.ProxyFunc()
+
+	t := pkgimporters.NewTransport(opts.tuning)
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+
+	if opts.caCert != "" || opts.insecureSkipVerify {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: opts.insecureSkipVerify}
+	}
+	if opts.caCert != "" {
+		pem, err := os.ReadFile(opts.caCert)
+		if err != nil {
+			return nil, fmt.Errorf("read -cacert file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("invalid -cacert file %s: no PEM certificates found", opts.caCert)
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	return t, nil
+}
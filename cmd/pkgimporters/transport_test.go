@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestNewTransportProxy(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("HTTPS_PROXY", "http://env-proxy.example.com:3128")
+	t.Setenv("NO_PROXY", "internal.corp")
+
+	tests := []struct {
+		name  string
+		proxy string
+		url   string
+		want  string
+	}{
+		{name: "environment", url: "https://pkg.go.dev/fmt", want: "http://env-proxy.example.com:3128"},
+		{name: "no proxy", url: "https://pkgsite.internal.corp/fmt", want: ""},
+		{name: "flag overrides environment", proxy: "socks5://127.0.0.1:1080", url: "https://pkg.go.dev/fmt", want: "socks5://127.0.0.1:1080"},
+		{name: "flag honors no proxy", proxy: "socks5://127.0.0.1:1080", url: "https://pkgsite.internal.corp/fmt", want: ""},
+		{name: "flag for plain http", proxy: "http://proxy.example.com:8080", url: "http://pkg.go.dev/fmt", want: "http://proxy.example.com:8080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := newTransport(transportOptions{proxy: tt.proxy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequest(http.MethodGet, tt.url, http.NoBody)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := transport.Proxy(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotURL := ""
+			if got != nil {
+				gotURL = got.String()
+			}
+			if gotURL != tt.want {
+				t.Errorf("expected proxy %q, got %q", tt.want, gotURL)
+			}
+		})
+	}
+
+	if _, err := newTransport(transportOptions{proxy: "ftp://proxy.example.com"}); err == nil {
+		t.Error("expected error for unsupported proxy scheme")
+	}
+}
+
+func TestNewTransportTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caCert, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    transportOptions
+		wantErr bool
+	}{
+		{name: "untrusted certificate", opts: transportOptions{}, wantErr: true},
+		{name: "custom CA bundle", opts: transportOptions{caCert: caCert}},
+		{name: "skip verification", opts: transportOptions{insecureSkipVerify: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := newTransport(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: transport}
+			resp, err := client.Get(srv.URL)
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("expected a certificate error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		})
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "not.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTransport(transportOptions{caCert: notPEM}); err == nil {
+		t.Error("expected error for a file without certificates")
+	}
+}
+
+func TestNewTransportTuning(t *testing.T) {
+	tr, err := newTransport(transportOptions{
+		proxy:  "http://proxy.corp:3128",
+		tuning: pkgimporters.TransportOptions{MaxIdleConnsPerHost: 32, DisableHTTP2: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.MaxIdleConnsPerHost != 32 {
+		t.Errorf("expected 32 idle connections per host, got %d", tr.MaxIdleConnsPerHost)
+	}
+	if tr.Protocols.HTTP2() {
+		t.Error("expected HTTP/2 to be disabled")
+	}
+	if tr.Proxy == nil {
+		t.Error("expected the proxy to be kept")
+	}
+}
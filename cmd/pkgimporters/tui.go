@@ -0,0 +1,222 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/alexandear/pkgimporters"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tuiModel is the bubbletea model of -tui: a live table of results
+// that can be sorted, filtered, and opened on pkg.go.dev.
+type tuiModel struct {
+	baseURL string
+	total   int
+	open    func(url string) error
+
+	results []pkgimporters.Result
+	sortBy  string // "name" or "count"
+
+	filter    string
+	filtering bool
+
+	done   bool
+	err    error
+	status string
+
+	table table.Model
+}
+
+// tuiResultMsg delivers a result to the TUI as it arrives.
+type tuiResultMsg pkgimporters.Result
+
+// tuiDoneMsg reports that fetching finished, with err set if it stopped early.
+type tuiDoneMsg struct{ err error }
+
+func newTUIModel(baseURL string, total int, sortBy string) tuiModel {
+	t := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Package", Width: 50},
+			{Title: "Importers", Width: 12},
+			{Title: "Status", Width: 40},
+		}),
+		table.WithFocused(true),
+		table.WithHeight(20),
+	)
+	return tuiModel{baseURL: baseURL, total: total, open: openBrowser, sortBy: sortBy, table: t}
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tuiResultMsg:
+		m.results = append(m.results, pkgimporters.Result(msg))
+		m.refresh()
+		return m, nil
+	case tuiDoneMsg:
+		m.done, m.err = true, msg.err
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.table.SetHeight(max(msg.Height-4, 3))
+		return m, nil
+	case tea.KeyMsg:
+		if m.filtering {
+			return m.updateFilter(msg), nil
+		}
+		m.status = ""
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		case "s":
+			m.sortBy = map[string]string{"name": "count", "count": "name"}[m.sortBy]
+			m.refresh()
+			return m, nil
+		case "/":
+			m.filtering = true
+			return m, nil
+		case "o", "enter":
+			if row := m.table.SelectedRow(); row != nil {
+				url := strings.TrimSuffix(m.baseURL, "/") + "/" + row[0] + "?tab=importedby"
+				if err := m.open(url); err != nil {
+					m.status = fmt.Sprintf("open %s: %v", url, err)
+				}
+			}
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+// updateFilter edits the filter while the user is typing it.
+func (m tuiModel) updateFilter(msg tea.KeyMsg) tuiModel {
+	switch msg.Type {
+	case tea.KeyEnter, tea.KeyEsc:
+		m.filtering = false
+	case tea.KeyBackspace:
+		if r := []rune(m.filter); len(r) > 0 {
+			m.filter = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes:
+		m.filter += string(msg.Runes)
+	case tea.KeyCtrlC:
+		m.filtering, m.filter = false, ""
+	}
+	m.refresh()
+	return m
+}
+
+// rows returns the table rows of the results matching the filter, in sort order.
+func (m tuiModel) rows() []table.Row {
+	results := slices.Clone(m.results)
+	slices.SortFunc(results, func(a, b pkgimporters.Result) int {
+		if m.sortBy == "count" {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Path, b.Path))
+		}
+		return cmp.Compare(a.Path, b.Path)
+	})
+	rows := make([]table.Row, 0, len(results))
+	for _, r := range results {
+		if !strings.Contains(r.Path, m.filter) {
+			continue
+		}
+		count, status := pkgimporters.FormatCount(r.Count), string(cmp.Or(r.Status, pkgimporters.StatusOK))
+		if r.Error != "" {
+			count, status = "", string(cmp.Or(r.Status, pkgimporters.StatusFailed))+" "+r.Error
+		}
+		rows = append(rows, table.Row{r.Path, count, status})
+	}
+	return rows
+}
+
+func (m *tuiModel) refresh() {
+	m.table.SetRows(m.rows())
+}
+
+func (m tuiModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.table.View())
+	b.WriteString("\n")
+
+	progress := fmt.Sprintf("%d/%d fetched", len(m.results), m.total)
+	switch {
+	case m.err != nil:
+		progress += fmt.Sprintf(", stopped: %v", m.err)
+	case m.done:
+		progress += ", done"
+	}
+	fmt.Fprintf(&b, "%s  sort: %s", progress, m.sortBy)
+	if m.filter != "" || m.filtering {
+		fmt.Fprintf(&b, "  filter: %s", m.filter)
+		if m.filtering {
+			b.WriteString("_")
+		}
+	}
+	b.WriteString("\n")
+	if m.status != "" {
+		b.WriteString(m.status + "\n")
+	}
+	b.WriteString("↑/↓ move  s sort  / filter  o open on pkg.go.dev  q quit\n")
+	return b.String()
+}
+
+// fetchFunc fetches importer counts, calling opts.emit for every result as it arrives.
+type fetchFunc func(ctx context.Context, opts fetchOptions) (results, failures []pkgimporters.Result, err error)
+
+// runTUI calls fetch while showing the results in the TUI, and returns its results once the user quits.
+// Quitting before fetching finished cancels the outstanding requests and returns no results.
+func runTUI(ctx context.Context, m tuiModel, fetch fetchFunc, opts fetchOptions) (results, failures []pkgimporters.Result, err error) {
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
+	opts.emit = func(r pkgimporters.Result) error {
+		p.Send(tuiResultMsg(r))
+		return nil
+	}
+	fetched := make(chan struct{})
+	go func() {
+		defer close(fetched)
+		results, failures, err = fetch(fetchCtx, opts)
+		p.Send(tuiDoneMsg{err: err})
+	}()
+
+	_, tuiErr := p.Run()
+	cancel()
+	<-fetched
+	switch {
+	case ctx.Err() != nil:
+		return nil, nil, err
+	case tuiErr != nil:
+		return nil, nil, tuiErr
+	case errors.Is(err, context.Canceled):
+		return nil, nil, nil
+	}
+	return results, failures, err
+}
+
+// openBrowser opens url in the default web browser.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
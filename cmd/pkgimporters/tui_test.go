@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTUIModel(t *testing.T) {
+	var opened []string
+	var m tea.Model = newTUIModel("https://pkg.go.dev/", 3, "name")
+	model := m.(tuiModel)
+	model.open = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	m = model
+
+	update := func(msg tea.Msg) {
+		t.Helper()
+		m, _ = m.Update(msg)
+	}
+	paths := func() []string {
+		t.Helper()
+		var paths []string
+		for _, row := range m.(tuiModel).table.Rows() {
+			paths = append(paths, row[0])
+		}
+		return paths
+	}
+
+	update(tuiResultMsg{Path: "io", Count: 5, Status: pkgimporters.StatusOK})
+	update(tuiResultMsg{Path: "fmt", Count: 7, Status: pkgimporters.StatusOK})
+	update(tuiResultMsg{Path: "example.com/missing", Status: pkgimporters.StatusNotFound, Error: "package not found"})
+	if want := []string{"example.com/missing", "fmt", "io"}; !slices.Equal(paths(), want) {
+		t.Errorf("expected rows sorted by name %v, got %v", want, paths())
+	}
+	if want := (table.Row{"example.com/missing", "", "NOT_FOUND package not found"}); !slices.Equal(m.(tuiModel).table.Rows()[0], want) {
+		t.Errorf("expected failed row %v, got %v", want, m.(tuiModel).table.Rows()[0])
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if want := []string{"fmt", "io", "example.com/missing"}; !slices.Equal(paths(), want) {
+		t.Errorf("expected rows sorted by count %v, got %v", want, paths())
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("io")})
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if want := []string{"io"}; !slices.Equal(paths(), want) {
+		t.Errorf("expected filtered rows %v, got %v", want, paths())
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	if want := []string{"https://pkg.go.dev/io?tab=importedby"}; !slices.Equal(opened, want) {
+		t.Errorf("expected opened %v, got %v", want, opened)
+	}
+
+	update(tuiDoneMsg{})
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Error("expected q to quit")
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// uploader uploads reports to an S3 or Google Cloud Storage bucket.
+type uploader struct {
+	client    *http.Client
+	scheme    string // "s3" or "gs"
+	bucket    string
+	key       string // object key, or a prefix ending in "/" to which the report name is appended
+	bucketURL string // URL of the bucket, to which object keys are appended
+
+	// S3 credentials, signing requests with AWS Signature Version 4.
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+
+	// gcsToken is the OAuth 2.0 access token of Google Cloud Storage requests.
+	gcsToken string
+}
+
+// newUploader returns an uploader to rawURL, s3://bucket/key or gs://bucket/key,
+// with credentials from the environment read by getenv:
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, and AWS_REGION for S3,
+// whose endpoint can be replaced by AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL for S3-compatible storage,
+// and GOOGLE_OAUTH_ACCESS_TOKEN for Google Cloud Storage.
+func newUploader(rawURL string, client *http.Client, getenv func(string) string) (*uploader, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -upload value: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid -upload value: %q (must be s3://bucket/key or gs://bucket/key)", rawURL)
+	}
+	up := &uploader{
+		client: client,
+		scheme: u.Scheme,
+		bucket: u.Host,
+		key:    strings.TrimPrefix(u.Path, "/"),
+	}
+	switch u.Scheme {
+	case "s3":
+		up.region = cmp.Or(getenv("AWS_REGION"), getenv("AWS_DEFAULT_REGION"), "us-east-1")
+		// S3-compatible storage is addressed in path style, AWS in virtual-hosted style.
+		up.bucketURL = "https://" + up.bucket + ".s3." + up.region + ".amazonaws.com"
+		if endpoint := cmp.Or(getenv("AWS_ENDPOINT_URL_S3"), getenv("AWS_ENDPOINT_URL")); endpoint != "" {
+			up.bucketURL = strings.TrimSuffix(endpoint, "/") + "/" + up.bucket
+		}
+		up.accessKeyID = getenv("AWS_ACCESS_KEY_ID")
+		up.secretAccessKey = getenv("AWS_SECRET_ACCESS_KEY")
+		up.sessionToken = getenv("AWS_SESSION_TOKEN")
+		if up.accessKeyID == "" || up.secretAccessKey == "" {
+			return nil, fmt.Errorf("-upload %s requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY", rawURL)
+		}
+	case "gs":
+		up.bucketURL = "https://storage.googleapis.com/" + up.bucket
+		up.gcsToken = getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+		if up.gcsToken == "" {
+			return nil, fmt.Errorf("-upload %s requires GOOGLE_OAUTH_ACCESS_TOKEN, e.g., from 'gcloud auth print-access-token'", rawURL)
+		}
+	default:
+		return nil, fmt.Errorf("invalid -upload value: %q (must be s3://bucket/key or gs://bucket/key)", rawURL)
+	}
+	return up, nil
+}
+
+// upload stores data with contentType as the object key of the uploader, or name under its prefix,
+// and returns the URL of the object.
+func (up *uploader) upload(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	key := up.key
+	if key == "" || strings.HasSuffix(key, "/") {
+		key += name
+	}
+	objectURL := up.scheme + "://" + up.bucket + "/" + key
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, up.bucketURL+"/"+escapeKey(key), bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("upload %s: new request: %w", objectURL, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if up.scheme == "s3" {
+		sum := sha256.Sum256(data)
+		req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum[:]))
+		if up.sessionToken != "" {
+			req.Header.Set("X-Amz-Security-Token", up.sessionToken)
+		}
+		signV4(req, up.accessKeyID, up.secretAccessKey, up.region, "s3", time.Now())
+	} else {
+		req.Header.Set("Authorization", "Bearer "+up.gcsToken)
+	}
+
+	resp, err := up.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload %s: %w", objectURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// The error response names the cause, e.g., AccessDenied or NoSuchBucket.
+		return "", fmt.Errorf("upload %s: %w", objectURL, apiError(resp))
+	}
+	return objectURL, nil
+}
+
+// reportName returns the name of a report of a run started at runTime in format,
+// e.g., "pkgimporters-20250106T030000Z.csv".
+func reportName(runTime time.Time, format string) string {
+	ext := format
+	if format == "text" {
+		ext = "txt"
+	}
+	return "pkgimporters-" + runTime.UTC().Format("20060102T150405Z") + "." + ext
+}
+
+// reportContentType returns the media type of a report in format.
+func reportContentType(format string) string {
+	switch format {
+	case "json":
+		return "application/json"
+	case "csv":
+		return "text/csv; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// escapeKey escapes an object key for a URL path, keeping its slashes.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode percent-encodes all bytes of s except the unreserved characters of RFC 3986,
+// as required by AWS Signature Version 4.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := range len(s) {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// signV4 signs req for service in region with AWS Signature Version 4
+// (https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html),
+// signing its host and all headers set on it. The X-Amz-Content-Sha256 header must hold the hash of the body.
+func signV4(req *http.Request, accessKeyID, secretAccessKey, region, service string, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := slices.Sorted(maps.Keys(headers))
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		cmp.Or(req.URL.EscapedPath(), "/"),
+		req.URL.RawQuery, // requests have no query
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, s := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
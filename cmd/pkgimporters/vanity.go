@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// codeHosts are the hosting domains whose import paths are repository paths, not vanity paths.
+var codeHosts = []string{"github.com", "gitlab.com", "bitbucket.org"}
+
+var goImportRe = regexp.MustCompile(`<meta\s+name="go-import"\s+content="([^"]+)"`)
+
+// isVanityPath reports whether pkgPath is served from a custom domain
+// rather than from a well-known code host or the standard library.
+func isVanityPath(pkgPath string) bool {
+	host, _, _ := strings.Cut(pkgPath, "/")
+	if !strings.Contains(host, ".") {
+		return false
+	}
+	return !slices.Contains(codeHosts, host)
+}
+
+// resolveVanityPath follows the go-import meta tag served for the vanity pkgPath
+// and returns the corresponding package path inside the repository, e.g.,
+// "go.uber.org/zap/zapcore" resolves to "github.com/uber-go/zap/zapcore".
+// It returns pkgPath unchanged if the repository path is the same,
+// or if the repository is not on one of the codeHosts, e.g., go.googlesource.com for golang.org/x,
+// as pkg.go.dev only serves such packages under their vanity path.
+func resolveVanityPath(ctx context.Context, client *http.Client, pkgPath string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+pkgPath+"?go-get=1", http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("read body: %w", err)
+	}
+
+	for _, m := range goImportRe.FindAllSubmatch(body, -1) {
+		fields := strings.Fields(string(m[1]))
+		if len(fields) != 3 || fields[1] == "mod" {
+			continue
+		}
+		prefix, repoRoot := fields[0], fields[2]
+		if pkgPath != prefix && !strings.HasPrefix(pkgPath, prefix+"/") {
+			continue
+		}
+
+		u, err := url.Parse(repoRoot)
+		if err != nil {
+			return "", fmt.Errorf("parse repository URL %q: %w", repoRoot, err)
+		}
+		if !slices.Contains(codeHosts, u.Host) {
+			return pkgPath, nil
+		}
+		repoPath := u.Host + strings.TrimSuffix(u.Path, ".git")
+		return repoPath + strings.TrimPrefix(pkgPath, prefix), nil
+	}
+	return "", fmt.Errorf("no go-import meta tag for %s", pkgPath)
+}
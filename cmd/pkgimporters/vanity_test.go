@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsVanityPath(t *testing.T) {
+	tests := []struct {
+		pkgPath string
+		want    bool
+	}{
+		{pkgPath: "net/http", want: false},
+		{pkgPath: "github.com/spf13/cobra", want: false},
+		{pkgPath: "go.uber.org/zap", want: true},
+		{pkgPath: "golang.org/x/tools/go/analysis", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := isVanityPath(tt.pkgPath); got != tt.want {
+			t.Errorf("isVanityPath(%q): expected %t, got %t", tt.pkgPath, tt.want, got)
+		}
+	}
+}
+
+func TestResolveVanityPath(t *testing.T) {
+	const page = `<html><head>
+<meta name="go-import" content="go.uber.org/zap mod https://proxy.golang.org">
+<meta name="go-import" content="go.uber.org/zap git https://github.com/uber-go/zap.git">
+<meta name="go-source" content="go.uber.org/zap https://github.com/uber-go/zap https://github.com/uber-go/zap/tree/master{/dir} https://github.com/uber-go/zap/tree/master{/dir}/{file}#L{line}">
+</head></html>`
+
+	transport := &urlTransport{files: map[string][]byte{
+		"https://go.uber.org/zap/zapcore?go-get=1": []byte(page),
+		"https://golang.org/x/tools/go/analysis?go-get=1": []byte(
+			`<meta name="go-import" content="golang.org/x/tools git https://go.googlesource.com/tools">`),
+	}}
+	client := &http.Client{Transport: transport}
+
+	got, err := resolveVanityPath(t.Context(), client, "go.uber.org/zap/zapcore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "github.com/uber-go/zap/zapcore"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	// pkg.go.dev does not serve go.googlesource.com, so the vanity path is kept.
+	got, err = resolveVanityPath(t.Context(), client, "golang.org/x/tools/go/analysis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "golang.org/x/tools/go/analysis"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// verifyCounts cross-checks pkg.go.dev results against the deps.dev counts reported by client
+// and returns an error listing the packages whose counts diverge by more than tolerance,
+// expressed as the difference relative to the larger count.
+// Packages unknown to deps.dev are not checked.
+func verifyCounts(ctx context.Context, client *pkgimporters.Client, results []pkgimporters.Result, tolerance float64) error {
+	pkgPaths := make([]string, 0, len(results))
+	want := make(map[string]int, len(results))
+	for _, r := range results {
+		pkgPaths = append(pkgPaths, r.Path)
+		want[r.Path] = r.Count
+	}
+
+	got := make(map[string]int, len(results))
+	for r, err := range client.Stream(ctx, pkgPaths) {
+		if errors.Is(err, pkgimporters.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("verify: %w", err)
+		}
+		got[r.Path] = r.Count
+	}
+
+	var errs []error
+	for _, path := range pkgPaths {
+		count, ok := got[path]
+		if !ok {
+			continue
+		}
+		if diff := divergence(want[path], count); diff > tolerance {
+			errs = append(errs, fmt.Errorf("verify %s: pkg.go.dev reports %s but deps.dev reports %s (%.0f%% apart)",
+				path, pkgimporters.FormatCount(want[path]), pkgimporters.FormatCount(count), diff*100))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// divergence returns the difference between a and b relative to the larger of the two.
+func divergence(a, b int) float64 {
+	hi, lo := max(a, b), min(a, b)
+	if hi == 0 {
+		return 0
+	}
+	return float64(hi-lo) / float64(hi)
+}
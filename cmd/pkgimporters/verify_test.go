@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestVerifyCounts(t *testing.T) {
+	client := &pkgimporters.Client{
+		Source: mapSource{"github.com/spf13/cobra": 900, "github.com/urfave/cli": 100},
+	}
+	results := []pkgimporters.Result{
+		{Path: "fmt", Count: 5000},
+		{Path: "github.com/spf13/cobra", Count: 1000},
+		{Path: "github.com/urfave/cli", Count: 1000},
+	}
+
+	err := verifyCounts(t.Context(), client, results, 0.5)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := "verify github.com/urfave/cli: pkg.go.dev reports 1,000 but deps.dev reports 100 (90% apart)"
+	if got := err.Error(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if err := verifyCounts(t.Context(), client, results, 0.95); err != nil {
+		t.Errorf("expected no error with a loose tolerance, got %v", err)
+	}
+	if err := verifyCounts(t.Context(), client, results[:2], 0); !strings.Contains(err.Error(), "github.com/spf13/cobra") {
+		t.Errorf("expected cobra to diverge with zero tolerance, got %v", err)
+	}
+}
+
+func TestDivergence(t *testing.T) {
+	tests := []struct {
+		a, b int
+		want float64
+	}{
+		{a: 0, b: 0, want: 0},
+		{a: 100, b: 100, want: 0},
+		{a: 100, b: 50, want: 0.5},
+		{a: 25, b: 100, want: 0.75},
+		{a: 0, b: 10, want: 1},
+	}
+	for _, tt := range tests {
+		if got := divergence(tt.a, tt.b); got != tt.want {
+			t.Errorf("divergence(%d, %d): expected %v, got %v", tt.a, tt.b, tt.want, got)
+		}
+	}
+}
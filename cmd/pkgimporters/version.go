@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+)
+
+const repoURL = "https://github.com/alexandear/pkgimporters"
+
+// version returns the module version the binary was built from,
+// or "devel" for builds from a local checkout.
+func version() string {
+	info, _ := debug.ReadBuildInfo()
+	return moduleVersion(info)
+}
+
+func moduleVersion(info *debug.BuildInfo) string {
+	if info == nil || info.Main.Version == "" || info.Main.Version == "(devel)" {
+		return "devel"
+	}
+	return info.Main.Version
+}
+
+// writeVersion writes the module version, the VCS revision, if known, and the Go version
+// of the binary described by info to w, for -version, e.g.:
+//
+//	pkgimporters v1.2.0
+//	revision: 4f2c1e9 (2026-10-01T12:00:00Z, modified)
+//	go: go1.25.0
+func writeVersion(w io.Writer, info *debug.BuildInfo) error {
+	if _, err := fmt.Fprintf(w, "pkgimporters %s\n", moduleVersion(info)); err != nil {
+		return err
+	}
+	if info == nil {
+		return nil
+	}
+	var revision string
+	var details []string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.time":
+			details = append(details, s.Value)
+		case "vcs.modified":
+			if s.Value == "true" {
+				details = append(details, "modified")
+			}
+		}
+	}
+	if revision != "" {
+		if len(details) > 0 {
+			revision += " (" + strings.Join(details, ", ") + ")"
+		}
+		if _, err := fmt.Fprintf(w, "revision: %s\n", revision); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "go: %s\n", info.GoVersion)
+	return err
+}
+
+// defaultUserAgent returns the User-Agent sent with every request unless -user-agent is set.
+func defaultUserAgent() string {
+	return "pkgimporters/" + strings.TrimPrefix(version(), "v") + " (+" + repoURL + ")"
+}
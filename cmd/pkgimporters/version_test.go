@@ -0,0 +1,49 @@
+package main
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestWriteVersion(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		info *debug.BuildInfo
+		want string
+	}{
+		{
+			name: "release",
+			info: &debug.BuildInfo{GoVersion: "go1.25.0", Main: debug.Module{Version: "v1.2.0"}},
+			want: "pkgimporters v1.2.0\ngo: go1.25.0\n",
+		},
+		{
+			name: "local checkout",
+			info: &debug.BuildInfo{
+				GoVersion: "go1.25.0",
+				Main:      debug.Module{Version: "(devel)"},
+				Settings: []debug.BuildSetting{
+					{Key: "vcs", Value: "git"},
+					{Key: "vcs.revision", Value: "4f2c1e9"},
+					{Key: "vcs.time", Value: "2026-10-01T12:00:00Z"},
+					{Key: "vcs.modified", Value: "true"},
+				},
+			},
+			want: "pkgimporters devel\nrevision: 4f2c1e9 (2026-10-01T12:00:00Z, modified)\ngo: go1.25.0\n",
+		},
+		{
+			name: "no build info",
+			want: "pkgimporters devel\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeVersion(&b, tt.info); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// typicalLatency is the latency of a pkg.go.dev request assumed when checking -workers against -rps.
+const typicalLatency = time.Second
+
+// parseWorkers parses the -workers flag: a positive number or "auto".
+func parseWorkers(s string) (int, error) {
+	if s == "auto" {
+		return pkgimporters.AutoWorkers, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid -workers value: %q (must be a positive number or 'auto')", s)
+	}
+	return n, nil
+}
+
+// workersWarning returns a warning if the number of workers does not fit the rate limit
+// at typicalLatency, or an empty string if it does.
+// With too many workers, most wait on the rate limiter; with too few, the rate is never reached.
+func workersWarning(workers int, rps float64, burst int) string {
+	busy := int(math.Ceil(rps*typicalLatency.Seconds())) + 1
+	switch {
+	case workers > 2*max(busy, burst):
+		return fmt.Sprintf("-workers %d is more than -rps %g can keep busy; most workers will wait on the rate limit (try -workers auto)", workers, rps)
+	case float64(workers) < rps*typicalLatency.Seconds()/2:
+		return fmt.Sprintf("-workers %d is too few to reach -rps %g (try -workers auto)", workers, rps)
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestParseWorkers(t *testing.T) {
+	if got, err := parseWorkers("auto"); err != nil || got != pkgimporters.AutoWorkers {
+		t.Errorf("parseWorkers(auto) = %d, %v", got, err)
+	}
+	if got, err := parseWorkers("8"); err != nil || got != 8 {
+		t.Errorf("parseWorkers(8) = %d, %v", got, err)
+	}
+	for _, s := range []string{"0", "-1", "many"} {
+		if _, err := parseWorkers(s); err == nil {
+			t.Errorf("parseWorkers(%q): expected an error", s)
+		}
+	}
+}
+
+func TestWorkersWarning(t *testing.T) {
+	tests := []struct {
+		workers int
+		rps     float64
+		burst   int
+		warn    bool
+	}{
+		{workers: pkgimporters.DefaultWorkers, rps: pkgimporters.DefaultRequestsPerSecond, burst: pkgimporters.DefaultBurst},
+		{workers: 20, rps: 1, burst: 3, warn: true},
+		{workers: 20, rps: 20, burst: 20},
+		{workers: 2, rps: 20, burst: 20, warn: true},
+	}
+	for _, tt := range tests {
+		if got := workersWarning(tt.workers, tt.rps, tt.burst); (got != "") != tt.warn {
+			t.Errorf("workersWarning(%d, %g, %d) = %q", tt.workers, tt.rps, tt.burst, got)
+		}
+	}
+}
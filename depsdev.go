@@ -0,0 +1,90 @@
+package pkgimporters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const depsDevURL = "https://api.deps.dev"
+
+// DepsDev is a Source that reports the number of dependents of a Go module
+// from the deps.dev API (https://docs.deps.dev/api/).
+// deps.dev tracks modules, not packages, so the path must be a module path;
+// other paths, including standard library packages, are not found.
+type DepsDev struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// Count returns the number of dependents of the default version of the module.
+// It returns ErrNotFound if deps.dev does not know the module.
+func (s *DepsDev) Count(ctx context.Context, pkgPath string) (int, error) {
+	name := url.PathEscape(pkgPath)
+
+	var pkg struct {
+		Versions []struct {
+			VersionKey struct {
+				Version string `json:"version"`
+			} `json:"versionKey"`
+			IsDefault bool `json:"isDefault"`
+		} `json:"versions"`
+	}
+	if err := s.get(ctx, "/v3/systems/go/packages/"+name, &pkg); err != nil {
+		return 0, err
+	}
+
+	version := ""
+	for _, v := range pkg.Versions {
+		if v.IsDefault {
+			version = v.VersionKey.Version
+		}
+	}
+	if version == "" {
+		return 0, fmt.Errorf("no default version: %w", ErrNotFound)
+	}
+
+	var dependents struct {
+		DependentCount int `json:"dependentCount"`
+	}
+	path := "/v3alpha/systems/go/packages/" + name + "/versions/" + url.PathEscape(version) + ":dependents"
+	if err := s.get(ctx, path, &dependents); err != nil {
+		return 0, err
+	}
+	return dependents.DependentCount, nil
+}
+
+// get decodes the JSON response of the deps.dev API at path into v.
+func (s *DepsDev) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, depsDevURL+path, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return newStatusError(resp)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
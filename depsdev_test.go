@@ -0,0 +1,39 @@
+package pkgimporters
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestDepsDevCount(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://api.deps.dev/v3/systems/go/packages/github.com%2Fspf13%2Fcobra": []byte(`{
+			"packageKey": {"system": "GO", "name": "github.com/spf13/cobra"},
+			"versions": [
+				{"versionKey": {"system": "GO", "name": "github.com/spf13/cobra", "version": "v1.8.0"}, "isDefault": false},
+				{"versionKey": {"system": "GO", "name": "github.com/spf13/cobra", "version": "v1.8.1"}, "isDefault": true}
+			]
+		}`),
+		"https://api.deps.dev/v3alpha/systems/go/packages/github.com%2Fspf13%2Fcobra/versions/v1.8.1:dependents": []byte(`{
+			"dependentCount": 171234,
+			"directDependentCount": 34567,
+			"indirectDependentCount": 136667
+		}`),
+	}}
+	source := &DepsDev{
+		HTTPClient: &http.Client{Transport: transport},
+	}
+
+	count, err := source.Count(t.Context(), "github.com/spf13/cobra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 171234 {
+		t.Errorf("expected count 171234, got %d", count)
+	}
+
+	if _, err := source.Count(t.Context(), "fmt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for fmt, got %v", err)
+	}
+}
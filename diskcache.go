@@ -0,0 +1,170 @@
+package pkgimporters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DiskCache is a Cache that keeps entries as JSON files in a directory,
+// so fetched counts survive between runs and can be served offline.
+// Each package is stored in its own file, named after the package path.
+type DiskCache struct {
+	// Dir is the directory holding the cache files. It is created on first Set.
+	Dir string
+}
+
+type diskCacheEntry struct {
+	Count        int       `json:"count"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Expires      time.Time `json:"expires"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+
+	CanonicalPath string    `json:"canonical_path,omitempty"`
+	Modules       int       `json:"modules,omitempty"`
+	Examples      []string  `json:"examples,omitempty"`
+	License       string    `json:"license,omitempty"`
+	Version       string    `json:"version,omitempty"`
+	Published     time.Time `json:"published,omitzero"`
+	Imports       *int      `json:"imports,omitempty"`
+
+	Redistributable *bool `json:"redistributable,omitempty"`
+}
+
+func (e diskCacheEntry) cacheEntry() CacheEntry {
+	return CacheEntry{
+		Count:           e.Count,
+		FetchedAt:       e.FetchedAt,
+		Validators:      Validators{ETag: e.ETag, LastModified: e.LastModified},
+		CanonicalPath:   e.CanonicalPath,
+		Modules:         e.Modules,
+		Examples:        e.Examples,
+		License:         e.License,
+		Version:         e.Version,
+		Published:       e.Published,
+		Imports:         e.Imports,
+		Redistributable: e.Redistributable,
+	}
+}
+
+// Get implements Cache.
+func (c *DiskCache) Get(ctx context.Context, pkgPath string) (CacheEntry, bool, error) {
+	e, ok, err := c.read(pkgPath)
+	if err != nil || !ok || !time.Now().Before(e.Expires) {
+		return CacheEntry{}, false, err
+	}
+	return e.cacheEntry(), true, nil
+}
+
+// GetStale implements StaleCache.
+func (c *DiskCache) GetStale(ctx context.Context, pkgPath string) (CacheEntry, bool, error) {
+	e, ok, err := c.read(pkgPath)
+	if err != nil || !ok {
+		return CacheEntry{}, false, err
+	}
+	return e.cacheEntry(), true, nil
+}
+
+// Set implements Cache.
+// The file is replaced atomically, so concurrent readers never see a partial entry.
+func (c *DiskCache) Set(ctx context.Context, pkgPath string, entry CacheEntry, ttl time.Duration) error {
+	name, err := c.file(pkgPath)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(diskCacheEntry{
+		Count:           entry.Count,
+		FetchedAt:       entry.FetchedAt,
+		Expires:         time.Now().Add(ttl),
+		ETag:            entry.Validators.ETag,
+		LastModified:    entry.Validators.LastModified,
+		CanonicalPath:   entry.CanonicalPath,
+		Modules:         entry.Modules,
+		Examples:        entry.Examples,
+		License:         entry.License,
+		Version:         entry.Version,
+		Published:       entry.Published,
+		Imports:         entry.Imports,
+		Redistributable: entry.Redistributable,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(name, data)
+}
+
+// writeFileAtomic writes data to the file name, creating its directory,
+// so that concurrent readers see either the old or the new content.
+func writeFileAtomic(name string, data []byte) error {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// read returns the stored entry for pkgPath, expired or not.
+func (c *DiskCache) read(pkgPath string) (diskCacheEntry, bool, error) {
+	name, err := c.file(pkgPath)
+	if err != nil {
+		return diskCacheEntry{}, false, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return diskCacheEntry{}, false, nil
+	}
+	if err != nil {
+		return diskCacheEntry{}, false, err
+	}
+
+	var e diskCacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return diskCacheEntry{}, false, fmt.Errorf("decode %s: %w", name, err)
+	}
+	return e, true, nil
+}
+
+// file returns the name of the cache file for pkgPath.
+// Upper-case letters are escaped as '!' followed by the lower-case letter, as in the module cache,
+// so that paths differing only in case do not collide on case-insensitive file systems.
+func (c *DiskCache) file(pkgPath string) (string, error) {
+	var b strings.Builder
+	for _, r := range pkgPath {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	rel := filepath.FromSlash(b.String()) + ".json"
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("invalid package path %q", pkgPath)
+	}
+	return filepath.Join(c.Dir, rel), nil
+}
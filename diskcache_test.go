@@ -0,0 +1,44 @@
+package pkgimporters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiskCache(t *testing.T) {
+	c := &DiskCache{Dir: t.TempDir()}
+	ctx := t.Context()
+
+	if _, ok, err := c.Get(ctx, "github.com/BurntSushi/toml"); err != nil || ok {
+		t.Fatalf("expected miss on empty cache, got ok=%t err=%v", ok, err)
+	}
+
+	entry := CacheEntry{Count: 42, FetchedAt: time.Now()}
+	if err := c.Set(ctx, "github.com/BurntSushi/toml", entry, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "github.com/burntsushi/toml", CacheEntry{Count: 1}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := (&DiskCache{Dir: c.Dir}).Get(ctx, "github.com/BurntSushi/toml")
+	if err != nil || !ok {
+		t.Fatalf("expected hit from a new DiskCache, got ok=%t err=%v", ok, err)
+	}
+	if got.Count != 42 || !got.FetchedAt.Equal(entry.FetchedAt) {
+		t.Errorf("expected %+v, got %+v", entry, got)
+	}
+
+	if err := c.Set(ctx, "io", entry, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "io"); ok {
+		t.Error("expected miss for expired entry")
+	}
+	if got, ok, err := c.GetStale(ctx, "io"); err != nil || !ok || got.Count != 42 {
+		t.Errorf("expected stale entry with count 42, got %+v ok=%t err=%v", got, ok, err)
+	}
+
+	if err := c.Set(ctx, "../escape", entry, time.Hour); err == nil {
+		t.Error("expected error for a path outside the cache directory")
+	}
+}
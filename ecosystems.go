@@ -0,0 +1,77 @@
+package pkgimporters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const ecosystemsURL = "https://packages.ecosyste.ms"
+
+// Ecosystems is a Source that reports the number of dependent packages of a Go module
+// from the ecosyste.ms packages API (https://packages.ecosyste.ms/docs).
+// ecosyste.ms indexes modules from proxy.golang.org, so the path must be a module path.
+type Ecosystems struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// EcosystemsPackage is the metadata ecosyste.ms reports for a Go module.
+type EcosystemsPackage struct {
+	Name                   string   `json:"name"`
+	Description            string   `json:"description"`
+	RepositoryURL          string   `json:"repository_url"`
+	Licenses               string   `json:"licenses"`
+	LatestReleaseNumber    string   `json:"latest_release_number"`
+	Keywords               []string `json:"keywords"`
+	DependentPackagesCount int      `json:"dependent_packages_count"`
+	DependentReposCount    int      `json:"dependent_repos_count"`
+}
+
+// Count returns the number of packages depending on the module.
+// It returns ErrNotFound if ecosyste.ms does not know the module.
+func (s *Ecosystems) Count(ctx context.Context, pkgPath string) (int, error) {
+	pkg, err := s.Package(ctx, pkgPath)
+	if err != nil {
+		return 0, err
+	}
+	return pkg.DependentPackagesCount, nil
+}
+
+// Package returns the metadata of the module.
+// It returns ErrNotFound if ecosyste.ms does not know the module.
+func (s *Ecosystems) Package(ctx context.Context, pkgPath string) (*EcosystemsPackage, error) {
+	u := ecosystemsURL + "/api/v1/registries/proxy.golang.org/packages/" + url.PathEscape(pkgPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, newStatusError(resp)
+	}
+
+	var pkg EcosystemsPackage
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&pkg); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &pkg, nil
+}
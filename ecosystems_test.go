@@ -0,0 +1,46 @@
+package pkgimporters
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestEcosystemsCount(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://packages.ecosyste.ms/api/v1/registries/proxy.golang.org/packages/github.com%2Fspf13%2Fcobra": []byte(`{
+			"name": "github.com/spf13/cobra",
+			"ecosystem": "go",
+			"description": "A Commander for modern Go CLI interactions",
+			"repository_url": "https://github.com/spf13/cobra",
+			"licenses": "apache-2.0",
+			"latest_release_number": "v1.8.1",
+			"keywords": ["cli", "go"],
+			"dependent_packages_count": 54321,
+			"dependent_repos_count": 123456
+		}`),
+	}}
+	source := &Ecosystems{
+		HTTPClient: &http.Client{Transport: transport},
+	}
+
+	count, err := source.Count(t.Context(), "github.com/spf13/cobra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 54321 {
+		t.Errorf("expected count 54321, got %d", count)
+	}
+
+	pkg, err := source.Package(t.Context(), "github.com/spf13/cobra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Licenses != "apache-2.0" || pkg.LatestReleaseNumber != "v1.8.1" || pkg.DependentReposCount != 123456 {
+		t.Errorf("unexpected package metadata: %+v", pkg)
+	}
+
+	if _, err := source.Count(t.Context(), "example.com/unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package pkgimporters
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Enricher adds metadata from another service to the result of a package,
+// e.g., its known vulnerabilities, for reports that combine popularity with other signals.
+type Enricher interface {
+	// Enrich sets the fields of r it provides. r.Count and r.CanonicalPath are already set.
+	Enrich(ctx context.Context, r *Result) error
+}
+
+// enrich applies c.Enrichers to r. A failed enricher leaves its fields unset and is logged,
+// as the count is still valid.
+func (c *Client) enrich(ctx context.Context, r *Result) {
+	for _, e := range c.Enrichers {
+		ctx, cancel := context.WithTimeout(ctx, cmp.Or(c.Timeout, DefaultTimeout))
+		err := e.Enrich(ctx, r)
+		cancel()
+		if err != nil {
+			c.logger().WarnContext(ctx, "enrich failed", "pkg", r.Path, "err", err)
+		}
+	}
+}
+
+// repoMemo memoizes a lookup per repository, so an enricher requests each repository once
+// however many of its packages are enriched. The zero value is ready to use.
+type repoMemo[T any] struct {
+	mu     sync.Mutex
+	values map[string]T
+	flight singleflight.Group
+}
+
+// get returns the value for the repository key, calling fetch if it is not memoized yet.
+// Concurrent calls for the same key share a single fetch; failed fetches are not memoized.
+func (m *repoMemo[T]) get(key string, fetch func() (T, error)) (T, error) {
+	key = strings.ToLower(key)
+	m.mu.Lock()
+	v, ok := m.values[key]
+	m.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	res, err, _ := m.flight.Do(key, func() (any, error) {
+		v, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.values == nil {
+			m.values = make(map[string]T)
+		}
+		m.values[key] = v
+		return v, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return res.(T), nil
+}
+
+// repoPath returns the repository path "host/owner/repo" of the package of r, preferring its canonical path,
+// or "" if the package is not hosted on one of hosts.
+func repoPath(r *Result, hosts ...string) string {
+	pkgPath := cmp.Or(r.CanonicalPath, r.Path)
+	elems := strings.Split(pkgPath, "/")
+	if len(elems) < 3 || !slices.Contains(hosts, elems[0]) {
+		return ""
+	}
+	return strings.Join(elems[:3], "/")
+}
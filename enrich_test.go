@@ -0,0 +1,58 @@
+package pkgimporters
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// enricherFunc is an Enricher calling a function, for tests.
+type enricherFunc func(ctx context.Context, r *Result) error
+
+func (f enricherFunc) Enrich(ctx context.Context, r *Result) error {
+	return f(ctx, r)
+}
+
+func TestClientEnrichers(t *testing.T) {
+	var calls []string
+	client := &Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			return 42, nil
+		}),
+		Enrichers: []Enricher{
+			enricherFunc(func(ctx context.Context, r *Result) error {
+				calls = append(calls, r.Path)
+				r.Vulns = []string{"GO-2024-0001"}
+				return nil
+			}),
+			enricherFunc(func(ctx context.Context, r *Result) error {
+				return errors.New("unavailable")
+			}),
+		},
+		RequestsPerSecond: 100,
+	}
+
+	// The second lookup is a cache hit, which is enriched too.
+	for range 2 {
+		results, err := client.ImporterCounts(t.Context(), []string{"example.com/a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := results[0]; r.Count != 42 || !slices.Equal(r.Vulns, []string{"GO-2024-0001"}) {
+			t.Errorf("expected count 42 with a vuln, got %+v", r)
+		}
+	}
+	if want := []string{"example.com/a", "example.com/a"}; !slices.Equal(calls, want) {
+		t.Errorf("expected enricher calls %v, got %v", want, calls)
+	}
+
+	client.Offline = true
+	calls = nil
+	if _, err := client.ImporterCount(t.Context(), "example.com/a"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected no enricher calls in offline mode, got %v", calls)
+	}
+}
@@ -0,0 +1,68 @@
+package pkgimporters
+
+import (
+	"context"
+	"strings"
+)
+
+// Fallback is a Source that tries each of its sources in order
+// until one returns a count without error, e.g., deps.dev when pkg.go.dev fails.
+type Fallback struct {
+	Sources []Source
+}
+
+// Count returns the count from the first source that succeeds.
+// If all sources fail, the returned error wraps each of their errors,
+// so it wraps ErrNotFound if any source did not know the package.
+func (f *Fallback) Count(ctx context.Context, pkgPath string) (int, error) {
+	resp, err := f.CountIfModified(ctx, pkgPath, Validators{})
+	return resp.Count, err
+}
+
+// CountIfModified implements ConditionalSource.
+// It tries the sources in order like Count, sending prev to those that are ConditionalSources,
+// so the response of the first source that succeeds keeps its validators, canonical path, and details.
+// Sources that are not ConditionalSources report only the count.
+func (f *Fallback) CountIfModified(ctx context.Context, pkgPath string, prev Validators) (Response, error) {
+	var errs fallbackError
+	for _, s := range f.Sources {
+		var (
+			resp Response
+			err  error
+		)
+		if cs, ok := s.(ConditionalSource); ok {
+			resp, err = cs.CountIfModified(ctx, pkgPath, prev)
+		} else {
+			resp.Count, err = s.Count(ctx, pkgPath)
+		}
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return Response{}, ErrNotFound
+	}
+	return Response{}, errs
+}
+
+// fallbackError records the error of each source tried by Fallback.
+type fallbackError []error
+
+func (e fallbackError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+		if i > 0 {
+			msgs[i] = "fallback: " + msgs[i]
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e fallbackError) Unwrap() []error {
+	return e
+}
@@ -0,0 +1,92 @@
+package pkgimporters
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestFallbackCount(t *testing.T) {
+	errBoom := errors.New("boom")
+	var calls []string
+	source := func(name string, count int, err error) Source {
+		return sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			calls = append(calls, name)
+			return count, err
+		})
+	}
+
+	f := &Fallback{Sources: []Source{source("primary", 0, errBoom), source("secondary", 42, nil), source("unused", 1, nil)}}
+	count, err := f.Count(t.Context(), "fmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 42 {
+		t.Errorf("expected count 42, got %d", count)
+	}
+	if want := []string{"primary", "secondary"}; !slices.Equal(calls, want) {
+		t.Errorf("expected calls %v, got %v", want, calls)
+	}
+
+	f = &Fallback{Sources: []Source{source("primary", 0, errBoom), source("secondary", 0, ErrNotFound)}}
+	_, err = f.Count(t.Context(), "fmt")
+	if !errors.Is(err, errBoom) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected error wrapping both errors, got %v", err)
+	}
+	if want := "boom; fallback: package not found"; err.Error() != want {
+		t.Errorf("expected error %q, got %q", want, err)
+	}
+}
+
+func TestFallbackCountIfModified(t *testing.T) {
+	prev := Validators{ETag: `"v1"`}
+	var gotPrev Validators
+	conditional := conditionalSourceFunc(func(ctx context.Context, pkgPath string, prev Validators) (Response, error) {
+		gotPrev = prev
+		return Response{NotModified: true, Validators: prev, CanonicalPath: "github.com/new/cobra"}, nil
+	})
+
+	f := &Fallback{Sources: []Source{conditional, sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+		t.Error("expected the fallback source to be skipped")
+		return 0, nil
+	})}}
+	resp, err := f.CountIfModified(t.Context(), "github.com/old/cobra", prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPrev != prev {
+		t.Errorf("expected validators %v to be sent, got %v", prev, gotPrev)
+	}
+	if !resp.NotModified || resp.CanonicalPath != "github.com/new/cobra" {
+		t.Errorf("expected the response of the conditional source, got %+v", resp)
+	}
+
+	f = &Fallback{Sources: []Source{
+		conditionalSourceFunc(func(ctx context.Context, pkgPath string, prev Validators) (Response, error) {
+			return Response{}, ErrParse
+		}),
+		sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			return 42, nil
+		}),
+	}}
+	resp, err = f.CountIfModified(t.Context(), "fmt", prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Response{Count: 42}); !reflect.DeepEqual(resp, want) {
+		t.Errorf("expected %+v, got %+v", want, resp)
+	}
+}
+
+type conditionalSourceFunc func(ctx context.Context, pkgPath string, prev Validators) (Response, error)
+
+func (f conditionalSourceFunc) Count(ctx context.Context, pkgPath string) (int, error) {
+	resp, err := f(ctx, pkgPath, Validators{})
+	return resp.Count, err
+}
+
+func (f conditionalSourceFunc) CountIfModified(ctx context.Context, pkgPath string, prev Validators) (Response, error) {
+	return f(ctx, pkgPath, prev)
+}
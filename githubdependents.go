@@ -0,0 +1,72 @@
+package pkgimporters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const githubURL = "https://github.com"
+
+// GitHubDependents is a Source that scrapes the number of dependent repositories
+// ("Used by") from the dependency graph of the GitHub repository hosting a module.
+// It is useful as a cross-check against pkg.go.dev numbers.
+type GitHubDependents struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+var dependentsRe = regexp.MustCompile(`([\d,]+)\s*Repositor(?:y|ies)`)
+
+// Count returns the number of repositories depending on the GitHub repository of pkgPath.
+// It returns an error wrapping ErrNotFound if pkgPath is not under github.com
+// or the repository does not exist, and an error wrapping ErrParse if the page shows no count.
+func (s *GitHubDependents) Count(ctx context.Context, pkgPath string) (int, error) {
+	elems := strings.Split(pkgPath, "/")
+	if len(elems) < 3 || elems[0] != "github.com" {
+		return 0, fmt.Errorf("not hosted on GitHub: %w", ErrNotFound)
+	}
+	url := githubURL + "/" + elems[1] + "/" + elems[2] + "/network/dependents?dependent_type=REPOSITORY"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return 0, fmt.Errorf("new request: %w", err)
+	}
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return 0, ErrNotFound
+	default:
+		return 0, newStatusError(resp)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, fmt.Errorf("read body: %w", err)
+	}
+
+	m := dependentsRe.FindSubmatch(body)
+	if m == nil {
+		return 0, fmt.Errorf("dependents count not found on page: %w", ErrParse)
+	}
+	count, err := strconv.Atoi(strings.ReplaceAll(string(m[1]), ",", ""))
+	if err != nil {
+		return 0, fmt.Errorf("parse count: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,47 @@
+package pkgimporters
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestGitHubDependentsCount(t *testing.T) {
+	const page = `<div class="table-list-header-toggle states flex-auto pl-0">
+  <a class="btn-link selected" href="/spf13/cobra/network/dependents?dependent_type=REPOSITORY">
+    <svg aria-hidden="true" height="16" viewBox="0 0 16 16" version="1.1" width="16" class="octicon octicon-code-square"></svg>
+    171,234
+    Repositories
+  </a>
+  <a class="btn-link " href="/spf13/cobra/network/dependents?dependent_type=PACKAGE">
+    1,234
+    Packages
+  </a>
+</div>`
+
+	transport := &urlTransport{files: map[string][]byte{
+		"https://github.com/spf13/cobra/network/dependents?dependent_type=REPOSITORY":   []byte(page),
+		"https://github.com/spf13/private/network/dependents?dependent_type=REPOSITORY": []byte(`<div class="blankslate">Dependency graph not enabled</div>`),
+	}}
+	source := &GitHubDependents{
+		HTTPClient: &http.Client{Transport: transport},
+	}
+
+	count, err := source.Count(t.Context(), "github.com/spf13/cobra/doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 171234 {
+		t.Errorf("expected count 171234, got %d", count)
+	}
+
+	for _, path := range []string{"fmt", "go.uber.org/zap", "github.com/spf13/unknown"} {
+		if _, err := source.Count(t.Context(), path); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound for %s, got %v", path, err)
+		}
+	}
+
+	if _, err := source.Count(t.Context(), "github.com/spf13/private"); !errors.Is(err, ErrParse) {
+		t.Errorf("expected ErrParse for a page without a count, got %v", err)
+	}
+}
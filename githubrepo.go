@@ -0,0 +1,100 @@
+package pkgimporters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const githubAPIURL = "https://api.github.com"
+
+// GitHubRepo is the popularity of the GitHub repository hosting a package.
+type GitHubRepo struct {
+	// Name is the repository name with its owner, e.g., "spf13/cobra".
+	Name  string `json:"name"`
+	Stars int    `json:"stars"`
+	Forks int    `json:"forks"`
+}
+
+// GitHubStars is an Enricher that adds the star and fork counts of the GitHub repository hosting a package
+// from the GitHub REST API (https://docs.github.com/rest/repos/repos#get-a-repository),
+// so popularity on pkg.go.dev can be compared with repository popularity.
+// Only packages under github.com are enriched; vanity import paths are not resolved.
+// Each repository is requested once, however many of its packages are enriched.
+// A GitHubStars must not be copied after first use.
+type GitHubStars struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Token, if set, authenticates requests, raising the API rate limit from 60 requests per hour.
+	Token string
+
+	repos repoMemo[*GitHubRepo]
+}
+
+// Enrich implements Enricher, setting r.GitHub, or leaving it nil
+// if the package is not hosted on GitHub or its repository does not exist.
+// The repository is that of the canonical path if the package was redirected.
+func (s *GitHubStars) Enrich(ctx context.Context, r *Result) error {
+	path := repoPath(r, "github.com")
+	if path == "" {
+		return nil
+	}
+	repo, err := s.Repo(ctx, strings.TrimPrefix(path, "github.com/"))
+	if err != nil {
+		return fmt.Errorf("github: %w", err)
+	}
+	r.GitHub = repo
+	return nil
+}
+
+// Repo returns the popularity of the GitHub repository with the given "owner/name",
+// or nil if the repository does not exist.
+func (s *GitHubStars) Repo(ctx context.Context, name string) (*GitHubRepo, error) {
+	return s.repos.get(name, func() (*GitHubRepo, error) {
+		return s.fetch(ctx, name)
+	})
+}
+
+func (s *GitHubStars) fetch(ctx context.Context, name string) (*GitHubRepo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, githubAPIURL+"/repos/"+name, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, newStatusError(resp)
+	}
+
+	var repo struct {
+		FullName        string `json:"full_name"`
+		StargazersCount int    `json:"stargazers_count"`
+		ForksCount      int    `json:"forks_count"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&repo); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &GitHubRepo{Name: repo.FullName, Stars: repo.StargazersCount, Forks: repo.ForksCount}, nil
+}
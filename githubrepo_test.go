@@ -0,0 +1,38 @@
+package pkgimporters
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGitHubStarsEnrich(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://api.github.com/repos/spf13/cobra": []byte(`{"full_name": "spf13/cobra", "stargazers_count": 39512, "forks_count": 2889}`),
+	}}
+	enricher := &GitHubStars{HTTPClient: &http.Client{Transport: transport}}
+
+	want := &GitHubRepo{Name: "spf13/cobra", Stars: 39512, Forks: 2889}
+	for _, path := range []string{"github.com/spf13/cobra", "github.com/spf13/cobra/doc"} {
+		r := Result{Path: path}
+		if err := enricher.Enrich(t.Context(), &r); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(r.GitHub, want) {
+			t.Errorf("%s: expected %+v, got %+v", path, want, r.GitHub)
+		}
+	}
+	if len(transport.requestedURLs) != 1 {
+		t.Errorf("expected a single request for the repository, got %v", transport.requestedURLs)
+	}
+
+	for _, r := range []Result{
+		{Path: "fmt"},
+		{Path: "go.uber.org/zap"},
+		{Path: "github.com/example/missing"},
+	} {
+		if err := enricher.Enrich(t.Context(), &r); err != nil || r.GitHub != nil {
+			t.Errorf("%s: expected no repository, got %+v, %v", r.Path, r.GitHub, err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package pkgimporters
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultMaxGraphPackages is the default maximum number of packages whose importers Graph lists.
+const DefaultMaxGraphPackages = 1000
+
+// Graph is a reverse-dependency graph: the packages importing Root,
+// the packages importing those, and so on.
+type Graph struct {
+	// Root is the package the graph was built from.
+	Root string `json:"root"`
+
+	// Importers maps each expanded package to the packages importing it, in the order of the source.
+	// Packages at the maximum depth are not expanded.
+	Importers map[string][]string `json:"importers"`
+
+	// Depth maps every package in the graph to its distance from Root, which is at depth 0.
+	Depth map[string]int `json:"depth"`
+
+	// Truncated reports whether the graph is missing importers,
+	// because GraphOptions.MaxPackages was reached or a source listed only some importers.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Errors maps the packages whose importers could not be listed to the error message.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Packages returns the packages in the graph ordered by depth, then by path.
+func (g Graph) Packages() []string {
+	pkgs := slices.Collect(maps.Keys(g.Depth))
+	slices.SortFunc(pkgs, func(a, b string) int {
+		return cmp.Or(cmp.Compare(g.Depth[a], g.Depth[b]), cmp.Compare(a, b))
+	})
+	return pkgs
+}
+
+// WriteDOT writes the graph to w in the Graphviz DOT language,
+// with an edge from each importer to the imported package and the root drawn as a box.
+func (g Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph importers {")
+	pkgs := g.Packages()
+	for _, pkg := range pkgs {
+		attrs := fmt.Sprintf("depth=%d", g.Depth[pkg])
+		if pkg == g.Root {
+			attrs += ", shape=box"
+		}
+		fmt.Fprintf(bw, "\t%s [%s];\n", strconv.Quote(pkg), attrs)
+	}
+	for _, pkg := range pkgs {
+		for _, importer := range g.Importers[pkg] {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", strconv.Quote(importer), strconv.Quote(pkg))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"http://graphml.graphdrawing.org/xmlns graphml"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+// WriteGraphML writes the graph to w in GraphML, with an edge from each importer to the imported package
+// and the distance of each package from the root in its "depth" data.
+func (g Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		Keys:  []graphMLKey{{ID: "depth", For: "node", AttrName: "depth", AttrType: "int"}},
+		Graph: graphMLGraph{ID: "importers", EdgeDefault: "directed"},
+	}
+	pkgs := g.Packages()
+	for _, pkg := range pkgs {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID:   pkg,
+			Data: []graphMLData{{Key: "depth", Value: strconv.Itoa(g.Depth[pkg])}},
+		})
+	}
+	for _, pkg := range pkgs {
+		for _, importer := range g.Importers[pkg] {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: importer, Target: pkg})
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// GraphOptions configures Client.Graph.
+type GraphOptions struct {
+	// Depth is the maximum distance from the root whose importers are listed:
+	// 1 lists the direct importers only, 2 also their importers, and so on. If 0, 1 is used.
+	Depth int
+
+	// MaxPackages is the maximum number of packages whose importers are listed.
+	// If 0, DefaultMaxGraphPackages is used.
+	MaxPackages int
+}
+
+// Graph builds the reverse-dependency graph of pkgPath by listing the importers of pkgPath,
+// then of those importers, and so on, up to opts.Depth.
+// Each package is expanded once, even if it is reached by several paths,
+// and the packages of a level are expanded concurrently by Workers, rate limited like ImporterCount.
+// Packages that cannot be listed are recorded in Graph.Errors rather than failing the whole graph,
+// except pkgPath itself.
+// The Source must be an ImportersLister, as for Importers.
+func (c *Client) Graph(ctx context.Context, pkgPath string, opts GraphOptions) (Graph, error) {
+	depth := max(opts.Depth, 1)
+	maxPackages := cmp.Or(opts.MaxPackages, DefaultMaxGraphPackages)
+	g := Graph{
+		Root:      pkgPath,
+		Importers: make(map[string][]string),
+		Depth:     map[string]int{pkgPath: 0},
+		Errors:    make(map[string]string),
+	}
+
+	frontier := []string{pkgPath}
+	expanded := 0
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		if n := maxPackages - expanded; len(frontier) > n {
+			c.logger().InfoContext(ctx, "graph truncated", "pkg", pkgPath, "depth", level, "max_packages", maxPackages)
+			frontier, g.Truncated = frontier[:n], true
+		}
+		lists, errs := c.importersOf(ctx, frontier)
+		expanded += len(frontier)
+
+		var next []string
+		for i, path := range frontier {
+			if err := errs[i]; err != nil {
+				if ctx.Err() != nil || path == pkgPath {
+					return Graph{}, err
+				}
+				c.logger().InfoContext(ctx, "list importers failed", "pkg", path, "err", err)
+				var pkgErr *PackageError
+				if errors.As(err, &pkgErr) {
+					err = pkgErr.Err
+				}
+				g.Errors[path] = err.Error()
+				continue
+			}
+			g.Importers[path] = lists[i].Paths
+			g.Truncated = g.Truncated || lists[i].Truncated
+			for _, importer := range lists[i].Paths {
+				if _, ok := g.Depth[importer]; !ok {
+					g.Depth[importer] = level + 1
+					next = append(next, importer)
+				}
+			}
+		}
+		frontier = next
+	}
+	return g, nil
+}
+
+// importersOf lists the importers of pkgPaths concurrently,
+// returning the lists and errors in the order of pkgPaths.
+func (c *Client) importersOf(ctx context.Context, pkgPaths []string) ([]ImporterList, []error) {
+	lists := make([]ImporterList, len(pkgPaths))
+	errs := make([]error, len(pkgPaths))
+	var g errgroup.Group
+	workers := c.workers()
+	if c.Workers == AutoWorkers {
+		workers = c.autoWorkers()
+	}
+	g.SetLimit(workers)
+	for i, path := range pkgPaths {
+		g.Go(func() error {
+			lists[i], errs[i] = c.Importers(ctx, path)
+			return nil
+		})
+	}
+	g.Wait()
+	return lists, errs
+}
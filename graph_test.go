@@ -0,0 +1,158 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// importersSource is an ImportersLister that lists the importers of each package from a map.
+type importersSource map[string][]string
+
+func (s importersSource) Count(ctx context.Context, pkgPath string) (int, error) {
+	l, err := s.Importers(ctx, pkgPath)
+	return len(l.Paths), err
+}
+
+func (s importersSource) Importers(ctx context.Context, pkgPath string) (ImporterList, error) {
+	importers, ok := s[pkgPath]
+	if !ok {
+		return ImporterList{}, ErrNotFound
+	}
+	return ImporterList{Paths: importers, Count: len(importers)}, nil
+}
+
+func TestClientGraph(t *testing.T) {
+	source := importersSource{
+		"example.com/lib": {"example.com/a", "example.com/b"},
+		"example.com/a":   {"example.com/b", "example.com/c"},
+		"example.com/b":   {"example.com/d"},
+		"example.com/c":   {"example.com/a"},
+		"example.com/d":   {},
+	}
+	c := &Client{Source: source, RequestsPerSecond: 1000, Burst: 100}
+
+	t.Run("depth", func(t *testing.T) {
+		got, err := c.Graph(t.Context(), "example.com/lib", GraphOptions{Depth: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Graph{
+			Root: "example.com/lib",
+			Importers: map[string][]string{
+				"example.com/lib": {"example.com/a", "example.com/b"},
+				"example.com/a":   {"example.com/b", "example.com/c"},
+				"example.com/b":   {"example.com/d"},
+			},
+			Depth: map[string]int{
+				"example.com/lib": 0,
+				"example.com/a":   1,
+				"example.com/b":   1,
+				"example.com/c":   2,
+				"example.com/d":   2,
+			},
+			Errors: map[string]string{},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+
+		wantPkgs := []string{"example.com/lib", "example.com/a", "example.com/b", "example.com/c", "example.com/d"}
+		if pkgs := got.Packages(); !slices.Equal(pkgs, wantPkgs) {
+			t.Errorf("expected packages %v, got %v", wantPkgs, pkgs)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		got, err := c.Graph(t.Context(), "example.com/lib", GraphOptions{Depth: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Importers) != 5 || got.Truncated {
+			t.Errorf("expected all 5 packages to be expanded once, got %+v", got)
+		}
+	})
+
+	t.Run("max packages", func(t *testing.T) {
+		got, err := c.Graph(t.Context(), "example.com/lib", GraphOptions{Depth: 3, MaxPackages: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Importers) != 2 || !got.Truncated {
+			t.Errorf("expected a truncated graph with 2 expanded packages, got %+v", got)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		c := &Client{Source: importersSource{"example.com/lib": {"example.com/gone"}}, RequestsPerSecond: 1000}
+		got, err := c.Graph(t.Context(), "example.com/lib", GraphOptions{Depth: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"example.com/gone": ErrNotFound.Error()}; !reflect.DeepEqual(got.Errors, want) {
+			t.Errorf("expected errors %v, got %v", want, got.Errors)
+		}
+
+		if _, err := c.Graph(t.Context(), "example.com/missing", GraphOptions{}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound for an unknown root, got %v", err)
+		}
+	})
+}
+
+func TestGraphWrite(t *testing.T) {
+	g := Graph{
+		Root: "example.com/lib",
+		Importers: map[string][]string{
+			"example.com/lib": {"example.com/a", "example.com/b"},
+			"example.com/a":   {"example.com/b"},
+		},
+		Depth: map[string]int{"example.com/lib": 0, "example.com/a": 1, "example.com/b": 1},
+	}
+
+	var dot bytes.Buffer
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	wantDOT := `digraph importers {
+	"example.com/lib" [depth=0, shape=box];
+	"example.com/a" [depth=1];
+	"example.com/b" [depth=1];
+	"example.com/a" -> "example.com/lib";
+	"example.com/b" -> "example.com/lib";
+	"example.com/b" -> "example.com/a";
+}
+`
+	if got := dot.String(); got != wantDOT {
+		t.Errorf("expected DOT:\n%s\ngot:\n%s", wantDOT, got)
+	}
+
+	var graphML bytes.Buffer
+	if err := g.WriteGraphML(&graphML); err != nil {
+		t.Fatal(err)
+	}
+	wantGraphML := `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="depth" for="node" attr.name="depth" attr.type="int"></key>
+  <graph id="importers" edgedefault="directed">
+    <node id="example.com/lib">
+      <data key="depth">0</data>
+    </node>
+    <node id="example.com/a">
+      <data key="depth">1</data>
+    </node>
+    <node id="example.com/b">
+      <data key="depth">1</data>
+    </node>
+    <edge source="example.com/a" target="example.com/lib"></edge>
+    <edge source="example.com/b" target="example.com/lib"></edge>
+    <edge source="example.com/b" target="example.com/a"></edge>
+  </graph>
+</graphml>
+`
+	if got := graphML.String(); got != wantGraphML {
+		t.Errorf("expected GraphML:\n%s\ngot:\n%s", wantGraphML, got)
+	}
+}
@@ -0,0 +1,59 @@
+package pkgimporters
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// hedge returns the result of count, calling it a second time
+// if the first call has not returned after c.HedgeDelay and the rate limiter allows another request.
+// The first successful call wins and the other is canceled;
+// if both fail, the error of the first to fail is returned.
+func (c *Client) hedge(ctx context.Context, pkgPath string, count func(ctx context.Context) (Response, error)) (Response, error) {
+	if c.HedgeDelay <= 0 {
+		return count(ctx)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		resp Response
+		err  error
+	}
+	// Buffered, so the losing call never blocks after hedge returns.
+	outcomes := make(chan outcome, 2)
+	call := func() {
+		resp, err := count(ctx)
+		outcomes <- outcome{resp: resp, err: err}
+	}
+	go call()
+
+	var hedged atomic.Bool
+	timer := time.NewTimer(c.HedgeDelay)
+	defer timer.Stop()
+	go func() {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+		c.init()
+		if err := c.limiter.Wait(ctx); err != nil {
+			return
+		}
+		hedged.Store(true)
+		c.metrics().ObserveHedge()
+		c.logger().DebugContext(ctx, "hedging slow request", "pkg", pkgPath, "delay", c.HedgeDelay)
+		call()
+	}()
+
+	first := <-outcomes
+	if first.err == nil || !hedged.Load() {
+		return first.resp, first.err
+	}
+	if second := <-outcomes; second.err == nil {
+		return second.resp, nil
+	}
+	return first.resp, first.err
+}
@@ -0,0 +1,84 @@
+package pkgimporters
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientHedge(t *testing.T) {
+	t.Run("slow request", func(t *testing.T) {
+		var calls atomic.Int32
+		canceled := make(chan struct{})
+		metrics := &countingMetrics{}
+		c := &Client{
+			Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+				if calls.Add(1) == 1 {
+					<-ctx.Done()
+					close(canceled)
+					return 0, ctx.Err()
+				}
+				return 42, nil
+			}),
+			RequestsPerSecond: 100,
+			HedgeDelay:        10 * time.Millisecond,
+			Metrics:           metrics,
+		}
+		count, err := c.ImporterCount(t.Context(), "example.com/slow")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 42 {
+			t.Errorf("expected the hedged count 42, got %d", count)
+		}
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Error("expected the slow request to be canceled")
+		}
+		if got := metrics.hedged.Load(); got != 1 {
+			t.Errorf("expected 1 hedged request, got %v", got)
+		}
+	})
+
+	t.Run("fast request", func(t *testing.T) {
+		var calls atomic.Int32
+		c := &Client{
+			Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+				calls.Add(1)
+				return 7, nil
+			}),
+			RequestsPerSecond: 100,
+			HedgeDelay:        time.Second,
+		}
+		if _, err := c.ImporterCount(t.Context(), "fmt"); err != nil {
+			t.Fatal(err)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("expected 1 request, got %d", got)
+		}
+	})
+
+	t.Run("both fail", func(t *testing.T) {
+		errFirst := errors.New("first")
+		var calls atomic.Int32
+		c := &Client{
+			Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+				if calls.Add(1) == 1 {
+					time.Sleep(50 * time.Millisecond)
+					return 0, errFirst
+				}
+				time.Sleep(100 * time.Millisecond)
+				return 0, errors.New("second")
+			}),
+			RequestsPerSecond: 100,
+			Retry:             RetryPolicy{MaxAttempts: 1},
+			HedgeDelay:        10 * time.Millisecond,
+		}
+		if _, err := c.ImporterCount(t.Context(), "example.com/broken"); !errors.Is(err, errFirst) {
+			t.Errorf("expected the error of the first failure, got %v", err)
+		}
+	})
+}
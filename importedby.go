@@ -0,0 +1,291 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// legacyRes match the importer count in the markup of earlier pkg.go.dev releases.
+	legacyRes = []*regexp.Regexp{
+		regexp.MustCompile(`Known importers:\s*</strong>\s*([\d,]+)`),
+		regexp.MustCompile(`Imported by:\s*(?:<[^>]*>\s*)*([\d,]+)`),
+	}
+	numberRe = regexp.MustCompile(`\d[\d,]*`)
+	// displayingRe matches the number of importers listed on an "importedby" tab,
+	// e.g., "Known importers: 1,533,321 (displaying 20,000 packages)".
+	displayingRe = regexp.MustCompile(`displaying\s+([\d,]+)\s+packages`)
+	// majorVersionRe matches the major version suffix of a module path, e.g., "v2".
+	majorVersionRe = regexp.MustCompile(`^v[2-9]\d*$|^v[1-9]\d+$`)
+)
+
+// importedByParser is a strategy for extracting the importer count from an "importedby" tab.
+type importedByParser struct {
+	name  string
+	count func(doc *html.Node, body []byte) (int, bool)
+}
+
+// importedByParsers are tried in order until one finds the count,
+// so a pkg.go.dev redesign that breaks one of them does not break counting.
+var importedByParsers = []importedByParser{
+	{name: "markup", count: markupCount},
+	{name: "legacy", count: legacyCount},
+	{name: "json-ld", count: jsonLDCount},
+}
+
+// importedByPage is what parseImportedBy found on an "importedby" tab.
+type importedByPage struct {
+	// title is the text of the <title> element.
+	title string
+	// tab reports whether the page has an ImportedBy section.
+	tab bool
+	// count is the number of known importers. It is valid if parser is not empty.
+	count int
+	// parser is the name of the importedByParser that found count.
+	parser string
+	// none reports whether the page states that there are no known importers.
+	none bool
+	// importers are the listed importing packages, in page order.
+	importers []string
+	// displayed is the number of listed packages stated by the heading, or 0 if it states none.
+	displayed int
+}
+
+// parseImportedBy parses the "importedby" tab of a package page,
+// trying each of importedByParsers in order.
+func parseImportedBy(body []byte) importedByPage {
+	var p importedByPage
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		doc = &html.Node{Type: html.DocumentNode}
+	}
+	p.walk(doc)
+	p.importers, p.displayed = listImporters(doc)
+	for _, parser := range importedByParsers {
+		if count, ok := parser.count(doc, body); ok {
+			p.count, p.parser = count, parser.name
+			break
+		}
+	}
+	p.tab = p.tab || bytes.Contains(body, []byte(`class="ImportedBy`))
+	p.none = p.none || bytes.Contains(body, []byte("No known importers"))
+	return p
+}
+
+func (p *importedByPage) walk(n *html.Node) {
+	if n.Type == html.TextNode && strings.Contains(n.Data, "No known importers") {
+		p.none = true
+	}
+	if n.Type == html.ElementNode {
+		switch {
+		case n.DataAtom == atom.Title && p.title == "":
+			p.title = strings.TrimSpace(textContent(n))
+		case hasClass(n, "ImportedBy"):
+			p.tab = true
+		}
+	}
+	for c := range n.ChildNodes() {
+		p.walk(c)
+	}
+}
+
+// markupCount selects the count of the current pkg.go.dev markup by element structure:
+// the number following "Known importers" within the ImportedBy heading,
+// or the number in or labelled by an element whose aria-label mentions importers.
+func markupCount(doc *html.Node, _ []byte) (int, bool) {
+	for n := range doc.Descendants() {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if count, ok := importerCount(n); ok {
+			return count, true
+		}
+	}
+	return 0, false
+}
+
+// legacyCount matches the count in the raw markup of earlier pkg.go.dev releases.
+func legacyCount(_ *html.Node, body []byte) (int, bool) {
+	for _, re := range legacyRes {
+		if m := re.FindSubmatch(body); m != nil {
+			return parseNumber(string(m[1]))
+		}
+	}
+	return 0, false
+}
+
+// jsonLDCount reads the count from schema.org structured data, if present:
+// an InteractionCounter whose name or interactionType mentions importers,
+// e.g., {"@type": "InteractionCounter", "name": "Known importers", "userInteractionCount": 1234}.
+func jsonLDCount(doc *html.Node, _ []byte) (int, bool) {
+	for n := range doc.Descendants() {
+		if n.DataAtom != atom.Script || attr(n, "type") != "application/ld+json" {
+			continue
+		}
+		var data any
+		if err := json.Unmarshal([]byte(textContent(n)), &data); err != nil {
+			continue
+		}
+		if count, ok := interactionCount(data); ok {
+			return count, true
+		}
+	}
+	return 0, false
+}
+
+// interactionCount searches the decoded JSON-LD value v for an InteractionCounter of importers.
+func interactionCount(v any) (int, bool) {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			if count, ok := interactionCount(e); ok {
+				return count, true
+			}
+		}
+	case map[string]any:
+		name, _ := v["name"].(string)
+		typ, _ := v["interactionType"].(string)
+		count, isNumber := v["userInteractionCount"].(float64)
+		if v["@type"] == "InteractionCounter" && isNumber &&
+			strings.Contains(strings.ToLower(name+" "+typ), "import") {
+			return int(count), true
+		}
+		for _, e := range v {
+			if count, ok := interactionCount(e); ok {
+				return count, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// listImporters returns the importing packages listed in the ImportedBy section of an "importedby" tab,
+// in page order: the text of the first link of each list item.
+// It also returns the number of listed packages stated by the heading, or 0 if it states none.
+func listImporters(doc *html.Node) (importers []string, displayed int) {
+	for n := range doc.Descendants() {
+		if !hasClass(n, "ImportedBy") {
+			continue
+		}
+		for c := range n.Descendants() {
+			if hasClass(c, "ImportedBy-heading") {
+				if m := displayingRe.FindStringSubmatch(textContent(c)); m != nil {
+					displayed, _ = parseNumber(m[1])
+				}
+				continue
+			}
+			if c.DataAtom != atom.Li {
+				continue
+			}
+			for a := range c.Descendants() {
+				if a.DataAtom == atom.A {
+					if path := strings.TrimSpace(textContent(a)); path != "" {
+						importers = append(importers, path)
+					}
+					break
+				}
+			}
+		}
+		break
+	}
+	return importers, displayed
+}
+
+// hostingSites are the code hosting sites whose repositories are at host/owner/repo,
+// so a module path has at least three elements.
+var hostingSites = []string{"github.com", "gitlab.com", "bitbucket.org", "codeberg.org", "gitee.com", "golang.org", "git.sr.ht"}
+
+// importingModules returns the number of unique modules among the importing packages.
+// Modules are estimated from the package paths alone, see modulePathOf.
+func importingModules(pkgPaths []string) int {
+	modules := make(map[string]bool)
+	for _, path := range pkgPaths {
+		modules[modulePathOf(path)] = true
+	}
+	return len(modules)
+}
+
+// modulePathOf estimates the path of the module providing pkgPath without looking it up:
+// host/owner/repo on code hosting sites like github.com, host/name elsewhere, e.g., go.uber.org/zap,
+// followed by a major version suffix like /v2 if present.
+// It is wrong for nested modules and for modules at the root of a domain.
+func modulePathOf(pkgPath string) string {
+	elems := strings.Split(pkgPath, "/")
+	n := 2
+	if slices.Contains(hostingSites, elems[0]) {
+		n = 3
+	}
+	if n < len(elems) && majorVersionRe.MatchString(elems[n]) {
+		n++
+	}
+	return strings.Join(elems[:min(n, len(elems))], "/")
+}
+
+// importerCount returns the importer count shown by n, if n is an element that shows one.
+func importerCount(n *html.Node) (int, bool) {
+	if label := attr(n, "aria-label"); strings.Contains(strings.ToLower(label), "importers") {
+		if count, ok := parseNumber(textContent(n)); ok {
+			return count, true
+		}
+		return parseNumber(label)
+	}
+
+	// <strong>Known importers:</strong> 1,533,321 (displaying 20,000 packages)
+	if !hasClass(n, "ImportedBy-heading") && !(n.DataAtom == atom.Strong && strings.Contains(textContent(n), "Known importers")) {
+		return 0, false
+	}
+	text := textContent(n)
+	if n.DataAtom == atom.Strong {
+		for s := n.NextSibling; s != nil; s = s.NextSibling {
+			text += textContent(s)
+		}
+	}
+	_, after, ok := strings.Cut(text, "Known importers")
+	if !ok {
+		return 0, false
+	}
+	return parseNumber(after)
+}
+
+// parseNumber parses the first number in s, ignoring thousands separators.
+func parseNumber(s string) (int, bool) {
+	m := numberRe.FindString(s)
+	if m == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.ReplaceAll(m, ",", ""))
+	return n, err == nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := range n.Descendants() {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	return slices.Contains(strings.Fields(attr(n, "class")), class)
+}
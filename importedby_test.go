@@ -0,0 +1,165 @@
+package pkgimporters
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestParseImportedBy(t *testing.T) {
+	ioPage, err := os.ReadFile("testdata/io.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		page string
+		want importedByPage
+	}{
+		{
+			name: "pkg.go.dev",
+			page: string(ioPage),
+			want: importedByPage{title: "io package importedby - io - Go Packages", tab: true, count: fixtureCount(t, "io"), parser: "markup"},
+		},
+		{
+			name: "count wrapped in an element",
+			page: `<div class="ImportedBy"><div class="ImportedBy-heading"><strong>Known importers:</strong> <span>1,234</span></div></div>`,
+			want: importedByPage{tab: true, count: 1234, parser: "markup"},
+		},
+		{
+			name: "heading without strong",
+			page: `<div class="ImportedBy"><h2 class="ImportedBy-heading">Known importers: 42</h2></div>`,
+			want: importedByPage{tab: true, count: 42, parser: "markup"},
+		},
+		{
+			name: "strong in another container",
+			page: `<section class="ImportedBy"><p><strong>Known importers:</strong>
+				7 (displaying 7 packages)</p></section>`,
+			want: importedByPage{tab: true, count: 7, parser: "markup"},
+		},
+		{
+			name: "aria label",
+			page: `<div class="ImportedBy"><span aria-label="Known importers">5,485,422</span></div>`,
+			want: importedByPage{tab: true, count: 5485422, parser: "markup"},
+		},
+		{
+			name: "count in aria label",
+			page: `<div class="ImportedBy"><a aria-label="3,141 known importers">Imported by</a></div>`,
+			want: importedByPage{tab: true, count: 3141, parser: "markup"},
+		},
+		{
+			name: "legacy markup",
+			page: `<div class="ImportedBy"><p>Known importers:</strong> 12</p></div>`,
+			want: importedByPage{tab: true, count: 12, parser: "legacy"},
+		},
+		{
+			name: "legacy header",
+			page: `<div class="ImportedBy"></div><span data-test-id="UnitHeader-importedby">Imported by: <a href="?tab=importedby">2,718</a></span>`,
+			want: importedByPage{tab: true, count: 2718, parser: "legacy"},
+		},
+		{
+			name: "json-ld",
+			page: `<script type="application/ld+json">{"@context": "https://schema.org", "@type": "SoftwareSourceCode",
+				"interactionStatistic": [{"@type": "InteractionCounter", "name": "Stars", "userInteractionCount": 5},
+				{"@type": "InteractionCounter", "name": "Known importers", "userInteractionCount": 1618}]}</script>
+				<div class="ImportedBy"><p>Imported by 1,618 packages</p></div>`,
+			want: importedByPage{tab: true, count: 1618, parser: "json-ld"},
+		},
+		{
+			name: "no importers",
+			page: `<div class="ImportedBy"><p>No known importers for this package!</p></div>`,
+			want: importedByPage{tab: true, none: true},
+		},
+		{
+			name: "interstitial",
+			page: `<html><head><title> Just a moment... </title></head><body>Checking your browser</body></html>`,
+			want: importedByPage{title: "Just a moment..."},
+		},
+		{
+			name: "changed markup",
+			page: `<div class="ImportedBy"><p>Imported by 1,234 packages</p></div>`,
+			want: importedByPage{tab: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseImportedBy([]byte(tt.page))
+			got.importers, got.displayed = nil, 0 // checked by TestListImporters
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListImporters(t *testing.T) {
+	tests := []struct {
+		name          string
+		page          string
+		want          []string
+		wantDisplayed int
+	}{
+		{
+			name: "heading",
+			page: `<div class="ImportedBy"><div class="ImportedBy-heading"><strong>Known importers:</strong> 1,533,321 (displaying 20,000 packages)</div>
+				<ul class="ImportedBy-list"><li><a href="/example.com/a">example.com/a</a></li></ul></div>`,
+			want:          []string{"example.com/a"},
+			wantDisplayed: 20000,
+		},
+		{
+			name: "nested lists",
+			page: `<div class="ImportedBy"><ul><li><a href="/example.com/a">example.com/a</a>
+				<ul><li><a href="/example.com/a/b"> example.com/a/b </a></li></ul></li></ul></div>`,
+			want: []string{"example.com/a", "example.com/a/b"},
+		},
+		{
+			name: "list outside the section",
+			page: `<ul><li><a href="/">Home</a></li></ul><div class="ImportedBy"><ul><li><a>example.com/c</a></li></ul></div>`,
+			want: []string{"example.com/c"},
+		},
+		{
+			name: "no section",
+			page: `<ul><li><a href="/">Home</a></li></ul>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := parseImportedBy([]byte(tt.page))
+			if !slices.Equal(page.importers, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, page.importers)
+			}
+			if page.displayed != tt.wantDisplayed {
+				t.Errorf("expected %d displayed, got %d", tt.wantDisplayed, page.displayed)
+			}
+		})
+	}
+}
+
+func TestModulePathOf(t *testing.T) {
+	tests := []struct {
+		pkgPath string
+		want    string
+	}{
+		{"github.com/spf13/cobra", "github.com/spf13/cobra"},
+		{"github.com/spf13/cobra/doc", "github.com/spf13/cobra"},
+		{"github.com/urfave/cli/v2/altsrc", "github.com/urfave/cli/v2"},
+		{"golang.org/x/tools/go/analysis", "golang.org/x/tools"},
+		{"go.uber.org/zap/zapcore", "go.uber.org/zap"},
+		{"k8s.io/client-go/v11/kubernetes", "k8s.io/client-go/v11"},
+		{"gopkg.in/yaml.v3", "gopkg.in/yaml.v3"},
+		{"github.com/v2/lib", "github.com/v2/lib"},
+		{"example.com", "example.com"},
+	}
+	for _, tt := range tests {
+		if got := modulePathOf(tt.pkgPath); got != tt.want {
+			t.Errorf("modulePathOf(%q) = %q, want %q", tt.pkgPath, got, tt.want)
+		}
+	}
+
+	pkgPaths := []string{"github.com/a/b", "github.com/a/b/c", "github.com/a/b/v2", "go.uber.org/zap", "go.uber.org/zap/zapcore"}
+	if got := importingModules(pkgPaths); got != 3 {
+		t.Errorf("expected 3 modules, got %d", got)
+	}
+}
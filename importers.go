@@ -0,0 +1,54 @@
+package pkgimporters
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ImporterList is the list of importers of a package returned by Client.Importers.
+type ImporterList struct {
+	// Paths are the paths of the importing packages, in the order of the source.
+	Paths []string `json:"paths"`
+
+	// Count is the number of known importers according to the source.
+	Count int `json:"count"`
+
+	// Truncated reports whether the source lists only some of the Count importers.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ImportersLister is implemented by sources that can list the importers of a package,
+// not just count them.
+type ImportersLister interface {
+	// Importers returns the packages importing pkgPath.
+	// Implementations return an error wrapping ErrNotFound for unknown packages,
+	// and set Truncated rather than fail if they cannot list all importers.
+	Importers(ctx context.Context, pkgPath string) (ImporterList, error)
+}
+
+// Importers returns the packages importing pkgPath, as listed by c.Source.
+// The request is rate limited like ImporterCount, but neither cached nor retried.
+// It returns an error wrapping errors.ErrUnsupported if the Source is not an ImportersLister.
+func (c *Client) Importers(ctx context.Context, pkgPath string) (ImporterList, error) {
+	lister, ok := c.source().(ImportersLister)
+	if !ok {
+		return ImporterList{}, &PackageError{Path: pkgPath, Err: fmt.Errorf("list importers with %T: %w", c.source(), errors.ErrUnsupported)}
+	}
+	if err := c.wait(ctx); err != nil {
+		return ImporterList{}, err
+	}
+
+	if c.OnRequest != nil {
+		c.OnRequest(pkgPath)
+	}
+	c.logger().DebugContext(ctx, "list importers", "pkg", pkgPath)
+	ctx, cancel := context.WithTimeout(ctx, cmp.Or(c.Timeout, DefaultTimeout))
+	defer cancel()
+	l, err := lister.Importers(ctx, pkgPath)
+	if err != nil {
+		return ImporterList{}, &PackageError{Path: pkgPath, Err: err}
+	}
+	return l, nil
+}
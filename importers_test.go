@@ -0,0 +1,42 @@
+package pkgimporters
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestClientImporters(t *testing.T) {
+	page, err := os.ReadFile("testdata/io.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{
+		HTTPClient:        &http.Client{Transport: &htmlFileTransport{content: page}},
+		RequestsPerSecond: 100,
+	}
+	got, err := c.Importers(t.Context(), "io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"gio.realy.lol/app",
+		"gio.realy.lol/io/clipboard",
+		"gio.realy.lol/io/input",
+		"gio.realy.lol/io/transfer",
+	}
+	if !slices.Equal(got.Paths[:len(want)], want) {
+		t.Errorf("expected importers to start with %v, got %v", want, got.Paths)
+	}
+	if want := fixtureCount(t, "io"); got.Count != want || !got.Truncated {
+		t.Errorf("expected a truncated list of %d importers, got count %d, truncated %v", want, got.Count, got.Truncated)
+	}
+
+	c = &Client{Source: sourceFunc(func(context.Context, string) (int, error) { return 1, nil }), RequestsPerSecond: 100}
+	if _, err := c.Importers(t.Context(), "io"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected errors.ErrUnsupported for a source that cannot list importers, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package pkgimporters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const librariesIOURL = "https://libraries.io"
+
+// LibrariesIO is a Source that reports the number of dependent repositories
+// of a Go package from the libraries.io API (https://libraries.io/api).
+type LibrariesIO struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// APIKey is the libraries.io API key, required by the API.
+	APIKey string
+}
+
+// Count returns the number of repositories depending on the package.
+// It returns ErrNotFound if libraries.io does not know the package.
+func (s *LibrariesIO) Count(ctx context.Context, pkgPath string) (int, error) {
+	q := url.Values{}
+	q.Set("api_key", s.APIKey)
+	u := librariesIOURL + "/api/go/" + url.PathEscape(pkgPath) + "?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return 0, fmt.Errorf("new request: %w", err)
+	}
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Do not leak the API key in the error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return 0, ErrNotFound
+	default:
+		return 0, newStatusError(resp)
+	}
+
+	var project struct {
+		DependentReposCount int `json:"dependent_repos_count"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&project); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+	return project.DependentReposCount, nil
+}
@@ -0,0 +1,34 @@
+package pkgimporters
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestLibrariesIOCount(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://libraries.io/api/go/github.com%2Fspf13%2Fcobra?api_key=secret": []byte(`{
+			"name": "github.com/spf13/cobra",
+			"platform": "Go",
+			"dependents_count": 12345,
+			"dependent_repos_count": 98765
+		}`),
+	}}
+	source := &LibrariesIO{
+		HTTPClient: &http.Client{Transport: transport},
+		APIKey:     "secret",
+	}
+
+	count, err := source.Count(t.Context(), "github.com/spf13/cobra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 98765 {
+		t.Errorf("expected count 98765, got %d", count)
+	}
+
+	if _, err := source.Count(t.Context(), "example.com/unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package pkgimporters
+
+import "time"
+
+// Metrics records metrics about the fetches of a Client.
+// The prommetrics package implements it with Prometheus metrics.
+// Its methods may be called concurrently from multiple goroutines.
+type Metrics interface {
+	// ObserveRequest records a request to the Source that took d and failed with err, if non-nil.
+	ObserveRequest(d time.Duration, err error)
+
+	// ObserveCache records a lookup of a count in the cache.
+	ObserveCache(hit bool)
+
+	// ObserveRevalidation records an expired count confirmed by a Not Modified response.
+	ObserveRevalidation()
+
+	// ObserveHedge records a second request made because the first was slower than the hedge delay.
+	ObserveHedge()
+
+	// ObserveRetry records a retried request.
+	ObserveRetry()
+}
+
+// noMetrics is the Metrics of a Client without Metrics, recording nothing.
+type noMetrics struct{}
+
+func (noMetrics) ObserveRequest(time.Duration, error) {}
+func (noMetrics) ObserveCache(bool)                   {}
+func (noMetrics) ObserveRevalidation()                {}
+func (noMetrics) ObserveHedge()                       {}
+func (noMetrics) ObserveRetry()                       {}
@@ -0,0 +1,62 @@
+package pkgimporters
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientMetrics(t *testing.T) {
+	metrics := &countingMetrics{}
+	client := &Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			if pkgPath == "example.com/unknown" {
+				return 0, ErrNotFound
+			}
+			return 3, nil
+		}),
+		Metrics: metrics,
+	}
+
+	for _, path := range []string{"fmt", "fmt", "example.com/unknown"} {
+		_, _ = client.ImporterCount(t.Context(), path)
+	}
+
+	if got := metrics.cacheHits.Load(); got != 1 {
+		t.Errorf("expected 1 cache hit, got %d", got)
+	}
+	if got := metrics.cacheMisses.Load(); got != 2 {
+		t.Errorf("expected 2 cache misses, got %d", got)
+	}
+	if got := metrics.requests.Load(); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+	if got := metrics.errors.Load(); got != 1 {
+		t.Errorf("expected 1 failed request, got %d", got)
+	}
+}
+
+// countingMetrics is a Metrics counting the observations.
+type countingMetrics struct {
+	requests, errors, cacheHits, cacheMisses, revalidated, hedged, retries atomic.Int32
+}
+
+func (m *countingMetrics) ObserveRequest(d time.Duration, err error) {
+	m.requests.Add(1)
+	if err != nil {
+		m.errors.Add(1)
+	}
+}
+
+func (m *countingMetrics) ObserveCache(hit bool) {
+	if hit {
+		m.cacheHits.Add(1)
+	} else {
+		m.cacheMisses.Add(1)
+	}
+}
+
+func (m *countingMetrics) ObserveRevalidation() { m.revalidated.Add(1) }
+func (m *countingMetrics) ObserveHedge()        { m.hedged.Add(1) }
+func (m *countingMetrics) ObserveRetry()        { m.retries.Add(1) }
@@ -0,0 +1,45 @@
+package pkgimporters
+
+import "net/http"
+
+// Middleware wraps the transport of requests made by a Client,
+// e.g., to sign requests for a corporate proxy, inject headers, or record traffic.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to allow the use of ordinary functions as HTTP round trippers.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithHeader returns a middleware that sets the header on every request.
+func WithHeader(key, value string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set(key, value)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// chain returns a copy of client whose transport is wrapped by the middleware,
+// with the first middleware being the outermost.
+func chain(client *http.Client, middleware []Middleware) *http.Client {
+	if len(middleware) == 0 {
+		return client
+	}
+
+	wrapped := *client
+	rt := wrapped.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		rt = middleware[i](rt)
+	}
+	wrapped.Transport = rt
+	return &wrapped
+}
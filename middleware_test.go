@@ -0,0 +1,40 @@
+package pkgimporters
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestClientMiddleware(t *testing.T) {
+	var order []string
+	var gotHeader string
+	record := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotHeader = req.Header.Get("X-Proxy-Auth")
+		return (&htmlFileTransport{content: []byte(`<div class="ImportedBy"><p>No known importers for this package!</p></div>`)}).RoundTrip(req)
+	})
+	client := &Client{
+		HTTPClient: &http.Client{Transport: transport},
+		Middleware: []Middleware{record("outer"), WithHeader("X-Proxy-Auth", "secret"), record("inner")},
+	}
+
+	if _, err := client.ImporterCount(t.Context(), "fmt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"outer", "inner"}; !slices.Equal(order, want) {
+		t.Errorf("expected middleware order %v, got %v", want, order)
+	}
+	if gotHeader != "secret" {
+		t.Errorf("expected header to be set, got %q", gotHeader)
+	}
+}
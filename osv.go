@@ -0,0 +1,152 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const osvURL = "https://api.osv.dev"
+
+// OSV is an Enricher that adds the IDs of the known vulnerabilities of a package
+// from the OSV database (https://osv.dev), which includes the Go vulnerability database.
+// OSV is queried by module, whose path is estimated from the package path like Result.Modules,
+// so vulnerabilities of nested modules are missed.
+type OSV struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// osvVuln is the part of an OSV entry needed to tell whether it affects a package.
+type osvVuln struct {
+	ID        string `json:"id"`
+	Withdrawn string `json:"withdrawn"`
+	Affected  []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+		EcosystemSpecific struct {
+			Imports []struct {
+				Path string `json:"path"`
+			} `json:"imports"`
+		} `json:"ecosystem_specific"`
+	} `json:"affected"`
+}
+
+// affects reports whether v affects pkgPath of module modPath in any version.
+// Entries that do not list the affected packages of the module affect all of them.
+func (v osvVuln) affects(modPath, pkgPath string) bool {
+	if v.Withdrawn != "" {
+		return false
+	}
+	for _, a := range v.Affected {
+		if a.Package.Name != modPath {
+			continue
+		}
+		if len(a.EcosystemSpecific.Imports) == 0 {
+			return true
+		}
+		for _, imp := range a.EcosystemSpecific.Imports {
+			if imp.Path == pkgPath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Enrich implements Enricher, setting r.Vulns.
+// The vulnerabilities are those of the canonical path if the package was redirected.
+func (s *OSV) Enrich(ctx context.Context, r *Result) error {
+	pkgPath := r.Path
+	if r.CanonicalPath != "" {
+		pkgPath = r.CanonicalPath
+	}
+	vulns, err := s.Vulns(ctx, pkgPath)
+	if err != nil {
+		return fmt.Errorf("osv: %w", err)
+	}
+	r.Vulns = vulns
+	return nil
+}
+
+// Vulns returns the sorted IDs of the vulnerabilities affecting any version of the package,
+// e.g., "GO-2023-2102", or nil if there are none.
+func (s *OSV) Vulns(ctx context.Context, pkgPath string) ([]string, error) {
+	modPath := modulePathOf(pkgPath)
+	if isStdPath(pkgPath) {
+		modPath = "stdlib"
+	}
+
+	var ids []string
+	pageToken := ""
+	for {
+		var page struct {
+			Vulns         []osvVuln `json:"vulns"`
+			NextPageToken string    `json:"next_page_token"`
+		}
+		if err := s.query(ctx, modPath, pageToken, &page); err != nil {
+			return nil, err
+		}
+		for _, v := range page.Vulns {
+			if v.affects(modPath, pkgPath) {
+				ids = append(ids, v.ID)
+			}
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+// query decodes a page of the OSV query response for the Go module modPath into v.
+func (s *OSV) query(ctx context.Context, modPath, pageToken string, v any) error {
+	query := map[string]any{
+		"package": map[string]string{"name": modPath, "ecosystem": "Go"},
+	}
+	if pageToken != "" {
+		query["page_token"] = pageToken
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return fmt.Errorf("encode query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, osvURL+"/v1/query", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// isStdPath reports whether pkgPath is a standard library package, whose first element has no dot.
+func isStdPath(pkgPath string) bool {
+	elem, _, _ := strings.Cut(pkgPath, "/")
+	return !strings.Contains(elem, ".")
+}
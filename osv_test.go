@@ -0,0 +1,81 @@
+package pkgimporters
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOSVVulns(t *testing.T) {
+	pages := map[string]string{
+		"golang.org/x/net": `{
+			"vulns": [
+				{"id": "GO-2023-1988", "affected": [{"package": {"name": "golang.org/x/net", "ecosystem": "Go"},
+					"ecosystem_specific": {"imports": [{"path": "golang.org/x/net/html"}]}}]},
+				{"id": "GO-2023-1571", "affected": [{"package": {"name": "golang.org/x/net", "ecosystem": "Go"},
+					"ecosystem_specific": {"imports": [{"path": "golang.org/x/net/http2"}]}}]}
+			],
+			"next_page_token": "p2"
+		}`,
+		"golang.org/x/net p2": `{
+			"vulns": [
+				{"id": "GHSA-vvpx-j8f3-3w6h", "affected": [{"package": {"name": "golang.org/x/net", "ecosystem": "Go"}}]},
+				{"id": "GO-2022-0001", "withdrawn": "2022-06-01T00:00:00Z", "affected": [{"package": {"name": "golang.org/x/net", "ecosystem": "Go"}}]}
+			]
+		}`,
+		"stdlib": `{"vulns": [{"id": "GO-2024-2687", "affected": [{"package": {"name": "stdlib", "ecosystem": "Go"},
+			"ecosystem_specific": {"imports": [{"path": "net/http"}]}}]}]}`,
+	}
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var query struct {
+			Package struct {
+				Name      string `json:"name"`
+				Ecosystem string `json:"ecosystem"`
+			} `json:"package"`
+			PageToken string `json:"page_token"`
+		}
+		if req.Method != http.MethodPost || req.URL.String() != "https://api.osv.dev/v1/query" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+		}
+		if err := json.NewDecoder(req.Body).Decode(&query); err != nil || query.Package.Ecosystem != "Go" {
+			t.Errorf("unexpected query %+v: %v", query, err)
+		}
+		body, ok := pages[strings.TrimSpace(query.Package.Name+" "+query.PageToken)]
+		if !ok {
+			body = "{}"
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})
+	osv := &OSV{HTTPClient: &http.Client{Transport: transport}}
+
+	tests := []struct {
+		pkgPath string
+		want    []string
+	}{
+		{pkgPath: "golang.org/x/net/html", want: []string{"GHSA-vvpx-j8f3-3w6h", "GO-2023-1988"}},
+		{pkgPath: "golang.org/x/net/http2", want: []string{"GHSA-vvpx-j8f3-3w6h", "GO-2023-1571"}},
+		{pkgPath: "net/http", want: []string{"GO-2024-2687"}},
+		{pkgPath: "fmt", want: nil},
+		{pkgPath: "github.com/spf13/cobra", want: nil},
+	}
+	for _, tt := range tests {
+		got, err := osv.Vulns(t.Context(), tt.pkgPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Vulns(%q) = %v, want %v", tt.pkgPath, got, tt.want)
+		}
+	}
+
+	r := Result{Path: "golang.org/x/net/websocket", CanonicalPath: "golang.org/x/net/html"}
+	if err := osv.Enrich(t.Context(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"GHSA-vvpx-j8f3-3w6h", "GO-2023-1988"}; !slices.Equal(r.Vulns, want) {
+		t.Errorf("expected vulns of the canonical path %v, got %v", want, r.Vulns)
+	}
+}
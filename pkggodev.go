@@ -0,0 +1,295 @@
+package pkgimporters
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// PkgGoDev is a Source that scrapes importer counts from pkg.go.dev
+// or another pkgsite instance.
+type PkgGoDev struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// BaseURL is the URL of the pkgsite instance. If empty, DefaultBaseURL is used.
+	BaseURL string
+
+	// CountModules makes CountIfModified read the whole importedby tab, not just the count,
+	// to report the number of unique modules among the listed importers in Response.Modules.
+	CountModules bool
+
+	// Examples is the number of listed importers that CountIfModified reports in Response.Examples,
+	// reading the whole importedby tab if positive.
+	Examples int
+
+	// License makes CountIfModified also request the main page of each package,
+	// e.g., https://pkg.go.dev/io, to report the licenses shown in its header in Response.License.
+	License bool
+
+	// Version makes CountIfModified also request the main page of each package
+	// to report its latest version and publish time in Response.Version and Response.Published.
+	Version bool
+
+	// Imports makes CountIfModified also request the main page of each package
+	// to report the number of packages it imports in Response.Imports.
+	Imports bool
+
+	// Redistributable makes CountIfModified also request the main page of each package
+	// to report whether pkg.go.dev considers it redistributable in Response.Redistributable.
+	// pkg.go.dev hides the documentation of packages without licenses that allow redistribution.
+	Redistributable bool
+}
+
+// ErrBlocked is returned when pkg.go.dev responds with a page that is not a package page,
+// e.g., a bot-block interstitial, a consent page, or a CAPTCHA.
+var ErrBlocked = errors.New("blocked by upstream")
+
+// ErrParse is returned when the "importedby" tab of a package page neither shows
+// the number of known importers nor states that there are none,
+// e.g., because pkg.go.dev changed its markup.
+var ErrParse = errors.New("importer count not found on page")
+
+// Count retrieves the number of known importers for a Go package
+// from pkg.go.dev by scraping the "importedby" tab. E.g., https://pkg.go.dev/io?tab=importedby.
+// It returns the count as an integer, or 0 if the page states that there are no known importers.
+// It returns ErrNotFound if pkg.go.dev responds with 404 Not Found,
+// an error wrapping ErrBlocked if the page lacks the "importedby" tab,
+// and ErrParse if the tab shows no count.
+// If pkg.go.dev redirects the package to a new path, the count at the new path is returned.
+func (s *PkgGoDev) Count(ctx context.Context, pkgPath string) (int, error) {
+	resp, err := s.CountIfModified(ctx, pkgPath, Validators{})
+	return resp.Count, err
+}
+
+// CountIfModified implements ConditionalSource.
+// It sends prev as If-None-Match and If-Modified-Since headers
+// and reports NotModified if pkg.go.dev responds with 304 Not Modified.
+// It follows redirects to a new package path, e.g., after a repository rename,
+// reporting the new path as CanonicalPath.
+// With License, Version, Imports, or Redistributable, the main page is only requested if the importedby tab was modified.
+func (s *PkgGoDev) CountIfModified(ctx context.Context, pkgPath string, prev Validators) (Response, error) {
+	resp, err := s.countIfModified(ctx, pkgPath, prev)
+	if err != nil || resp.NotModified || !s.License && !s.Version && !s.Imports && !s.Redistributable {
+		return resp, err
+	}
+	unit, err := s.fetchUnitPage(ctx, cmp.Or(resp.CanonicalPath, pkgPath))
+	if err != nil {
+		return Response{}, fmt.Errorf("main page: %w", err)
+	}
+	if s.License {
+		resp.License = unit.license()
+	}
+	if s.Version {
+		resp.Version, resp.Published = unit.version, unit.published
+	}
+	if s.Imports {
+		resp.Imports = unit.imports
+	}
+	if s.Redistributable {
+		redistributable := unit.redistributable()
+		resp.Redistributable = &redistributable
+	}
+	return resp, nil
+}
+
+func (s *PkgGoDev) countIfModified(ctx context.Context, pkgPath string, prev Validators) (Response, error) {
+	resp, err := s.fetch(ctx, pkgPath, prev)
+	if err != nil || resp.CanonicalPath == "" || resp.tab {
+		return resp.Response, err
+	}
+
+	// The redirect dropped the importedby tab, so request it at the new path.
+	canonical, err := s.fetch(ctx, resp.CanonicalPath, Validators{})
+	if err != nil {
+		return Response{}, err
+	}
+	canonical.CanonicalPath = resp.CanonicalPath
+	return canonical.Response, nil
+}
+
+// pkgGoDevResponse is the response of a single fetch of an importedby tab.
+type pkgGoDevResponse struct {
+	Response
+
+	// tab reports that the final page, after any redirects, is the importedby tab.
+	tab bool
+}
+
+// fetch requests the importedby tab of pkgPath.
+// If the request is redirected to another package path, it returns that path as CanonicalPath
+// and does not read the page unless it is still the importedby tab.
+func (s *PkgGoDev) fetch(ctx context.Context, pkgPath string, prev Validators) (pkgGoDevResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.importedByURL(pkgPath), http.NoBody)
+	if err != nil {
+		return pkgGoDevResponse{}, fmt.Errorf("new request: %w", err)
+	}
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return pkgGoDevResponse{}, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	r := pkgGoDevResponse{
+		Response: Response{Validators: Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}},
+		tab: true,
+	}
+	if resp.Request != nil && resp.Request.URL.Path != req.URL.Path {
+		final := resp.Request.URL
+		basePath := strings.TrimSuffix(req.URL.Path, "/"+pkgPath)
+		r.CanonicalPath = strings.Trim(strings.TrimPrefix(final.Path, basePath), "/")
+		r.tab = final.Query().Get("tab") == "importedby"
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		// A 304 response may omit validators that did not change.
+		r.Validators.ETag = cmp.Or(r.Validators.ETag, prev.ETag)
+		r.Validators.LastModified = cmp.Or(r.Validators.LastModified, prev.LastModified)
+		r.NotModified = true
+		return r, nil
+	case http.StatusNotFound:
+		return pkgGoDevResponse{}, ErrNotFound
+	default:
+		return pkgGoDevResponse{}, newStatusError(resp)
+	}
+	if !r.tab {
+		return r, nil
+	}
+
+	// Only read first 40KB since "Known importers" appears early in HTML
+	limit := int64(40 * 1024)
+	if s.CountModules || s.Examples > 0 {
+		limit = maxImportersPageSize
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return pkgGoDevResponse{}, fmt.Errorf("read body: %w", err)
+	}
+
+	page := parseImportedBy(body)
+	switch {
+	case page.parser != "":
+		r.Count, r.Parser, r.Fallback = page.count, page.parser, page.parser != importedByParsers[0].name
+		if s.CountModules {
+			r.Modules = importingModules(page.importers)
+		}
+		if s.Examples > 0 {
+			r.Examples = page.importers[:min(s.Examples, len(page.importers))]
+		}
+	case !page.tab:
+		return pkgGoDevResponse{}, blockedError(page.title)
+	case !page.none:
+		return pkgGoDevResponse{}, ErrParse
+	}
+	return r, nil
+}
+
+// fetchUnitPage requests the main page of pkgPath and parses its header.
+// It returns ErrNotFound and ErrBlocked like Count.
+func (s *PkgGoDev) fetchUnitPage(ctx context.Context, pkgPath string) (unitPage, error) {
+	u := strings.TrimSuffix(cmp.Or(s.BaseURL, DefaultBaseURL), "/") + "/" + pkgPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return unitPage{}, fmt.Errorf("new request: %w", err)
+	}
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return unitPage{}, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return unitPage{}, ErrNotFound
+	default:
+		return unitPage{}, newStatusError(resp)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxUnitHeaderSize))
+	if err != nil {
+		return unitPage{}, fmt.Errorf("read body: %w", err)
+	}
+	page := parseUnitPage(body)
+	if !page.header {
+		return unitPage{}, blockedError(page.title)
+	}
+	return page, nil
+}
+
+// maxImportersPageSize caps the size of an importedby tab read by Importers.
+const maxImportersPageSize = 64 << 20
+
+// Importers implements ImportersLister by reading the list of importers from the "importedby" tab.
+// pkg.go.dev does not paginate the tab and lists at most 20,000 packages,
+// so the list of a popular package is Truncated.
+// It returns ErrNotFound and ErrBlocked like Count.
+func (s *PkgGoDev) Importers(ctx context.Context, pkgPath string) (ImporterList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.importedByURL(pkgPath), http.NoBody)
+	if err != nil {
+		return ImporterList{}, fmt.Errorf("new request: %w", err)
+	}
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return ImporterList{}, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return ImporterList{}, ErrNotFound
+	default:
+		return ImporterList{}, newStatusError(resp)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImportersPageSize))
+	if err != nil {
+		return ImporterList{}, fmt.Errorf("read body: %w", err)
+	}
+	page := parseImportedBy(body)
+	if !page.tab {
+		return ImporterList{}, blockedError(page.title)
+	}
+	l := ImporterList{Paths: page.importers, Count: page.count}
+	if page.parser == "" {
+		l.Count = len(page.importers)
+	}
+	// The listed packages include internal and invalid ones that are not counted,
+	// so only a list shorter than the count is incomplete.
+	l.Truncated = cmp.Or(page.displayed, len(page.importers)) < l.Count
+	return l, nil
+}
+
+func (s *PkgGoDev) importedByURL(pkgPath string) string {
+	return strings.TrimSuffix(cmp.Or(s.BaseURL, DefaultBaseURL), "/") + "/" + pkgPath + "?tab=importedby"
+}
+
+func (s *PkgGoDev) client() *http.Client {
+	if s.HTTPClient != nil {
+		return s.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// blockedError returns an error wrapping ErrBlocked that includes the title of the page, if any.
+func blockedError(title string) error {
+	if title != "" {
+		return fmt.Errorf("%w: unexpected page %q", ErrBlocked, title)
+	}
+	return ErrBlocked
+}
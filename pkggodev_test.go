@@ -0,0 +1,333 @@
+package pkgimporters
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPkgGoDevCount(t *testing.T) {
+	tests := []struct {
+		name          string
+		htmlFile      string
+		pkgPath       string
+		baseURL       string
+		expectedCount int
+		expectedURL   string
+	}{
+		{
+			name:          "io package",
+			htmlFile:      "testdata/io.html",
+			pkgPath:       "io",
+			expectedCount: fixtureCount(t, "io"),
+			expectedURL:   "https://pkg.go.dev/io?tab=importedby",
+		},
+		{
+			name:          "golang.org/x/tools/go/analysis package",
+			htmlFile:      "testdata/golang.org/x/tools/go/analysis.html",
+			pkgPath:       "golang.org/x/tools/go/analysis",
+			expectedCount: fixtureCount(t, "golang.org/x/tools/go/analysis"),
+			expectedURL:   "https://pkg.go.dev/golang.org/x/tools/go/analysis?tab=importedby",
+		},
+		{
+			name:          "private pkgsite instance",
+			htmlFile:      "testdata/io.html",
+			pkgPath:       "io",
+			baseURL:       "https://pkgsite.internal.corp/",
+			expectedCount: fixtureCount(t, "io"),
+			expectedURL:   "https://pkgsite.internal.corp/io?tab=importedby",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			htmlBytes, err := os.ReadFile(tt.htmlFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			transport := &htmlFileTransport{
+				content: htmlBytes,
+			}
+			source := &PkgGoDev{
+				HTTPClient: &http.Client{Transport: transport},
+				BaseURL:    tt.baseURL,
+			}
+			count, err := source.Count(t.Context(), tt.pkgPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if count != tt.expectedCount {
+				t.Errorf("expected count %d, got %d", tt.expectedCount, count)
+			}
+			if len(transport.requestedURLs) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(transport.requestedURLs))
+			}
+			if transport.requestedURLs[0] != tt.expectedURL {
+				t.Errorf("expected URL %q, got %q", tt.expectedURL, transport.requestedURLs[0])
+			}
+		})
+	}
+}
+
+func TestPkgGoDevCountPageStructure(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		want    int
+		wantErr string
+	}{
+		{
+			name: "no importers",
+			page: `<div class="ImportedBy"><p>No known importers for this package!</p></div>`,
+			want: 0,
+		},
+		{
+			name:    "bot-block interstitial",
+			page:    `<html><head><title>Just a moment...</title></head><body>Checking your browser</body></html>`,
+			wantErr: `blocked by upstream: unexpected page "Just a moment..."`,
+		},
+		{
+			name:    "empty page",
+			page:    ``,
+			wantErr: "blocked by upstream",
+		},
+		{
+			name:    "changed markup",
+			page:    `<div class="ImportedBy"><p>Imported by 1,234 packages</p></div>`,
+			wantErr: "importer count not found on page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &PkgGoDev{
+				HTTPClient: &http.Client{Transport: &htmlFileTransport{content: []byte(tt.page)}},
+			}
+			count, err := source.Count(t.Context(), "example.com/pkg")
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrBlocked) && !errors.Is(err, ErrParse) || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q wrapping ErrBlocked or ErrParse, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.want {
+				t.Errorf("expected count %d, got %d", tt.want, count)
+			}
+		})
+	}
+}
+
+func TestPkgGoDevCountParser(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want Response
+	}{
+		{
+			name: "current markup",
+			page: `<div class="ImportedBy"><strong>Known importers:</strong> 1,234</div>`,
+			want: Response{Count: 1234, Parser: "markup"},
+		},
+		{
+			name: "legacy markup",
+			page: `<div class="ImportedBy"><p>Known importers:</strong> 1,234</p></div>`,
+			want: Response{Count: 1234, Parser: "legacy", Fallback: true},
+		},
+		{
+			name: "no importers",
+			page: `<div class="ImportedBy"><p>No known importers for this package!</p></div>`,
+			want: Response{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &PkgGoDev{
+				HTTPClient: &http.Client{Transport: &htmlFileTransport{content: []byte(tt.page)}},
+			}
+			resp, err := source.CountIfModified(t.Context(), "example.com/pkg", Validators{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resp, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, resp)
+			}
+		})
+	}
+}
+
+func TestPkgGoDevCountRedirect(t *testing.T) {
+	page := []byte(`<div class="ImportedBy"><strong>Known importers:</strong> 1,234</div>`)
+	tests := []struct {
+		name     string
+		location string
+	}{
+		{name: "keeps tab", location: "/github.com/sirupsen/logrus?tab=importedby"},
+		{name: "drops tab", location: "/github.com/sirupsen/logrus"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested []string
+			transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				requested = append(requested, req.URL.RequestURI())
+				if req.URL.Path == "/github.com/Sirupsen/logrus" {
+					return &http.Response{
+						StatusCode: http.StatusMovedPermanently,
+						Header:     http.Header{"Location": []string{tt.location}},
+						Body:       http.NoBody,
+						Request:    req,
+					}, nil
+				}
+				if req.URL.Query().Get("tab") != "importedby" {
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("<title>logrus</title>")), Request: req}, nil
+				}
+				return (&htmlFileTransport{content: page}).RoundTrip(req)
+			})
+			source := &PkgGoDev{HTTPClient: &http.Client{Transport: transport}}
+
+			resp, err := source.CountIfModified(t.Context(), "github.com/Sirupsen/logrus", Validators{})
+			if err != nil {
+				t.Fatalf("%v (requested %v)", err, requested)
+			}
+			want := Response{Count: 1234, CanonicalPath: "github.com/sirupsen/logrus", Parser: "markup"}
+			if !reflect.DeepEqual(resp, want) {
+				t.Errorf("expected %+v, got %+v", want, resp)
+			}
+			if last := requested[len(requested)-1]; last != "/github.com/sirupsen/logrus?tab=importedby" {
+				t.Errorf("expected the importedby tab of the new path to be requested last, got %v", requested)
+			}
+		})
+	}
+}
+
+func TestPkgGoDevImporters(t *testing.T) {
+	page, err := os.ReadFile("testdata/golang.org/x/tools/go/analysis.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &PkgGoDev{HTTPClient: &http.Client{Transport: &htmlFileTransport{content: page}}}
+	importers, err := source.Importers(t.Context(), "golang.org/x/tools/go/analysis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The page lists more packages than it counts, as it includes internal and invalid ones.
+	if want := fixtureCount(t, "golang.org/x/tools/go/analysis"); importers.Count != want || importers.Truncated {
+		t.Errorf("expected a complete list of %d importers, got count %d, truncated %v", want, importers.Count, importers.Truncated)
+	}
+	if len(importers.Paths) == 0 {
+		t.Fatal("expected importers, got none")
+	}
+	for _, path := range importers.Paths {
+		if path == "" || strings.ContainsAny(path, " \n") {
+			t.Errorf("expected a package path, got %q", path)
+		}
+	}
+
+	page = []byte(`<div class="ImportedBy"><div class="ImportedBy-heading"><strong>Known importers:</strong> 3</div>
+		<ul class="ImportedBy-list"><li><a href="/example.com/a">example.com/a</a></li></ul></div>`)
+	source = &PkgGoDev{HTTPClient: &http.Client{Transport: &htmlFileTransport{content: page}}}
+	if importers, err := source.Importers(t.Context(), "example.com/lib"); err != nil || !importers.Truncated {
+		t.Errorf("expected a truncated list for 1 of 3 importers, got %+v, %v", importers, err)
+	}
+
+	source = &PkgGoDev{HTTPClient: &http.Client{Transport: &htmlFileTransport{content: []byte("<title>Just a moment...</title>")}}}
+	if _, err := source.Importers(t.Context(), "io"); !errors.Is(err, ErrBlocked) {
+		t.Errorf("expected ErrBlocked, got %v", err)
+	}
+}
+
+func TestPkgGoDevCountModules(t *testing.T) {
+	page, err := os.ReadFile("testdata/golang.org/x/tools/go/analysis.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &PkgGoDev{HTTPClient: &http.Client{Transport: &htmlFileTransport{content: page}}, CountModules: true}
+	resp, err := source.CountIfModified(t.Context(), "golang.org/x/tools/go/analysis", Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fixtureCount(t, "golang.org/x/tools/go/analysis"); resp.Count != want || resp.Modules == 0 || resp.Modules >= resp.Count {
+		t.Errorf("expected %d importers from fewer modules, got %d importers from %d modules", want, resp.Count, resp.Modules)
+	}
+
+	source.CountModules = false
+	if resp, err := source.CountIfModified(t.Context(), "golang.org/x/tools/go/analysis", Validators{}); err != nil || resp.Modules != 0 {
+		t.Errorf("expected no module count without CountModules, got %d, %v", resp.Modules, err)
+	}
+}
+
+func TestPkgGoDevCountExamples(t *testing.T) {
+	page, err := os.ReadFile("testdata/golang.org/x/tools/go/analysis.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &PkgGoDev{HTTPClient: &http.Client{Transport: &htmlFileTransport{content: page}}, Examples: 2}
+	resp, err := source.CountIfModified(t.Context(), "golang.org/x/tools/go/analysis", Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"4d63.com/gocheckcompilerdirectives/checkcompilerdirectives", "4d63.com/gochecknoglobals/checknoglobals"}
+	if !slices.Equal(resp.Examples, want) {
+		t.Errorf("expected examples %q, got %q", want, resp.Examples)
+	}
+}
+
+func TestPkgGoDevCountDetails(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://pkg.go.dev/github.com/spf13/cobra?tab=importedby": []byte(`<div class="ImportedBy"><strong>Known importers:</strong> 184,231</div>`),
+		"https://pkg.go.dev/github.com/spf13/cobra":                []byte(unitHeader),
+		"https://pkg.go.dev/example.com/blocked?tab=importedby":    []byte(`<div class="ImportedBy"><strong>Known importers:</strong> 1</div>`),
+		"https://pkg.go.dev/example.com/blocked":                   []byte(`<title>Just a moment...</title>`),
+	}}
+	source := &PkgGoDev{HTTPClient: &http.Client{Transport: transport}, License: true}
+
+	resp, err := source.CountIfModified(t.Context(), "github.com/spf13/cobra", Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 184231 || resp.License != "Apache-2.0" || resp.Version != "" {
+		t.Errorf("expected 184231 importers with Apache-2.0 and no version, got %d with %q and %q", resp.Count, resp.License, resp.Version)
+	}
+
+	source.License, source.Version, source.Imports = false, true, true
+	resp, err = source.CountIfModified(t.Context(), "github.com/spf13/cobra", Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.License != "" || resp.Version != "v1.8.0" || resp.Published.IsZero() {
+		t.Errorf("expected v1.8.0 with a publish time and no license, got %q at %v with %q", resp.Version, resp.Published, resp.License)
+	}
+	if resp.Imports == nil || *resp.Imports != 14 {
+		t.Errorf("expected 14 imports, got %v", resp.Imports)
+	}
+	source.Version, source.Imports, source.Redistributable = false, false, true
+	resp, err = source.CountIfModified(t.Context(), "github.com/spf13/cobra", Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Redistributable == nil || !*resp.Redistributable {
+		t.Errorf("expected a redistributable package, got %v", resp.Redistributable)
+	}
+	source.License, source.Redistributable = true, false
+
+	if _, err := source.CountIfModified(t.Context(), "example.com/blocked", Validators{}); !errors.Is(err, ErrBlocked) {
+		t.Errorf("expected ErrBlocked for a blocked main page, got %v", err)
+	}
+
+	source.License = false
+	transport.requestedURLs = nil
+	if resp, err := source.CountIfModified(t.Context(), "github.com/spf13/cobra", Validators{}); err != nil || resp.License != "" {
+		t.Errorf("expected no license without License, got %q, %v", resp.License, err)
+	}
+	if len(transport.requestedURLs) != 1 {
+		t.Errorf("expected only the importedby tab to be requested, got %v", transport.requestedURLs)
+	}
+}
@@ -0,0 +1,226 @@
+// Package pkgimporterstest provides a fake pkg.go.dev server and builders of its pages,
+// so code using the pkgimporters library can be tested without network access.
+package pkgimporterstest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+// Package describes a package served by a Server.
+type Package struct {
+	// Importers is the number of known importers shown on the importedby tab.
+	Importers int
+
+	// ImporterPaths are the importing packages listed on the importedby tab.
+	ImporterPaths []string
+
+	// Licenses are the license identifiers shown in the header of the main page.
+	// If empty, the header states that no license was detected.
+	Licenses []string
+
+	// Version is the latest version of the module shown in the header of the main page,
+	// and Published the time it was published.
+	Version   string
+	Published time.Time
+
+	// Imports is the number of packages the package imports, shown in the header of the main page.
+	Imports int
+
+	// DocsHidden makes the main page state that the documentation is not displayed due to license restrictions,
+	// as pkg.go.dev does for packages that are not redistributable. It is implied if Licenses is empty.
+	DocsHidden bool
+}
+
+// ImportedByPage returns the importedby tab of the package pkgPath,
+// e.g., https://pkg.go.dev/io?tab=importedby.
+func ImportedByPage(pkgPath string, p Package) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html><head><title>%s package importedby - Go Packages</title></head><body>\n", html.EscapeString(pkgPath))
+	b.WriteString(`<div class="ImportedBy">` + "\n")
+	if p.Importers == 0 && len(p.ImporterPaths) == 0 {
+		b.WriteString("  <p>No known importers for this package!</p>\n")
+	} else {
+		fmt.Fprintf(&b, `  <div class="ImportedBy-heading"><strong>Known importers:</strong> %s (displaying %s packages)</div>`+"\n",
+			pkgimporters.FormatCount(p.Importers), pkgimporters.FormatCount(len(p.ImporterPaths)))
+		b.WriteString(`  <ul class="ImportedBy-list">` + "\n")
+		for _, path := range p.ImporterPaths {
+			path = html.EscapeString(path)
+			fmt.Fprintf(&b, `    <li><a class="u-breakWord" href="/%s">%s</a></li>`+"\n", path, path)
+		}
+		b.WriteString("  </ul>\n")
+	}
+	b.WriteString("</div>\n</body></html>\n")
+	return b.Bytes()
+}
+
+// UnitPage returns the main page of the package pkgPath with the details header,
+// e.g., https://pkg.go.dev/io.
+func UnitPage(pkgPath string, p Package) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html><head><title>%s package - Go Packages</title></head><body>\n", html.EscapeString(pkgPath))
+	b.WriteString(`<div class="go-Main-headerDetails">` + "\n")
+	if p.Version != "" {
+		fmt.Fprintf(&b, `  <span data-test-id="UnitHeader-version"><a href="?tab=versions"><span>Version: </span>%s</a></span>`+"\n", html.EscapeString(p.Version))
+	}
+	if !p.Published.IsZero() {
+		fmt.Fprintf(&b, `  <span data-test-id="UnitHeader-commitTime"><span>Published: </span>%s</span>`+"\n", p.Published.Format("Jan 2, 2006"))
+	}
+	b.WriteString(`  <span data-test-id="UnitHeader-licenses"><span>License: </span>`)
+	if len(p.Licenses) == 0 {
+		b.WriteString(`<span>None detected</span>`)
+	}
+	for i, license := range p.Licenses {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `<a data-test-id="UnitHeader-license">%s</a>`, html.EscapeString(license))
+	}
+	b.WriteString("</span>\n")
+	fmt.Fprintf(&b, `  <span data-test-id="UnitHeader-imports"><a href="?tab=imports"><span>Imports: </span>%d</a></span>`+"\n", p.Imports)
+	b.WriteString("</div>\n")
+	if p.DocsHidden || len(p.Licenses) == 0 {
+		b.WriteString(`<section class="Documentation"><p>Documentation not displayed due to license restrictions.</p></section>` + "\n")
+	}
+	b.WriteString("</body></html>\n")
+	return b.Bytes()
+}
+
+// BlockedPage returns a bot-block interstitial served instead of a package page,
+// which makes the pkgimporters library report ErrBlocked.
+func BlockedPage() []byte {
+	return []byte("<!DOCTYPE html>\n<html><head><title>Just a moment...</title></head><body></body></html>\n")
+}
+
+// Server is a fake pkg.go.dev serving the importedby tab and the main page of the packages set on it.
+// It responds with 404 Not Found to unknown packages,
+// and with 304 Not Modified to conditional requests for unchanged pages.
+// The methods of a Server are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	packages  map[string]Package
+	redirects map[string]string
+	statuses  map[string]int
+	blocked   bool
+	requests  []string
+}
+
+// NewServer starts and returns a new Server without packages.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		packages:  make(map[string]Package),
+		redirects: make(map[string]string),
+		statuses:  make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// NewClient returns a pkgimporters.Client fetching from s, without rate limiting or retry delays.
+func (s *Server) NewClient() *pkgimporters.Client {
+	return &pkgimporters.Client{
+		BaseURL:           s.URL,
+		HTTPClient:        s.Client(),
+		RequestsPerSecond: 1e6,
+		Burst:             1000,
+		Retry:             pkgimporters.RetryPolicy{MaxAttempts: 1},
+	}
+}
+
+// SetPackage serves p at pkgPath, replacing any package already served there.
+func (s *Server) SetPackage(pkgPath string, p Package) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.packages[pkgPath] = p
+}
+
+// Redirect redirects the pages of the package from to the package to, e.g., after a repository rename.
+func (s *Server) Redirect(from, to string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redirects[from] = to
+}
+
+// SetStatus makes s respond to requests for the pages of pkgPath with the HTTP status code,
+// e.g., http.StatusTooManyRequests to simulate throttling. A code of 0 restores the package.
+func (s *Server) SetStatus(pkgPath string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code == 0 {
+		delete(s.statuses, pkgPath)
+		return
+	}
+	s.statuses[pkgPath] = code
+}
+
+// SetBlocked makes s respond to all requests with BlockedPage, or restores it.
+func (s *Server) SetBlocked(blocked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocked = blocked
+}
+
+// Requests returns the request URIs received by s, e.g., "/io?tab=importedby", in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.RequestURI())
+	pkgPath := strings.Trim(r.URL.Path, "/")
+	p, ok := s.packages[pkgPath]
+	to, redirected := s.redirects[pkgPath]
+	code, failing := s.statuses[pkgPath]
+	blocked := s.blocked
+	s.mu.Unlock()
+
+	var page []byte
+	switch tab := r.URL.Query().Get("tab"); {
+	case blocked:
+		page = BlockedPage()
+	case failing:
+		http.Error(w, http.StatusText(code), code)
+		return
+	case redirected:
+		target := "/" + to
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+		return
+	case !ok:
+		http.NotFound(w, r)
+		return
+	case tab == "importedby":
+		page = ImportedByPage(pkgPath, p)
+	case tab == "":
+		page = UnitPage(pkgPath, p)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(page))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+}
@@ -0,0 +1,101 @@
+package pkgimporterstest
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alexandear/pkgimporters"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetPackage("github.com/spf13/cobra", Package{
+		Importers:     184231,
+		ImporterPaths: []string{"github.com/cli/cli/v2/pkg/cmd/root", "k8s.io/kubectl/pkg/cmd"},
+		Licenses:      []string{"Apache-2.0"},
+		Version:       "v1.8.0",
+		Published:     time.Date(2023, 11, 4, 0, 0, 0, 0, time.UTC),
+		Imports:       14,
+	})
+	srv.SetPackage("example.com/unused", Package{})
+	srv.SetPackage("example.com/proprietary", Package{Importers: 12})
+	srv.Redirect("github.com/Sirupsen/logrus", "github.com/sirupsen/logrus")
+	srv.SetPackage("github.com/sirupsen/logrus", Package{Importers: 42, Licenses: []string{"MIT"}})
+
+	client := srv.NewClient()
+	client.Source = &pkgimporters.PkgGoDev{
+		HTTPClient: srv.Client(), BaseURL: srv.URL,
+		CountModules: true, Examples: 1, License: true, Version: true, Imports: true, Redistributable: true,
+	}
+	results, err := client.ImporterCounts(t.Context(), []string{"github.com/spf13/cobra", "example.com/unused", "example.com/proprietary", "github.com/Sirupsen/logrus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cobra := results[0]
+	if cobra.Count != 184231 || cobra.Modules != 2 || !slices.Equal(cobra.Examples, []string{"github.com/cli/cli/v2/pkg/cmd/root"}) {
+		t.Errorf("unexpected importers of cobra: %+v", cobra)
+	}
+	if cobra.License != "Apache-2.0" || cobra.Version != "v1.8.0" || cobra.Published.IsZero() || *cobra.Imports != 14 || !*cobra.Redistributable {
+		t.Errorf("unexpected details of cobra: %+v", cobra)
+	}
+	if unused := results[1]; unused.Count != 0 || unused.Error != "" {
+		t.Errorf("expected no importers, got %+v", unused)
+	}
+	if proprietary := results[2]; proprietary.License != "NONE" || *proprietary.Redistributable {
+		t.Errorf("expected a package without license that is not redistributable, got %+v", proprietary)
+	}
+	if logrus := results[3]; logrus.Count != 42 || logrus.CanonicalPath != "github.com/sirupsen/logrus" {
+		t.Errorf("expected the count at the canonical path, got %+v", logrus)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetPackage("fmt", Package{Importers: 5485422})
+	client := srv.NewClient()
+
+	if _, err := client.ImporterCount(t.Context(), "example.com/unknown"); !errors.Is(err, pkgimporters.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	srv.SetStatus("fmt", http.StatusInternalServerError)
+	var statusErr *pkgimporters.StatusError
+	if _, err := client.ImporterCount(t.Context(), "fmt"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected a 500 status error, got %v", err)
+	}
+	srv.SetStatus("fmt", 0)
+
+	srv.SetBlocked(true)
+	if _, err := client.ImporterCount(t.Context(), "fmt"); !errors.Is(err, pkgimporters.ErrBlocked) {
+		t.Errorf("expected ErrBlocked, got %v", err)
+	}
+	srv.SetBlocked(false)
+
+	if count, err := client.ImporterCount(t.Context(), "fmt"); err != nil || count != 5485422 {
+		t.Errorf("expected 5485422 importers, got %d, %v", count, err)
+	}
+	if want := []string{"/example.com/unknown?tab=importedby", "/fmt?tab=importedby", "/fmt?tab=importedby", "/fmt?tab=importedby"}; !slices.Equal(srv.Requests(), want) {
+		t.Errorf("expected requests %v, got %v", want, srv.Requests())
+	}
+}
+
+func TestServerNotModified(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetPackage("fmt", Package{Importers: 5485422})
+	source := &pkgimporters.PkgGoDev{HTTPClient: srv.Client(), BaseURL: srv.URL}
+
+	resp, err := source.CountIfModified(t.Context(), "fmt", pkgimporters.Validators{})
+	if err != nil || resp.Validators.ETag == "" {
+		t.Fatalf("expected a count with an ETag, got %+v, %v", resp, err)
+	}
+	if resp, err := source.CountIfModified(t.Context(), "fmt", resp.Validators); err != nil || !resp.NotModified {
+		t.Errorf("expected the unchanged page to be not modified, got %+v, %v", resp, err)
+	}
+}
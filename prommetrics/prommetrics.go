@@ -0,0 +1,130 @@
+// Package prommetrics records Prometheus metrics about the fetches of a pkgimporters.Client,
+// keeping the Prometheus client out of the dependencies of the pkgimporters package.
+package prommetrics
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements pkgimporters.Metrics with Prometheus metrics.
+// Register it with a prometheus.Registerer and set it as Client.Metrics.
+type Metrics struct {
+	requests    prometheus.Counter
+	errors      prometheus.Counter
+	retries     prometheus.Counter
+	cacheHits   prometheus.Counter
+	cacheMisses prometheus.Counter
+	revalidated prometheus.Counter
+	hedged      prometheus.Counter
+	latency     prometheus.Histogram
+	hitRatio    prometheus.GaugeFunc
+
+	// hits and lookups count the cache lookups for hitRatio.
+	hits, lookups atomic.Int64
+}
+
+// New returns metrics named with the "pkgimporters_" prefix.
+func New() *Metrics {
+	m := &Metrics{
+		requests: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pkgimporters_requests_total",
+			Help: "Number of requests made to the importer count source.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pkgimporters_request_errors_total",
+			Help: "Number of requests to the importer count source that failed.",
+		}),
+		retries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pkgimporters_retries_total",
+			Help: "Number of retried requests to the importer count source.",
+		}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pkgimporters_cache_hits_total",
+			Help: "Number of importer counts served from the cache.",
+		}),
+		cacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pkgimporters_cache_misses_total",
+			Help: "Number of importer counts not found in the cache.",
+		}),
+		revalidated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pkgimporters_revalidations_total",
+			Help: "Number of expired importer counts confirmed by a Not Modified response.",
+		}),
+		hedged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pkgimporters_hedged_requests_total",
+			Help: "Number of second requests made because the first was slower than the hedge delay.",
+		}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "pkgimporters_request_duration_seconds",
+			Help:    "Latency of requests to the importer count source.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+		}),
+	}
+	m.hitRatio = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "pkgimporters_cache_hit_ratio",
+		Help: "Fraction of importer counts served from the cache, or 0 before the first lookup.",
+	}, func() float64 {
+		lookups := m.lookups.Load()
+		if lookups == 0 {
+			return 0
+		}
+		return float64(m.hits.Load()) / float64(lookups)
+	})
+	return m
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.requests, m.errors, m.retries, m.cacheHits, m.cacheMisses, m.revalidated, m.hedged, m.latency, m.hitRatio}
+}
+
+// ObserveRequest implements pkgimporters.Metrics.
+func (m *Metrics) ObserveRequest(d time.Duration, err error) {
+	m.requests.Inc()
+	m.latency.Observe(d.Seconds())
+	if err != nil {
+		m.errors.Inc()
+	}
+}
+
+// ObserveCache implements pkgimporters.Metrics.
+func (m *Metrics) ObserveCache(hit bool) {
+	m.lookups.Add(1)
+	if hit {
+		m.hits.Add(1)
+		m.cacheHits.Inc()
+	} else {
+		m.cacheMisses.Inc()
+	}
+}
+
+// ObserveRevalidation implements pkgimporters.Metrics.
+func (m *Metrics) ObserveRevalidation() {
+	m.revalidated.Inc()
+}
+
+// ObserveHedge implements pkgimporters.Metrics.
+func (m *Metrics) ObserveHedge() {
+	m.hedged.Inc()
+}
+
+// ObserveRetry implements pkgimporters.Metrics.
+func (m *Metrics) ObserveRetry() {
+	m.retries.Inc()
+}
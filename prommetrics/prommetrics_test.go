@@ -0,0 +1,66 @@
+package prommetrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alexandear/pkgimporters"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := New()
+	client := &pkgimporters.Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			if pkgPath == "example.com/unknown" {
+				return 0, pkgimporters.ErrNotFound
+			}
+			return 3, nil
+		}),
+		Metrics: metrics,
+	}
+
+	for _, path := range []string{"fmt", "fmt", "example.com/unknown"} {
+		_, _ = client.ImporterCount(t.Context(), path)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(metrics); err != nil {
+		t.Fatal(err)
+	}
+
+	const want = `
+# HELP pkgimporters_cache_hit_ratio Fraction of importer counts served from the cache, or 0 before the first lookup.
+# TYPE pkgimporters_cache_hit_ratio gauge
+pkgimporters_cache_hit_ratio 0.3333333333333333
+# HELP pkgimporters_cache_hits_total Number of importer counts served from the cache.
+# TYPE pkgimporters_cache_hits_total counter
+pkgimporters_cache_hits_total 1
+# HELP pkgimporters_cache_misses_total Number of importer counts not found in the cache.
+# TYPE pkgimporters_cache_misses_total counter
+pkgimporters_cache_misses_total 2
+# HELP pkgimporters_request_errors_total Number of requests to the importer count source that failed.
+# TYPE pkgimporters_request_errors_total counter
+pkgimporters_request_errors_total 1
+# HELP pkgimporters_requests_total Number of requests made to the importer count source.
+# TYPE pkgimporters_requests_total counter
+pkgimporters_requests_total 2
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"pkgimporters_cache_hit_ratio", "pkgimporters_cache_hits_total", "pkgimporters_cache_misses_total",
+		"pkgimporters_request_errors_total", "pkgimporters_requests_total")
+	if err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(metrics, "pkgimporters_request_duration_seconds"); n != 1 {
+		t.Errorf("expected 1 latency histogram, got %d", n)
+	}
+}
+
+type sourceFunc func(ctx context.Context, pkgPath string) (int, error)
+
+func (f sourceFunc) Count(ctx context.Context, pkgPath string) (int, error) {
+	return f(ctx, pkgPath)
+}
@@ -0,0 +1,114 @@
+package pkgimporters
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+)
+
+// DefaultProxyURL is the module proxy used by ReleaseAge when ProxyURL is empty.
+const DefaultProxyURL = "https://proxy.golang.org"
+
+// ReleaseAge is an Enricher that adds the number of days since the latest version of the module
+// providing a package was published, from the @latest endpoint of a module proxy
+// (https://go.dev/ref/mod#goproxy-protocol), to flag popular but stale packages.
+// For modules without tagged versions, the latest version is the pseudo-version of the latest commit.
+// The module is the longest prefix of the package path the proxy knows, so nested modules are found,
+// and each module is requested once. Standard library packages are not enriched.
+// A ReleaseAge must not be copied after first use.
+type ReleaseAge struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// ProxyURL is the URL of the module proxy. If empty, DefaultProxyURL is used.
+	ProxyURL string
+
+	now    func() time.Time // for tests
+	latest repoMemo[*time.Time]
+}
+
+// Enrich implements Enricher, setting r.AgeDays, or leaving it nil if the proxy does not know the module.
+// The module is that of the canonical path if the package was redirected.
+func (s *ReleaseAge) Enrich(ctx context.Context, r *Result) error {
+	pkgPath := cmp.Or(r.CanonicalPath, r.Path)
+	if isStdPath(pkgPath) {
+		return nil
+	}
+	released, err := s.Latest(ctx, pkgPath)
+	if err != nil {
+		return fmt.Errorf("module proxy: %w", err)
+	}
+	if released == nil {
+		return nil
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	days := int(now().Sub(*released) / (24 * time.Hour))
+	r.AgeDays = &days
+	return nil
+}
+
+// Latest returns the time the latest version of the module providing pkgPath was published,
+// or nil if the proxy knows no module providing it.
+func (s *ReleaseAge) Latest(ctx context.Context, pkgPath string) (*time.Time, error) {
+	for modPath := pkgPath; strings.Contains(modPath, "/"); modPath = modPath[:strings.LastIndex(modPath, "/")] {
+		released, err := s.latest.get(modPath, func() (*time.Time, error) {
+			return s.fetch(ctx, modPath)
+		})
+		if err != nil || released != nil {
+			return released, err
+		}
+	}
+	return nil, nil
+}
+
+// fetch returns the publish time of the latest version of the module modPath,
+// or nil if the proxy does not know the module.
+func (s *ReleaseAge) fetch(ctx context.Context, modPath string) (*time.Time, error) {
+	escPath, err := module.EscapePath(modPath)
+	if err != nil {
+		return nil, nil
+	}
+	u := strings.TrimSuffix(cmp.Or(s.ProxyURL, DefaultProxyURL), "/") + "/" + escPath + "/@latest"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		// The proxy responds with 404 or 410 for paths that are not modules.
+		return nil, nil
+	default:
+		return nil, newStatusError(resp)
+	}
+
+	var info struct {
+		Version string    `json:"Version"`
+		Time    time.Time `json:"Time"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&info); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &info.Time, nil
+}
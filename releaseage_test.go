@@ -0,0 +1,52 @@
+package pkgimporters
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReleaseAgeEnrich(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://proxy.golang.org/github.com/!burnt!sushi/toml/@latest":   []byte(`{"Version": "v1.4.0", "Time": "2024-06-10T12:00:00Z"}`),
+		"https://proxy.golang.org/go.opentelemetry.io/otel/sdk/@latest":   []byte(`{"Version": "v1.31.0", "Time": "2024-10-11T00:00:00Z"}`),
+		"https://proxy.golang.org/go.opentelemetry.io/otel/@latest":       []byte(`{"Version": "v1.31.0", "Time": "2024-10-11T00:00:00Z"}`),
+		"https://proxy.golang.org/github.com/example/untagged/@latest":    []byte(`{"Version": "v0.0.0-20200101000000-abcdefabcdef", "Time": "2020-01-01T00:00:00Z"}`),
+		"https://proxy.golang.org/github.com/example/untagged/v2/@latest": []byte(`{"Version": "v2.0.0", "Time": "2024-10-01T00:00:00Z"}`),
+	}}
+	enricher := &ReleaseAge{
+		HTTPClient: &http.Client{Transport: transport},
+		now:        func() time.Time { return time.Date(2024, 10, 21, 6, 0, 0, 0, time.UTC) },
+	}
+
+	tests := []struct {
+		pkgPath string
+		want    int
+	}{
+		{pkgPath: "github.com/BurntSushi/toml", want: 132},
+		{pkgPath: "github.com/BurntSushi/toml/internal", want: 132},
+		{pkgPath: "go.opentelemetry.io/otel/sdk/trace", want: 10},
+		{pkgPath: "github.com/example/untagged/pkg", want: 1755},
+	}
+	for _, tt := range tests {
+		r := Result{Path: tt.pkgPath}
+		if err := enricher.Enrich(t.Context(), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.AgeDays == nil || *r.AgeDays != tt.want {
+			t.Errorf("%s: expected %d days, got %v", tt.pkgPath, tt.want, r.AgeDays)
+		}
+	}
+	if n := len(slices.DeleteFunc(slices.Clone(transport.requestedURLs), func(u string) bool {
+		return u != "https://proxy.golang.org/github.com/!burnt!sushi/toml/@latest"
+	})); n != 1 {
+		t.Errorf("expected a single request for the module, got %d", n)
+	}
+
+	for _, r := range []Result{{Path: "fmt"}, {Path: "example.com/unknown/pkg"}} {
+		if err := enricher.Enrich(t.Context(), &r); err != nil || r.AgeDays != nil {
+			t.Errorf("%s: expected no age, got %v, %v", r.Path, r.AgeDays, err)
+		}
+	}
+}
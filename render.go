@@ -0,0 +1,303 @@
+package pkgimporters
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaVersion is the version of the schema of the JSON and CSV output formats,
+// reported in their schema_version field and column so that parsers can rely on the format across releases.
+// Within a schema version, fields and columns are only added, CSV columns at the end;
+// removing, renaming, or changing the meaning of a field or column increments it.
+// It also versions the JSON and CSV output of the subcommands.
+const SchemaVersion = 1
+
+// Renderer writes results to w in an output format.
+type Renderer interface {
+	Render(w io.Writer, results []Result) error
+}
+
+// StreamRenderer is a Renderer that can also write results one at a time as they arrive,
+// e.g., for the -stream flag.
+type StreamRenderer interface {
+	Renderer
+
+	// RenderStream writes the header of the format, if any, to w
+	// and returns a function that writes a single result.
+	RenderStream(w io.Writer) (func(Result) error, error)
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = map[string]Renderer{
+		"text": TextRenderer{},
+		"json": JSONRenderer{},
+		"csv":  CSVRenderer{},
+	}
+)
+
+// RegisterRenderer makes a renderer available by name, e.g., for the -format flag.
+// It replaces any renderer already registered under the same name.
+func RegisterRenderer(name string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[name] = r
+}
+
+// LookupRenderer returns the renderer registered under name.
+func LookupRenderer(name string) (Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	r, ok := renderers[name]
+	return r, ok
+}
+
+// RendererNames returns the sorted names of the registered renderers.
+func RendererNames() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TextRenderer renders results as aligned "path count" lines with human-friendly counts,
+// followed by "(N modules)" if the number of importing modules is known
+// "(N vulns)" if the package has known vulnerabilities, "(license L)" if its license is known,
+// "(vX.Y.Z, published YYYY-MM-DD)" if its latest version is known,
+// "(imports N)" if the number of packages it imports is known,
+// "(N stars, M forks)" if the popularity of its GitHub repository is known,
+// "(scorecard S)" if the OpenSSF Scorecard score of its repository is known,
+// "(released N days ago)" if the age of the latest version of its module is known,
+// and "(not redistributable)" if pkg.go.dev does not consider it redistributable.
+// Redirected packages are marked with "(moved to canonical path)", stale counts with "(stale)",
+// and failed packages are rendered as "path STATUS message".
+type TextRenderer struct{}
+
+// Render implements Renderer.
+func (TextRenderer) Render(w io.Writer, results []Result) error {
+	// Find max width for alignment
+	maxWidth := 0
+	for _, r := range results {
+		if len(r.Path) > maxWidth {
+			maxWidth = len(r.Path)
+		}
+	}
+
+	// Ensure at least 20 characters for better readability
+	if maxWidth < 20 {
+		maxWidth = 20
+	}
+
+	for _, r := range results {
+		if _, err := fmt.Fprintf(w, "%-*s %s\n", maxWidth, r.Path, textValue(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderStream implements StreamRenderer.
+// Paths are padded to 20 characters, as the longest path is not known in advance.
+func (TextRenderer) RenderStream(w io.Writer) (func(Result) error, error) {
+	return func(r Result) error {
+		_, err := fmt.Fprintf(w, "%-20s %s\n", r.Path, textValue(r))
+		return err
+	}, nil
+}
+
+// textValue returns the text rendering of r without its path.
+func textValue(r Result) string {
+	value := FormatCount(r.Count)
+	if r.Modules > 0 {
+		value += " (" + FormatCount(r.Modules) + " modules)"
+	}
+	if len(r.Vulns) > 0 {
+		value += " (" + FormatCount(len(r.Vulns)) + " vulns)"
+	}
+	if r.License != "" {
+		value += " (license " + r.License + ")"
+	}
+	if r.Version != "" {
+		value += " (" + r.Version
+		if !r.Published.IsZero() {
+			value += ", published " + r.Published.Format(time.DateOnly)
+		}
+		value += ")"
+	}
+	if r.Imports != nil {
+		value += " (imports " + FormatCount(*r.Imports) + ")"
+	}
+	if r.GitHub != nil {
+		value += " (" + FormatCount(r.GitHub.Stars) + " stars, " + FormatCount(r.GitHub.Forks) + " forks)"
+	}
+	if r.Scorecard != nil {
+		value += " (scorecard " + strconv.FormatFloat(*r.Scorecard, 'f', 1, 64) + ")"
+	}
+	if r.AgeDays != nil {
+		value += " (released " + FormatCount(*r.AgeDays) + " days ago)"
+	}
+	if r.Redistributable != nil && !*r.Redistributable {
+		value += " (not redistributable)"
+	}
+	if r.Error != "" {
+		value = string(cmp.Or(r.Status, StatusFailed)) + " " + r.Error
+	}
+	if r.CanonicalPath != "" {
+		value += " (moved to " + r.CanonicalPath + ")"
+	}
+	if r.Stale {
+		value += " (stale)"
+	}
+	return value
+}
+
+// JSONRenderer renders results as an indented JSON array of objects
+// with the fields of Result and a schema_version field with SchemaVersion.
+type JSONRenderer struct{}
+
+// Render implements Renderer.
+func (JSONRenderer) Render(w io.Writer, results []Result) error {
+	versioned := make([]versionedResult, 0, len(results))
+	for _, r := range results {
+		versioned = append(versioned, versionedResult{SchemaVersion: SchemaVersion, Result: r})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(versioned)
+}
+
+// RenderStream implements StreamRenderer.
+// It writes JSON Lines: one compact JSON object per result.
+func (JSONRenderer) RenderStream(w io.Writer) (func(Result) error, error) {
+	enc := json.NewEncoder(w)
+	return func(r Result) error {
+		return enc.Encode(versionedResult{SchemaVersion: SchemaVersion, Result: r})
+	}, nil
+}
+
+// versionedResult is the JSON object of a result in the JSON format.
+type versionedResult struct {
+	SchemaVersion int `json:"schema_version"`
+	Result
+}
+
+// CSVRenderer renders results as CSV with a header row.
+// The schema_version column holds SchemaVersion,
+// the canonical_path column is empty for packages that were not redirected,
+// the error column is empty for packages that were fetched,
+// the modules column is empty if the number of importing modules is unknown,
+// the examples column holds the space-separated example importers, if any,
+// the vulns column the space-separated IDs of known vulnerabilities, if any,
+// the license column is empty if the license is unknown,
+// the version and published columns are empty if the latest version is unknown,
+// the imports column is empty if the number of imported packages is unknown,
+// the stars and forks columns are empty if the popularity of the GitHub repository is unknown,
+// the scorecard column is empty if the Scorecard score is unknown,
+// the age_days column is empty if the age of the latest version is unknown,
+// the redistributable column is true, false, or empty if unknown,
+// and the time column is empty unless the time of the run is set.
+type CSVRenderer struct{}
+
+// Render implements Renderer.
+func (CSVRenderer) Render(w io.Writer, results []Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if err := cw.Write(csvRecord(r)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// RenderStream implements StreamRenderer.
+func (CSVRenderer) RenderStream(w io.Writer) (func(Result) error, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+	return func(r Result) error {
+		if err := cw.Write(csvRecord(r)); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	}, nil
+}
+
+var csvHeader = []string{"schema_version", "path", "count", "status", "canonical_path", "error", "modules", "examples", "vulns", "license", "version", "published", "imports", "stars", "forks", "scorecard", "age_days", "redistributable", "time"}
+
+func csvRecord(r Result) []string {
+	modules := ""
+	if r.Modules > 0 {
+		modules = strconv.Itoa(r.Modules)
+	}
+	published := ""
+	if !r.Published.IsZero() {
+		published = r.Published.Format(time.DateOnly)
+	}
+	imports := ""
+	if r.Imports != nil {
+		imports = strconv.Itoa(*r.Imports)
+	}
+	stars, forks := "", ""
+	if r.GitHub != nil {
+		stars, forks = strconv.Itoa(r.GitHub.Stars), strconv.Itoa(r.GitHub.Forks)
+	}
+	scorecard := ""
+	if r.Scorecard != nil {
+		scorecard = strconv.FormatFloat(*r.Scorecard, 'f', -1, 64)
+	}
+	ageDays := ""
+	if r.AgeDays != nil {
+		ageDays = strconv.Itoa(*r.AgeDays)
+	}
+	redistributable := ""
+	if r.Redistributable != nil {
+		redistributable = strconv.FormatBool(*r.Redistributable)
+	}
+	runTime := ""
+	if !r.Time.IsZero() {
+		runTime = r.Time.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.Itoa(SchemaVersion), r.Path, strconv.Itoa(r.Count), string(r.Status), r.CanonicalPath, r.Error,
+		modules, strings.Join(r.Examples, " "), strings.Join(r.Vulns, " "), r.License, r.Version, published, imports, stars, forks, scorecard, ageDays, redistributable, runTime,
+	}
+}
+
+// FormatCount returns a human-friendly string representation of a number with comma separators.
+func FormatCount(n int) string {
+	str := strconv.Itoa(n)
+	var result strings.Builder
+	if n < 0 {
+		result.WriteByte('-')
+		str = str[1:]
+	}
+	for i, c := range str {
+		if i > 0 && (len(str)-i)%3 == 0 {
+			result.WriteRune(',')
+		}
+		result.WriteRune(c)
+	}
+	return result.String()
+}
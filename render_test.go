@@ -0,0 +1,229 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRenderers(t *testing.T) {
+	toolsScore, logrusAge, yes, no := 8.2, 520, true, false
+	results := []Result{
+		{Path: "fmt", Count: 5485422, Status: StatusOK, License: "BSD-3-Clause", Imports: new(int), Redistributable: &yes},
+		{Path: "golang.org/x/tools/go/analysis", Count: 6136, Status: StatusOK, Modules: 2981, Scorecard: &toolsScore, Examples: []string{"4d63.com/gocheckcompilerdirectives/checkcompilerdirectives", "andy.dev/omitlint"}},
+		{Path: "github.com/Sirupsen/logrus", Count: 42, Status: StatusOK, CanonicalPath: "github.com/sirupsen/logrus",
+			Version: "v1.9.3", Published: time.Date(2023, 5, 21, 0, 0, 0, 0, time.UTC), GitHub: &GitHubRepo{Name: "sirupsen/logrus", Stars: 25012, Forks: 2271}, AgeDays: &logrusAge},
+		{Path: "golang.org/x/net/html", Count: 31207, Status: StatusOK, Redistributable: &no, Vulns: []string{"GO-2023-1988", "GO-2024-3333"}},
+		{Path: "example.com/unknown", Status: StatusNotFound, Error: "package not found"},
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			name: "text",
+			want: "fmt                            5,485,422 (license BSD-3-Clause) (imports 0)\n" +
+				"golang.org/x/tools/go/analysis 6,136 (2,981 modules) (scorecard 8.2)\n" +
+				"github.com/Sirupsen/logrus     42 (v1.9.3, published 2023-05-21) (25,012 stars, 2,271 forks) (released 520 days ago) (moved to github.com/sirupsen/logrus)\n" +
+				"golang.org/x/net/html          31,207 (2 vulns) (not redistributable)\n" +
+				"example.com/unknown            NOT_FOUND package not found\n",
+		},
+		{
+			name: "json",
+			want: `[
+  {
+    "schema_version": 1,
+    "path": "fmt",
+    "count": 5485422,
+    "status": "OK",
+    "license": "BSD-3-Clause",
+    "imports": 0,
+    "redistributable": true
+  },
+  {
+    "schema_version": 1,
+    "path": "golang.org/x/tools/go/analysis",
+    "count": 6136,
+    "status": "OK",
+    "modules": 2981,
+    "examples": [
+      "4d63.com/gocheckcompilerdirectives/checkcompilerdirectives",
+      "andy.dev/omitlint"
+    ],
+    "scorecard": 8.2
+  },
+  {
+    "schema_version": 1,
+    "path": "github.com/Sirupsen/logrus",
+    "count": 42,
+    "status": "OK",
+    "canonical_path": "github.com/sirupsen/logrus",
+    "version": "v1.9.3",
+    "published": "2023-05-21T00:00:00Z",
+    "github": {
+      "name": "sirupsen/logrus",
+      "stars": 25012,
+      "forks": 2271
+    },
+    "age_days": 520
+  },
+  {
+    "schema_version": 1,
+    "path": "golang.org/x/net/html",
+    "count": 31207,
+    "status": "OK",
+    "redistributable": false,
+    "vulns": [
+      "GO-2023-1988",
+      "GO-2024-3333"
+    ]
+  },
+  {
+    "schema_version": 1,
+    "path": "example.com/unknown",
+    "count": 0,
+    "status": "NOT_FOUND",
+    "error": "package not found"
+  }
+]
+`,
+		},
+		{
+			name: "csv",
+			want: "schema_version,path,count,status,canonical_path,error,modules,examples,vulns,license,version,published,imports,stars,forks,scorecard,age_days,redistributable,time\n" +
+				"1,fmt,5485422,OK,,,,,,BSD-3-Clause,,,0,,,,,true,\n" +
+				"1,golang.org/x/tools/go/analysis,6136,OK,,,2981,4d63.com/gocheckcompilerdirectives/checkcompilerdirectives andy.dev/omitlint,,,,,,,,8.2,,,\n" +
+				"1,github.com/Sirupsen/logrus,42,OK,github.com/sirupsen/logrus,,,,,,v1.9.3,2023-05-21,,25012,2271,,520,,\n" +
+				"1,golang.org/x/net/html,31207,OK,,,,,GO-2023-1988 GO-2024-3333,,,,,,,,,false,\n" +
+				"1,example.com/unknown,0,NOT_FOUND,,package not found,,,,,,,,,,,,,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := LookupRenderer(tt.name)
+			if !ok {
+				t.Fatalf("renderer %q is not registered", tt.name)
+			}
+
+			var buf bytes.Buffer
+			if err := r.Render(&buf, results); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStreamRenderers(t *testing.T) {
+	results := []Result{
+		{Path: "fmt", Count: 5485422, Status: StatusOK},
+		{Path: "example.com/unknown", Status: StatusNotFound, Error: "package not found"},
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			name: "text",
+			want: "fmt                  5,485,422\n" +
+				"example.com/unknown  NOT_FOUND package not found\n",
+		},
+		{
+			name: "json",
+			want: `{"schema_version":1,"path":"fmt","count":5485422,"status":"OK"}` + "\n" +
+				`{"schema_version":1,"path":"example.com/unknown","count":0,"status":"NOT_FOUND","error":"package not found"}` + "\n",
+		},
+		{
+			name: "csv",
+			want: "schema_version,path,count,status,canonical_path,error,modules,examples,vulns,license,version,published,imports,stars,forks,scorecard,age_days,redistributable,time\n" +
+				"1,fmt,5485422,OK,,,,,,,,,,,,,,,\n" +
+				"1,example.com/unknown,0,NOT_FOUND,,package not found,,,,,,,,,,,,,\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := LookupRenderer(tt.name)
+			sr, ok := r.(StreamRenderer)
+			if !ok {
+				t.Fatalf("renderer %q does not implement StreamRenderer", tt.name)
+			}
+			var buf bytes.Buffer
+			write, err := sr.RenderStream(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range results {
+				if err := write(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	RegisterRenderer("paths", rendererFunc(func(w io.Writer, results []Result) error {
+		for _, r := range results {
+			if _, err := io.WriteString(w, r.Path+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	t.Cleanup(func() {
+		renderersMu.Lock()
+		defer renderersMu.Unlock()
+		delete(renderers, "paths")
+	})
+
+	if !slices.Contains(RendererNames(), "paths") {
+		t.Errorf("expected registered renderer in %v", RendererNames())
+	}
+	r, ok := LookupRenderer("paths")
+	if !ok {
+		t.Fatal("expected registered renderer")
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, []Result{{Path: "fmt"}}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "fmt\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+type rendererFunc func(w io.Writer, results []Result) error
+
+func (f rendererFunc) Render(w io.Writer, results []Result) error {
+	return f(w, results)
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: "0"},
+		{n: 999, want: "999"},
+		{n: 1000, want: "1,000"},
+		{n: 1533321, want: "1,533,321"},
+		{n: -100, want: "-100"},
+		{n: -1533321, want: "-1,533,321"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatCount(tt.n); got != tt.want {
+			t.Errorf("FormatCount(%d): expected %q, got %q", tt.n, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package pkgimporters
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+)
+
+// Default retry policy values used when the fields of RetryPolicy are zero.
+const (
+	DefaultRetryAttempts = 4
+	DefaultRetryDelay    = 500 * time.Millisecond
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// RetryPolicy configures how a Client retries transient failures.
+// The delay before retry n is BaseDelay*2^(n-1), capped at MaxDelay, with random jitter,
+// unless the response requested a delay with a Retry-After header.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of requests made for a package, including the first.
+	// If zero, DefaultRetryAttempts is used; 1 disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. If zero, DefaultRetryDelay is used.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay before a retry. If zero, DefaultRetryMaxDelay is used.
+	MaxDelay time.Duration
+}
+
+func (p RetryPolicy) maxAttempts() int {
+	return cmp.Or(p.MaxAttempts, DefaultRetryAttempts)
+}
+
+// backoff returns the delay before retrying after the given failed attempt, starting at 1.
+// Half of the delay is random jitter, so concurrent workers do not retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	maxDelay := cmp.Or(p.MaxDelay, DefaultRetryMaxDelay)
+	d := cmp.Or(p.BaseDelay, DefaultRetryDelay)
+	for range attempt - 1 {
+		if d >= maxDelay {
+			break
+		}
+		d *= 2
+	}
+	d = min(d, maxDelay)
+	return d/2 + rand.N(d/2+1)
+}
+
+// isRetryable reports whether err is a transient failure worth retrying:
+// a timeout, a connection reset, a 5xx response, or throttling.
+func isRetryable(err error) bool {
+	if isThrottled(err) {
+		return true
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryDelay returns how long to wait before retrying after the given failed attempt:
+// the Retry-After delay of the response, capped at maxRetryAfter, or the policy backoff.
+func (c *Client) retryDelay(attempt int, err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, maxRetryAfter)
+	}
+	return c.Retry.backoff(attempt)
+}
+
+// retry records that the request for pkgPath is retried after the given failed attempt
+// and sleeps until the retry is due.
+func (c *Client) retry(ctx context.Context, pkgPath string, attempt int, err error) error {
+	delay := c.retryDelay(attempt, err)
+	c.metrics().ObserveRetry()
+	c.logger().DebugContext(ctx, "retry", "pkg", pkgPath, "attempt", attempt, "delay", delay, "err", err)
+	if c.OnRetry != nil {
+		c.OnRetry(pkgPath, attempt, err)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,109 @@
+package pkgimporters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestClientRetry(t *testing.T) {
+	errServer := &StatusError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}
+	newClient := func(maxAttempts int, calls *int) *Client {
+		return &Client{
+			RequestsPerSecond: 100,
+			Retry:             RetryPolicy{MaxAttempts: maxAttempts, BaseDelay: time.Millisecond},
+			Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+				*calls++
+				if *calls <= 2 {
+					return 0, errServer
+				}
+				return 42, nil
+			}),
+		}
+	}
+
+	t.Run("recovers", func(t *testing.T) {
+		calls := 0
+		count, err := newClient(3, &calls).ImporterCount(t.Context(), "fmt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 42 || calls != 3 {
+			t.Errorf("expected count 42 after 3 calls, got %d after %d", count, calls)
+		}
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		calls := 0
+		_, err := newClient(2, &calls).ImporterCount(t.Context(), "fmt")
+		if !errors.Is(err, errServer) {
+			t.Errorf("expected bad gateway error, got %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("expected 2 calls, got %d", calls)
+		}
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		calls := 0
+		client := &Client{
+			Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+				calls++
+				return 0, ErrNotFound
+			}),
+		}
+		if _, err := client.ImporterCount(t.Context(), "fmt"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &StatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{err: &StatusError{StatusCode: http.StatusInternalServerError}, want: true},
+		{err: &StatusError{StatusCode: http.StatusForbidden}, want: false},
+		{err: fmt.Errorf("do request: %w", context.DeadlineExceeded), want: true},
+		{err: fmt.Errorf("do request: %w", syscall.ECONNRESET), want: true},
+		{err: fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), want: true},
+		{err: ErrNotFound, want: false},
+		{err: errors.New("parse count: invalid syntax"), want: false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("isRetryable(%v): expected %v, got %v", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 1, max: 100 * time.Millisecond},
+		{attempt: 2, max: 200 * time.Millisecond},
+		{attempt: 3, max: 400 * time.Millisecond},
+		{attempt: 5, max: time.Second},
+		{attempt: 50, max: time.Second},
+	}
+	for _, tt := range tests {
+		for range 100 {
+			if got := p.backoff(tt.attempt); got < tt.max/2 || got > tt.max {
+				t.Fatalf("backoff(%d): expected delay in [%v, %v], got %v", tt.attempt, tt.max/2, tt.max, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package pkgimporters
+
+import _ "embed"
+
+//go:embed schema.json
+var jsonSchema string
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the output of JSONRenderer for SchemaVersion,
+// an array of result objects. Each line of the output of its RenderStream is a single result object.
+// The JSON output of the subcommands is versioned by SchemaVersion too, but has no published schema.
+func JSONSchema() string {
+	return jsonSchema
+}
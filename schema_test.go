@@ -0,0 +1,70 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSchema struct {
+	Defs struct {
+		Result struct {
+			Required   []string `json:"required"`
+			Properties map[string]struct {
+				Const *int     `json:"const"`
+				Enum  []string `json:"enum"`
+			} `json:"properties"`
+		} `json:"result"`
+	} `json:"$defs"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	var schema testSchema
+	if err := json.Unmarshal([]byte(JSONSchema()), &schema); err != nil {
+		t.Fatal(err)
+	}
+	properties := schema.Defs.Result.Properties
+
+	fields := []string{"schema_version"}
+	typ := reflect.TypeFor[Result]()
+	for i := range typ.NumField() {
+		fields = append(fields, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+	}
+	slices.Sort(fields)
+	if got := slices.Sorted(maps.Keys(properties)); !slices.Equal(got, fields) {
+		t.Errorf("expected properties %v, got %v", fields, got)
+	}
+	if v := properties["schema_version"].Const; v == nil || *v != SchemaVersion {
+		t.Errorf("expected schema_version const %d, got %v", SchemaVersion, v)
+	}
+	statuses := []string{string(StatusOK), string(StatusNotFound), string(StatusBlocked), string(StatusParseError), string(StatusTimeout), string(StatusFailed)}
+	if got := properties["status"].Enum; !slices.Equal(got, statuses) {
+		t.Errorf("expected status enum %v, got %v", statuses, got)
+	}
+
+	// Every required field is present in the output, even for a failed package.
+	var buf bytes.Buffer
+	results := []Result{
+		{Path: "fmt", Count: 5485422, Status: StatusOK, Published: time.Date(2023, 5, 21, 0, 0, 0, 0, time.UTC)},
+		{Path: "example.com/unknown", Status: StatusNotFound, Error: "package not found"},
+	}
+	if err := (JSONRenderer{}).Render(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	var objects []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &objects); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range objects {
+		for _, name := range schema.Defs.Result.Required {
+			if _, ok := o[name]; !ok {
+				t.Errorf("expected required field %q in %v", name, o)
+			}
+		}
+	}
+}
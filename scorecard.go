@@ -0,0 +1,82 @@
+package pkgimporters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const scorecardURL = "https://api.securityscorecards.dev"
+
+// Scorecard is an Enricher that adds the aggregate OpenSSF Scorecard score of the repository hosting a package
+// from the public Scorecard API (https://api.securityscorecards.dev), a measure of its security practices.
+// Only packages under github.com and gitlab.com are enriched, and only repositories scanned by the
+// OpenSSF have a score; vanity import paths are not resolved.
+// Each repository is requested once, however many of its packages are enriched.
+// A Scorecard must not be copied after first use.
+type Scorecard struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	scores repoMemo[*float64]
+}
+
+// Enrich implements Enricher, setting r.Scorecard, or leaving it nil
+// if the repository of the package has no score.
+// The repository is that of the canonical path if the package was redirected.
+func (s *Scorecard) Enrich(ctx context.Context, r *Result) error {
+	path := repoPath(r, "github.com", "gitlab.com")
+	if path == "" {
+		return nil
+	}
+	score, err := s.Score(ctx, path)
+	if err != nil {
+		return fmt.Errorf("scorecard: %w", err)
+	}
+	r.Scorecard = score
+	return nil
+}
+
+// Score returns the aggregate score, from 0 to 10, of the repository at path, e.g., "github.com/spf13/cobra",
+// or nil if the repository has not been scanned.
+func (s *Scorecard) Score(ctx context.Context, path string) (*float64, error) {
+	return s.scores.get(path, func() (*float64, error) {
+		return s.fetch(ctx, path)
+	})
+}
+
+func (s *Scorecard) fetch(ctx context.Context, path string) (*float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scorecardURL+"/projects/"+path, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, newStatusError(resp)
+	}
+
+	var result struct {
+		Score *float64 `json:"score"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return result.Score, nil
+}
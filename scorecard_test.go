@@ -0,0 +1,45 @@
+package pkgimporters
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestScorecardEnrich(t *testing.T) {
+	transport := &urlTransport{files: map[string][]byte{
+		"https://api.securityscorecards.dev/projects/github.com/spf13/cobra": []byte(`{
+			"date": "2024-09-30",
+			"repo": {"name": "github.com/spf13/cobra", "commit": "e94f6d0dd9a5e5738dca6bce03c4b1207ffbc0ec"},
+			"score": 5.4,
+			"checks": [{"name": "Maintained", "score": 10}]
+		}`),
+		"https://api.securityscorecards.dev/projects/gitlab.com/gitlab-org/labkit": []byte(`{"score": 0}`),
+	}}
+	enricher := &Scorecard{HTTPClient: &http.Client{Transport: transport}}
+
+	tests := []struct {
+		r    Result
+		want float64
+	}{
+		{r: Result{Path: "github.com/spf13/cobra/doc"}, want: 5.4},
+		{r: Result{Path: "github.com/spf13/Cobra"}, want: 5.4},
+		{r: Result{Path: "gitlab.com/gitlab-org/labkit/log"}, want: 0},
+	}
+	for _, tt := range tests {
+		if err := enricher.Enrich(t.Context(), &tt.r); err != nil {
+			t.Fatal(err)
+		}
+		if tt.r.Scorecard == nil || *tt.r.Scorecard != tt.want {
+			t.Errorf("%s: expected score %v, got %v", tt.r.Path, tt.want, tt.r.Scorecard)
+		}
+	}
+	if len(transport.requestedURLs) != 2 {
+		t.Errorf("expected a single request per repository, got %v", transport.requestedURLs)
+	}
+
+	for _, r := range []Result{{Path: "fmt"}, {Path: "go.uber.org/zap"}, {Path: "github.com/example/unscanned"}} {
+		if err := enricher.Enrich(t.Context(), &r); err != nil || r.Scorecard != nil {
+			t.Errorf("%s: expected no score, got %v, %v", r.Path, r.Scorecard, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package pkgimporters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+var searchResultRe = regexp.MustCompile(`<a\s+href="/([^"?#]+)"[^>]*\sdata-test-id="snippet-title"`)
+
+// Search returns the paths of the top limit packages found by a pkg.go.dev search for query.
+// It searches c.BaseURL if set. The request counts against the rate limit of c.
+func (c *Client) Search(ctx context.Context, query string, limit int) ([]string, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("q", query)
+	q.Set("m", "package")
+	q.Set("limit", strconv.Itoa(limit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL()+"/search?"+q.Encode(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	var paths []string
+	for _, m := range searchResultRe.FindAllSubmatch(body, -1) {
+		if len(paths) == limit {
+			break
+		}
+		path, err := url.PathUnescape(string(m[1]))
+		if err != nil {
+			return nil, fmt.Errorf("parse result path %q: %w", m[1], err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,42 @@
+package pkgimporters
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestClientSearch(t *testing.T) {
+	const page = `<div class="SearchSnippet">
+  <h2><a href="/gopkg.in/yaml.v3" data-gtmc="search result" data-gtmv="0" data-test-id="snippet-title">yaml</a></h2>
+</div>
+<div class="SearchSnippet">
+  <h2><a href="/github.com/goccy/go-yaml" data-gtmc="search result" data-gtmv="1" data-test-id="snippet-title">yaml</a></h2>
+  <a href="/github.com/goccy/go-yaml?tab=importedby" data-test-id="snippet-importedby">Imported by</a>
+</div>
+<div class="SearchSnippet">
+  <h2><a href="/sigs.k8s.io/yaml" data-gtmc="search result" data-gtmv="2" data-test-id="snippet-title">yaml</a></h2>
+</div>`
+
+	transport := &htmlFileTransport{
+		content: []byte(page),
+	}
+	client := &Client{
+		HTTPClient: &http.Client{Transport: transport},
+	}
+
+	got, err := client.Search(t.Context(), "yaml parser", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantURL := "https://pkg.go.dev/search?limit=2&m=package&q=yaml+parser"
+	if len(transport.requestedURLs) != 1 || transport.requestedURLs[0] != wantURL {
+		t.Errorf("expected request to %q, got %v", wantURL, transport.requestedURLs)
+	}
+
+	want := []string{"gopkg.in/yaml.v3", "github.com/goccy/go-yaml"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
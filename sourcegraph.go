@@ -0,0 +1,102 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultSourcegraphURL is the Sourcegraph instance used when Sourcegraph.Endpoint is empty.
+const DefaultSourcegraphURL = "https://sourcegraph.com"
+
+// Sourcegraph is a Source that counts the repositories importing a package
+// using the Sourcegraph GraphQL search API.
+// Pointed at a private instance, it covers code that pkg.go.dev cannot see.
+type Sourcegraph struct {
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Endpoint is the URL of the Sourcegraph instance. If empty, DefaultSourcegraphURL is used.
+	Endpoint string
+
+	// Token is an optional access token sent with each request.
+	Token string
+}
+
+const sourcegraphSearchQuery = `query Search($query: String!) {
+	search(query: $query, version: V3) {
+		results {
+			matchCount
+		}
+	}
+}`
+
+// Count returns the number of repositories with a Go file importing pkgPath.
+func (s *Sourcegraph) Count(ctx context.Context, pkgPath string) (int, error) {
+	query := "lang:go count:all select:repo patterntype:regexp content:" + strconv.Quote(importPattern(pkgPath))
+	reqBody, err := json.Marshal(map[string]any{
+		"query":     sourcegraphSearchQuery,
+		"variables": map[string]string{"query": query},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("encode request: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(cmp.Or(s.Endpoint, DefaultSourcegraphURL), "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/.api/graphql", bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "token "+s.Token)
+	}
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, newStatusError(resp)
+	}
+
+	var result struct {
+		Data struct {
+			Search struct {
+				Results struct {
+					MatchCount int `json:"matchCount"`
+				} `json:"results"`
+			} `json:"search"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&result); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return 0, fmt.Errorf("search: %s", result.Errors[0].Message)
+	}
+	return result.Data.Search.Results.MatchCount, nil
+}
+
+// importPattern returns a regular expression matching the import declarations of pkgPath in Go files,
+// on their own or in a group and with an optional package name,
+// so string literals equal to the path elsewhere in the code do not count.
+func importPattern(pkgPath string) string {
+	return `\bimport\s*(\([^)]*)?[\s(]([\w.]+\s+)?"` + regexp.QuoteMeta(pkgPath) + `"`
+}
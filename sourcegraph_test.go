@@ -0,0 +1,84 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+func TestSourcegraphCount(t *testing.T) {
+	var gotURL, gotAuth, gotQuery string
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotURL = req.URL.String()
+		gotAuth = req.Header.Get("Authorization")
+
+		var body struct {
+			Variables struct {
+				Query string `json:"query"`
+			} `json:"variables"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		gotQuery = body.Variables.Query
+
+		content := []byte(`{"data": {"search": {"results": {"matchCount": 4242}}}}`)
+		return &http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(content)),
+			Request:    req,
+		}, nil
+	})
+	source := &Sourcegraph{
+		HTTPClient: &http.Client{Transport: transport},
+		Endpoint:   "https://sourcegraph.example.com/",
+		Token:      "secret",
+	}
+
+	count, err := source.Count(t.Context(), "net/http")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 4242 {
+		t.Errorf("expected count 4242, got %d", count)
+	}
+	if want := "https://sourcegraph.example.com/.api/graphql"; gotURL != want {
+		t.Errorf("expected URL %q, got %q", want, gotURL)
+	}
+	if want := "token secret"; gotAuth != want {
+		t.Errorf("expected Authorization %q, got %q", want, gotAuth)
+	}
+	if want := `lang:go count:all select:repo patterntype:regexp content:"\\bimport\\s*(\\([^)]*)?[\\s(]([\\w.]+\\s+)?\"net/http\""`; gotQuery != want {
+		t.Errorf("expected query %q, got %q", want, gotQuery)
+	}
+}
+
+func TestImportPattern(t *testing.T) {
+	re := regexp.MustCompile(importPattern("net/http"))
+	for _, src := range []string{
+		"import \"net/http\"",
+		"import _ \"net/http\"",
+		"import (\n\t\"fmt\"\n\n\t\"net/http\"\n)",
+		"import (\n\tstdhttp \"net/http\" // for the server\n)",
+		"import (\n\t. \"net/http\"\n)",
+	} {
+		if !re.MatchString(src) {
+			t.Errorf("expected %q to match", src)
+		}
+	}
+	for _, src := range []string{
+		"import \"fmt\"\n\nfunc f() string {\n\treturn \"net/http\"\n}",
+		"import (\n\t\"fmt\"\n)\n\nvar paths = []string{\"net/http\"}",
+		"import \"net/http/httptest\"",
+		"import \"netXhttp\"",
+	} {
+		if re.MatchString(src) {
+			t.Errorf("expected %q not to match", src)
+		}
+	}
+}
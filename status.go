@@ -0,0 +1,39 @@
+package pkgimporters
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Status classifies the outcome of fetching the importer count of a package,
+// so that automation can tell an unpopular package from one that could not be fetched.
+type Status string
+
+const (
+	StatusOK         Status = "OK"          // the count was fetched
+	StatusNotFound   Status = "NOT_FOUND"   // the source does not know the package
+	StatusBlocked    Status = "BLOCKED"     // the source responded with a block or CAPTCHA page
+	StatusParseError Status = "PARSE_ERROR" // the page shows no importer count
+	StatusTimeout    Status = "TIMEOUT"     // the request timed out
+	StatusFailed     Status = "ERROR"       // any other failure
+)
+
+// StatusOf returns the status of a fetch that failed with err, or StatusOK if err is nil.
+func StatusOf(err error) Status {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return StatusOK
+	case errors.Is(err, ErrNotFound):
+		return StatusNotFound
+	case errors.Is(err, ErrBlocked):
+		return StatusBlocked
+	case errors.Is(err, ErrParse):
+		return StatusParseError
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return StatusTimeout
+	default:
+		return StatusFailed
+	}
+}
@@ -0,0 +1,27 @@
+package pkgimporters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Status
+	}{
+		{err: nil, want: StatusOK},
+		{err: &PackageError{Path: "example.com/unknown", Err: ErrNotFound}, want: StatusNotFound},
+		{err: fmt.Errorf("%w: unexpected page %q", ErrBlocked, "Just a moment..."), want: StatusBlocked},
+		{err: ErrParse, want: StatusParseError},
+		{err: &PackageError{Path: "fmt", Err: context.DeadlineExceeded}, want: StatusTimeout},
+		{err: errors.New("connection refused"), want: StatusFailed},
+	}
+	for _, tt := range tests {
+		if got := StatusOf(tt.err); got != tt.want {
+			t.Errorf("StatusOf(%v): expected %s, got %s", tt.err, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package pkgimporters
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// maxRetryAfter caps the delay requested by a Retry-After header.
+const maxRetryAfter = 5 * time.Minute
+
+// minLimit is the lowest rate the limiter is slowed down to after throttling.
+const minLimit = rate.Limit(1.0 / 60)
+
+// isThrottled reports whether err is a 429 Too Many Requests or 503 Service Unavailable response.
+func isThrottled(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// maxLimit returns the configured rate of requests, which the adaptive limiter never exceeds.
+func (c *Client) maxLimit() rate.Limit {
+	return rate.Limit(cmp.Or(c.RequestsPerSecond, DefaultRequestsPerSecond))
+}
+
+// slowDown halves the request rate after a throttled response.
+// Responses throttled within a second of the last decrease are counted once,
+// so concurrent workers hitting the same throttle do not collapse the rate.
+func (c *Client) slowDown(ctx context.Context) {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+	if time.Since(c.slowedAt) < time.Second {
+		return
+	}
+	c.slowedAt = time.Now()
+	limit := max(c.limiter.Limit()/2, min(minLimit, c.maxLimit()))
+	c.limiter.SetLimit(limit)
+	c.logger().InfoContext(ctx, "throttled, decreasing request rate", "rps", float64(limit))
+}
+
+// speedUp raises the request rate by a tenth of the configured rate after a successful response,
+// recovering gradually from slowDown.
+func (c *Client) speedUp(ctx context.Context) {
+	c.limitMu.Lock()
+	defer c.limitMu.Unlock()
+	limit := c.limiter.Limit()
+	if limit >= c.maxLimit() {
+		return
+	}
+	limit = min(limit+c.maxLimit()/10, c.maxLimit())
+	c.limiter.SetLimit(limit)
+	c.logger().DebugContext(ctx, "increasing request rate", "rps", float64(limit))
+}
+
+// parseRetryAfter returns the delay of a Retry-After header value,
+// given either as seconds or as an HTTP date relative to now.
+// It returns zero if the value is empty or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
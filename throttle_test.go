@@ -0,0 +1,118 @@
+package pkgimporters
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestClientThrottle(t *testing.T) {
+	calls := 0
+	var retries []int
+	client := &Client{
+		RequestsPerSecond: 100,
+		Retry:             RetryPolicy{BaseDelay: time.Millisecond},
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			calls++
+			if calls <= 2 {
+				return 0, &StatusError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}
+			}
+			return 42, nil
+		}),
+		OnRetry: func(pkgPath string, attempt int, err error) {
+			retries = append(retries, attempt)
+		},
+	}
+
+	count, err := client.ImporterCount(t.Context(), "fmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 42 {
+		t.Errorf("expected count 42, got %d", count)
+	}
+	if len(retries) != 2 {
+		t.Errorf("expected 2 retries, got %v", retries)
+	}
+	// Two throttles within a second halve the rate once, and the success recovers a tenth.
+	if got, want := client.limiter.Limit(), rate.Limit(60); got != want {
+		t.Errorf("expected limit %v after throttling, got %v", want, got)
+	}
+
+	client.speedUp(t.Context())
+	client.speedUp(t.Context())
+	client.speedUp(t.Context())
+	client.speedUp(t.Context())
+	client.speedUp(t.Context())
+	if got, want := client.limiter.Limit(), rate.Limit(100); got != want {
+		t.Errorf("expected limit to recover to %v, got %v", want, got)
+	}
+}
+
+func TestIsThrottled(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &StatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{err: &PackageError{Path: "fmt", Err: &StatusError{StatusCode: http.StatusServiceUnavailable}}, want: true},
+		{err: &StatusError{StatusCode: http.StatusInternalServerError}, want: false},
+		{err: ErrNotFound, want: false},
+	}
+	for _, tt := range tests {
+		if got := isThrottled(tt.err); got != tt.want {
+			t.Errorf("isThrottled(%v): expected %v, got %v", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	calls := 0
+	var retriedAt, throttledAt time.Time
+	client := &Client{
+		RequestsPerSecond: 100,
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			calls++
+			if calls == 1 {
+				throttledAt = time.Now()
+				return 0, &StatusError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable", RetryAfter: time.Second}
+			}
+			retriedAt = time.Now()
+			return 42, nil
+		}),
+	}
+
+	count, err := client.ImporterCount(t.Context(), "fmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 42 {
+		t.Errorf("expected count 42, got %d", count)
+	}
+	if waited := retriedAt.Sub(throttledAt); waited < time.Second {
+		t.Errorf("expected retry after at least 1s, got %v", waited)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "120", want: 2 * time.Minute},
+		{value: "-1", want: 0},
+		{value: "Thu, 02 Jan 2025 15:04:35 GMT", want: 30 * time.Second},
+		{value: "Thu, 02 Jan 2025 15:00:00 GMT", want: 0},
+		{value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q): expected %v, got %v", tt.value, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package pkgimporters
+
+import (
+	"cmp"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultMaxIdleConnsPerHost is the number of idle connections per host kept by NewTransport
+// when TransportOptions.MaxIdleConnsPerHost is zero.
+// It is larger than that of http.DefaultTransport, so concurrent workers reuse their connections.
+const DefaultMaxIdleConnsPerHost = 16
+
+// DefaultIdleConnTimeout and DefaultKeepAlive are used by NewTransport
+// when the corresponding TransportOptions are zero.
+const (
+	DefaultIdleConnTimeout = 90 * time.Second
+	DefaultKeepAlive       = 30 * time.Second
+)
+
+// TransportOptions tunes the connection handling of the transport returned by NewTransport.
+type TransportOptions struct {
+	// MaxIdleConnsPerHost is the number of idle connections kept per host for reuse.
+	// If zero, DefaultMaxIdleConnsPerHost is used.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept before it is closed.
+	// If zero, DefaultIdleConnTimeout is used.
+	IdleConnTimeout time.Duration
+
+	// KeepAlive is the interval between TCP keep-alive probes.
+	// If zero, DefaultKeepAlive is used. If negative, keep-alive probes are disabled.
+	KeepAlive time.Duration
+
+	// DisableHTTP2, if true, restricts connections to HTTP/1.1.
+	DisableHTTP2 bool
+}
+
+// NewTransport returns an HTTP transport dedicated to a Client, based on http.DefaultTransport
+// but keeping enough idle connections per host for long runs with several workers.
+// A Client with a nil HTTPClient uses NewTransport(TransportOptions{}).
+func NewTransport(opts TransportOptions) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: cmp.Or(opts.KeepAlive, DefaultKeepAlive),
+	}).DialContext
+	t.MaxIdleConnsPerHost = cmp.Or(opts.MaxIdleConnsPerHost, DefaultMaxIdleConnsPerHost)
+	t.MaxIdleConns = max(t.MaxIdleConns, t.MaxIdleConnsPerHost)
+	t.IdleConnTimeout = cmp.Or(opts.IdleConnTimeout, DefaultIdleConnTimeout)
+
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(!opts.DisableHTTP2)
+	t.Protocols = &protocols
+	t.ForceAttemptHTTP2 = !opts.DisableHTTP2
+	return t
+}
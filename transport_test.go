@@ -0,0 +1,30 @@
+package pkgimporters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTransport(t *testing.T) {
+	tr := NewTransport(TransportOptions{})
+	if tr.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost {
+		t.Errorf("expected %d idle connections per host, got %d", DefaultMaxIdleConnsPerHost, tr.MaxIdleConnsPerHost)
+	}
+	if tr.IdleConnTimeout != DefaultIdleConnTimeout {
+		t.Errorf("expected idle timeout %v, got %v", DefaultIdleConnTimeout, tr.IdleConnTimeout)
+	}
+	if !tr.Protocols.HTTP2() || !tr.Protocols.HTTP1() {
+		t.Errorf("expected HTTP/1.1 and HTTP/2, got %v", tr.Protocols)
+	}
+
+	tr = NewTransport(TransportOptions{MaxIdleConnsPerHost: 200, IdleConnTimeout: time.Minute, DisableHTTP2: true})
+	if tr.MaxIdleConnsPerHost != 200 || tr.MaxIdleConns < 200 {
+		t.Errorf("expected 200 idle connections per host and in total, got %d and %d", tr.MaxIdleConnsPerHost, tr.MaxIdleConns)
+	}
+	if tr.IdleConnTimeout != time.Minute {
+		t.Errorf("expected idle timeout %v, got %v", time.Minute, tr.IdleConnTimeout)
+	}
+	if tr.Protocols.HTTP2() || tr.ForceAttemptHTTP2 {
+		t.Errorf("expected HTTP/2 to be disabled, got %v", tr.Protocols)
+	}
+}
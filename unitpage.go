@@ -0,0 +1,103 @@
+package pkgimporters
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// docsHiddenNotice is the notice pkg.go.dev shows instead of the documentation of non-redistributable packages.
+const docsHiddenNotice = "not displayed due to license restrictions"
+
+// maxUnitHeaderSize caps how much of the main page of a package is read:
+// the header with the package details comes before the documentation.
+const maxUnitHeaderSize = 128 << 10
+
+// noLicense is the license reported for packages without a detected license, following SPDX.
+const noLicense = "NONE"
+
+// unitPage is what parseUnitPage found in the header of the main page of a package.
+type unitPage struct {
+	// title is the text of the <title> element.
+	title string
+	// header reports whether the page has the details header of a package.
+	header bool
+	// licenses are the license identifiers linked from the header, in page order.
+	licenses []string
+	// version is the version of the module shown, the latest one unless the URL names another.
+	version string
+	// published is the time the version was published, or zero if the header does not show it.
+	published time.Time
+	// imports is the number of packages the package imports, or nil if the header does not show it.
+	imports *int
+	// docsHidden reports whether the page states that the documentation is not displayed
+	// due to license restrictions.
+	docsHidden bool
+}
+
+// parseUnitPage parses the details header of the main page of a package, e.g., https://pkg.go.dev/io.
+func parseUnitPage(body []byte) unitPage {
+	var p unitPage
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return p
+	}
+	p.walk(doc)
+	return p
+}
+
+func (p *unitPage) walk(n *html.Node) {
+	if n.Type == html.TextNode && strings.Contains(n.Data, docsHiddenNotice) {
+		p.docsHidden = true
+	}
+	if n.Type == html.ElementNode {
+		if n.DataAtom == atom.Title && p.title == "" {
+			p.title = strings.TrimSpace(textContent(n))
+		}
+		switch attr(n, "data-test-id") {
+		case "UnitHeader-licenses":
+			p.header = true
+		case "UnitHeader-license":
+			if license := strings.TrimSpace(textContent(n)); license != "" {
+				p.licenses = append(p.licenses, license)
+			}
+			return
+		case "UnitHeader-version":
+			p.version = headerDetail(n, "Version:")
+			return
+		case "UnitHeader-commitTime":
+			p.published, _ = time.Parse("Jan 2, 2006", headerDetail(n, "Published:"))
+			return
+		case "UnitHeader-imports":
+			if imports, ok := parseNumber(headerDetail(n, "Imports:")); ok {
+				p.imports = &imports
+			}
+			return
+		}
+	}
+	for c := range n.ChildNodes() {
+		p.walk(c)
+	}
+}
+
+// headerDetail returns the value of the header detail n, its text without the label.
+func headerDetail(n *html.Node, label string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.Join(strings.Fields(textContent(n)), " "), label))
+}
+
+// license returns the comma-separated licenses of the package, or noLicense if none were detected.
+func (p unitPage) license() string {
+	if len(p.licenses) == 0 {
+		return noLicense
+	}
+	return strings.Join(p.licenses, ", ")
+}
+
+// redistributable reports whether pkg.go.dev considers the package redistributable:
+// it has detected licenses that allow it, so the documentation is displayed.
+func (p unitPage) redistributable() bool {
+	return len(p.licenses) > 0 && !p.docsHidden
+}
@@ -0,0 +1,106 @@
+package pkgimporters
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// unitHeader is the details header of a package's main page on pkg.go.dev, for tests.
+const unitHeader = `<html><head><title>cobra package - github.com/spf13/cobra - Go Packages</title></head><body>
+<div class="go-Main-headerDetails">
+  <span class="go-Main-headerDetailItem" data-test-id="UnitHeader-version">
+    <a href="?tab=versions" aria-label="Version: v1.8.0"><span class="go-textSubtle">Version: </span>v1.8.0</a>
+  </span>
+  <span class="go-Main-headerDetailItem" data-test-id="UnitHeader-commitTime">
+    <span class="go-textSubtle">Published: </span>Nov 4, 2023
+  </span>
+  <span class="go-Main-headerDetailItem" data-test-id="UnitHeader-licenses">
+    <span class="go-textSubtle">License: </span><a href="/github.com/spf13/cobra?tab=licenses#lic-0" data-test-id="UnitHeader-license">Apache-2.0</a>
+  </span>
+  <span class="go-Main-headerDetailItem" data-test-id="UnitHeader-imports">
+    <a href="/github.com/spf13/cobra?tab=imports" aria-label="Imports: 14"><span class="go-textSubtle">Imports: </span>14</a>
+  </span>
+</div>
+</body></html>`
+
+func TestParseUnitPage(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantHeader  bool
+		wantLicense string
+	}{
+		{name: "license", body: unitHeader, wantHeader: true, wantLicense: "Apache-2.0"},
+		{
+			name: "several licenses",
+			body: `<span data-test-id="UnitHeader-licenses"><span>License: </span>` +
+				`<a data-test-id="UnitHeader-license">BSD-3-Clause</a>, <a data-test-id="UnitHeader-license">MIT</a></span>`,
+			wantHeader:  true,
+			wantLicense: "BSD-3-Clause, MIT",
+		},
+		{
+			name: "none detected",
+			body: `<span data-test-id="UnitHeader-licenses"><span>License: </span><span>None detected</span>` +
+				`<a href="/license-policy"><em>not legal advice</em></a></span>`,
+			wantHeader:  true,
+			wantLicense: "NONE",
+		},
+		{name: "not a package page", body: `<title>Just a moment...</title>`, wantLicense: "NONE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := parseUnitPage([]byte(tt.body))
+			if page.header != tt.wantHeader {
+				t.Errorf("expected header %v, got %v", tt.wantHeader, page.header)
+			}
+			if got := page.license(); got != tt.wantLicense {
+				t.Errorf("expected license %q, got %q", tt.wantLicense, got)
+			}
+		})
+	}
+
+	page := parseUnitPage([]byte(unitHeader))
+	if !slices.Equal(page.licenses, []string{"Apache-2.0"}) || page.title == "" {
+		t.Errorf("unexpected page %+v", page)
+	}
+	if want := time.Date(2023, 11, 4, 0, 0, 0, 0, time.UTC); page.version != "v1.8.0" || !page.published.Equal(want) {
+		t.Errorf("expected v1.8.0 published on %v, got %q published on %v", want, page.version, page.published)
+	}
+	if page.imports == nil || *page.imports != 14 {
+		t.Errorf("expected 14 imports, got %v", page.imports)
+	}
+	if !page.redistributable() {
+		t.Error("expected a package with a license to be redistributable")
+	}
+	if page := parseUnitPage([]byte(`<span data-test-id="UnitHeader-licenses"></span>`)); page.imports != nil {
+		t.Errorf("expected unknown imports without the imports detail, got %d", *page.imports)
+	}
+}
+
+func TestUnitPageRedistributable(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "license", body: unitHeader, want: true},
+		{
+			name: "docs hidden",
+			body: `<span data-test-id="UnitHeader-licenses"><span>License: </span><a data-test-id="UnitHeader-license">UNKNOWN</a></span>` +
+				`<section class="Documentation"><p>Documentation not displayed due to license restrictions.</p>` +
+				`<p>See our <a href="/license-policy">license policy</a>.</p></section>`,
+		},
+		{
+			name: "none detected",
+			body: `<span data-test-id="UnitHeader-licenses"><span>License: </span><span>None detected</span></span>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseUnitPage([]byte(tt.body)).redistributable(); got != tt.want {
+				t.Errorf("expected redistributable %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
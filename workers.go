@@ -0,0 +1,47 @@
+package pkgimporters
+
+import (
+	"math"
+	"time"
+)
+
+// AutoWorkers, set as Client.Workers, sizes the worker pool of ImporterCounts and Stream
+// to what the rate limit can keep busy: the configured requests per second
+// times the measured latency of a request, plus one.
+// The pool starts with Burst workers and grows, up to MaxAutoWorkers, as latencies are measured.
+const AutoWorkers = -1
+
+// MaxAutoWorkers caps the number of workers chosen by AutoWorkers.
+const MaxAutoWorkers = 64
+
+// meanJitter is the mean random delay that wait adds to each request.
+const meanJitter = 125 * time.Millisecond
+
+// latencyWeight is the weight of a new sample in the moving average of request latency.
+const latencyWeight = 0.2
+
+// observeLatency adds the latency of a request to the moving average used by AutoWorkers.
+func (c *Client) observeLatency(d time.Duration) {
+	for {
+		old := c.latency.Load()
+		avg := int64(d)
+		if old != 0 {
+			avg = int64(latencyWeight*float64(d) + (1-latencyWeight)*float64(old))
+		}
+		if c.latency.CompareAndSwap(old, avg) {
+			return
+		}
+	}
+}
+
+// autoWorkers returns the number of workers needed to keep the rate limit busy
+// at the measured latency, or the burst size before any latency is measured.
+func (c *Client) autoWorkers() int {
+	c.init()
+	latency := time.Duration(c.latency.Load())
+	if latency == 0 {
+		return min(c.limiter.Burst(), MaxAutoWorkers)
+	}
+	n := int(math.Ceil(float64(c.maxLimit())*(latency+meanJitter).Seconds())) + 1
+	return min(n, MaxAutoWorkers)
+}
@@ -0,0 +1,63 @@
+package pkgimporters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientAutoWorkers(t *testing.T) {
+	c := &Client{RequestsPerSecond: 10, Burst: 2}
+	if got := c.autoWorkers(); got != 2 {
+		t.Errorf("expected the burst size before measuring latency, got %d", got)
+	}
+	c.observeLatency(time.Second)
+	if got := c.autoWorkers(); got != 13 {
+		t.Errorf("expected 13 workers at 10 rps and 1s latency, got %d", got)
+	}
+	c.observeLatency(0)
+	if got := c.autoWorkers(); got != 11 {
+		t.Errorf("expected the moving average to lower the workers to 11, got %d", got)
+	}
+
+	fast := &Client{RequestsPerSecond: 1000}
+	fast.observeLatency(time.Second)
+	if got := fast.autoWorkers(); got != MaxAutoWorkers {
+		t.Errorf("expected %d workers, got %d", MaxAutoWorkers, got)
+	}
+}
+
+func TestClientStreamAutoWorkers(t *testing.T) {
+	var mu sync.Mutex
+	var running, maxRunning int
+	c := &Client{
+		Source: sourceFunc(func(ctx context.Context, pkgPath string) (int, error) {
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+			time.Sleep(200 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return 1, nil
+		}),
+		RequestsPerSecond: 50,
+		Burst:             1,
+		Workers:           AutoWorkers,
+	}
+	pkgPaths := make([]string, 30)
+	for i := range pkgPaths {
+		pkgPaths[i] = fmt.Sprintf("example.com/pkg%d", i)
+	}
+	for _, err := range c.Stream(t.Context(), pkgPaths) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if maxRunning <= 1 {
+		t.Errorf("expected the pool to grow beyond the burst size, got at most %d concurrent requests", maxRunning)
+	}
+}